@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"ufpeerassist/backend/api/storage"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// attachmentsCollection is the MongoDB collection for photo metadata; the files live in blobStore
+var attachmentsCollection *mongo.Collection
+
+// blobStore holds the uploaded photos and thumbnails
+var blobStore storage.BlobStore
+
+// uploadDir is where the local blob store keeps uploads
+const uploadDir = "uploads"
+
+// Photo limits per task
+const (
+	maxTaskPhotos       = 6 // Photos the poster can add to a task
+	maxCompletionPhotos = 4 // Proof photos each worker can add
+	maxCaptionLength    = 200
+)
+
+// InitAttachmentsCollection initializes the attachments collection and the local blob store
+func InitAttachmentsCollection() {
+	store, err := storage.NewLocalStore(uploadDir)
+	if err != nil {
+		log.Fatal("❌ Failed to open the upload directory:", err)
+	}
+	blobStore = store
+
+	if client != nil {
+		db := client.Database(databaseName)
+		attachmentsCollection = db.Collection("attachments")
+
+		// Index on task_id, kind and created_at for listing and counting a task's photos
+		taskIndex := mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "task_id", Value: 1},
+				{Key: "kind", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+		}
+
+		_, err := attachmentsCollection.Indexes().CreateOne(context.TODO(), taskIndex)
+		if err != nil {
+			fmt.Println("Error creating attachments task index:", err)
+		}
+
+		fmt.Println("Attachments collection initialized!")
+	}
+}
+
+// UploadTaskPhoto lets the poster add a photo to their task (multipart field "photo")
+func UploadTaskPhoto(c *gin.Context) {
+	task, ok := findAttachmentTask(c)
+	if !ok {
+		return
+	}
+	email := c.Param("email")
+
+	if task.CreatorEmail != email {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the poster can add photos to a task"})
+		return
+	}
+
+	if task.Status == models.Completed || task.Status == models.Cancelled || task.Status == models.Expired {
+		c.JSON(http.StatusConflict, gin.H{"error": "Photos can't be added to a " + strings.ToLower(string(task.Status)) + " task"})
+		return
+	}
+
+	saveAttachment(c, task, models.AttachmentTask, bson.M{"task_id": task.ID, "kind": models.AttachmentTask}, maxTaskPhotos)
+}
+
+// UploadCompletionPhoto lets a selected worker add proof of finished work (multipart field
+// "photo") while their part of the task is still open, typically just before ending it
+func UploadCompletionPhoto(c *gin.Context) {
+	task, ok := findAttachmentTask(c)
+	if !ok {
+		return
+	}
+	email := c.Param("email")
+
+	if !contains(task.SelectedUsers, email) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only workers on this task can add completion photos"})
+		return
+	}
+
+	count, err := scheduledTasksCollection.CountDocuments(context.TODO(), activeAssignmentFilter(task.ID, email))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check your assignment"})
+		return
+	}
+	if count == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Your part of this task is already closed"})
+		return
+	}
+
+	filter := bson.M{"task_id": task.ID, "kind": models.AttachmentCompletion, "uploader_email": email}
+	saveAttachment(c, task, models.AttachmentCompletion, filter, maxCompletionPhotos)
+}
+
+// GetTaskPhotos lists a task's photos. Everyone sees the poster's photos; completion photos
+// are only listed for the poster, the worker who took them and moderators.
+func GetTaskPhotos(c *gin.Context) {
+	task, ok := findAttachmentTask(c)
+	if !ok {
+		return
+	}
+	email := c.Param("email")
+
+	filter := bson.M{"task_id": task.ID}
+	switch {
+	case email == task.CreatorEmail || isModerator(email):
+	case contains(task.SelectedUsers, email):
+		filter["$or"] = []bson.M{
+			{"kind": models.AttachmentTask},
+			{"kind": models.AttachmentCompletion, "uploader_email": email},
+		}
+	default:
+		filter["kind"] = models.AttachmentTask
+	}
+
+	cursor, err := attachmentsCollection.Find(context.TODO(), filter, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve photos", "details": err.Error()})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	photos := []models.Attachment{}
+	if err := cursor.All(context.TODO(), &photos); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode photos", "details": err.Error()})
+		return
+	}
+
+	taskPhotos := []gin.H{}
+	completionPhotos := []gin.H{}
+	for _, photo := range photos {
+		entry := gin.H{
+			"photo":         photo,
+			"url":           fmt.Sprintf("/photos/%s/%s", photo.ID.Hex(), email),
+			"thumbnail_url": fmt.Sprintf("/photos/%s/%s?size=thumb", photo.ID.Hex(), email),
+		}
+		if photo.Kind == models.AttachmentCompletion {
+			completionPhotos = append(completionPhotos, entry)
+		} else {
+			taskPhotos = append(taskPhotos, entry)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"task_id":           task.ID,
+		"task_photos":       taskPhotos,
+		"completion_photos": completionPhotos,
+		"count":             len(photos),
+	})
+}
+
+// GetPhoto streams a photo, or its thumbnail with ?size=thumb
+func GetPhoto(c *gin.Context) {
+	photo, task, ok := findPhoto(c)
+	if !ok {
+		return
+	}
+	email := c.Param("email")
+
+	if photo.Kind == models.AttachmentCompletion &&
+		email != task.CreatorEmail && email != photo.UploaderEmail && !isModerator(email) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view this photo"})
+		return
+	}
+
+	key, contentType := photo.BlobKey, photo.ContentType
+	if c.Query("size") == "thumb" {
+		key, contentType = photo.ThumbnailKey, photo.ThumbnailType
+	}
+
+	blob, err := blobStore.Get(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Photo file not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read photo", "details": err.Error()})
+		return
+	}
+	defer blob.Close()
+
+	// Photos never change once uploaded
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, -1, contentType, blob, nil)
+}
+
+// DeletePhoto lets the uploader remove their photo while the task is still running
+func DeletePhoto(c *gin.Context) {
+	photo, task, ok := findPhoto(c)
+	if !ok {
+		return
+	}
+	email := c.Param("email")
+
+	if photo.UploaderEmail != email {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the uploader can remove this photo"})
+		return
+	}
+
+	// Completion photos are evidence once the task is settled
+	if photo.Kind == models.AttachmentCompletion && (task.Status == models.Completed || task.Status == models.Disputed) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Completion photos can't be removed once the task is " + strings.ToLower(string(task.Status))})
+		return
+	}
+
+	_, err := attachmentsCollection.DeleteOne(context.TODO(), bson.M{"_id": photo.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove photo", "details": err.Error()})
+		return
+	}
+
+	for _, key := range []string{photo.BlobKey, photo.ThumbnailKey} {
+		if err := blobStore.Delete(key); err != nil {
+			log.Printf("Failed to delete blob %s: %v\n", key, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Photo removed"})
+}
+
+// saveAttachment reads the uploaded photo, checks it against the limit for countFilter,
+// processes it and stores it. It writes the response.
+func saveAttachment(c *gin.Context, task models.Task, kind models.AttachmentKind, countFilter bson.M, limit int) {
+	email := c.Param("email")
+
+	count, err := attachmentsCollection.CountDocuments(context.TODO(), countFilter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count photos"})
+		return
+	}
+	if count >= int64(limit) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("You can add up to %d photos here", limit)})
+		return
+	}
+
+	caption := strings.TrimSpace(c.PostForm("caption"))
+	if len(caption) > maxCaptionLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Caption must be at most %d characters", maxCaptionLength)})
+		return
+	}
+
+	data, ok := readPhotoUpload(c)
+	if !ok {
+		return
+	}
+
+	photo, err := utils.ProcessPhoto(data)
+	if err != nil {
+		c.JSON(photoErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	attachment := models.Attachment{
+		ID:            primitive.NewObjectID(),
+		TaskID:        task.ID,
+		Kind:          kind,
+		UploaderEmail: email,
+		ContentType:   photo.ContentType,
+		Size:          len(photo.Data),
+		Width:         photo.Width,
+		Height:        photo.Height,
+		ThumbnailType: photo.ThumbnailType,
+		Caption:       caption,
+		CreatedAt:     time.Now(),
+	}
+	attachment.BlobKey = photoBlobKey(attachment, "", photo.ContentType)
+	attachment.ThumbnailKey = photoBlobKey(attachment, "_thumb", photo.ThumbnailType)
+
+	if err := blobStore.Put(attachment.BlobKey, bytes.NewReader(photo.Data)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store photo", "details": err.Error()})
+		return
+	}
+	if err := blobStore.Put(attachment.ThumbnailKey, bytes.NewReader(photo.Thumbnail)); err != nil {
+		blobStore.Delete(attachment.BlobKey)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store thumbnail", "details": err.Error()})
+		return
+	}
+
+	if _, err := attachmentsCollection.InsertOne(context.TODO(), attachment); err != nil {
+		blobStore.Delete(attachment.BlobKey)
+		blobStore.Delete(attachment.ThumbnailKey)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save photo", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":       "Photo uploaded",
+		"photo":         attachment,
+		"url":           fmt.Sprintf("/photos/%s/%s", attachment.ID.Hex(), email),
+		"thumbnail_url": fmt.Sprintf("/photos/%s/%s?size=thumb", attachment.ID.Hex(), email),
+	})
+}
+
+// readPhotoUpload reads the "photo" file from a multipart request, refusing oversized bodies
+// before they are buffered
+func readPhotoUpload(c *gin.Context) ([]byte, bool) {
+	// Leave room for the multipart framing and caption around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, utils.MaxPhotoBytes+64<<10)
+
+	header, err := c.FormFile("photo")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": utils.ErrPhotoTooLarge.Error()})
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Attach the image as the \"photo\" form field"})
+		return nil, false
+	}
+	if header.Size > utils.MaxPhotoBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": utils.ErrPhotoTooLarge.Error()})
+		return nil, false
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read the uploaded photo"})
+		return nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, utils.MaxPhotoBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read the uploaded photo"})
+		return nil, false
+	}
+	return data, true
+}
+
+// photoErrorStatus maps a photo processing error to its HTTP status
+func photoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrPhotoTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, utils.ErrUnsupportedPhotoType):
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// photoBlobKey names a photo's file in the blob store
+func photoBlobKey(attachment models.Attachment, suffix, contentType string) string {
+	extension := ".png"
+	if contentType == "image/jpeg" {
+		extension = ".jpg"
+	}
+	return fmt.Sprintf("tasks/%s/%s%s%s", attachment.TaskID.Hex(), attachment.ID.Hex(), suffix, extension)
+}
+
+// findAttachmentTask loads the task named in the URL, writing the error response when it can't
+func findAttachmentTask(c *gin.Context) (models.Task, bool) {
+	var task models.Task
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("task_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return task, false
+	}
+
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return task, false
+	}
+	return task, true
+}
+
+// findPhoto loads the photo named in the URL and its task, writing the error response when it can't
+func findPhoto(c *gin.Context) (models.Attachment, models.Task, bool) {
+	var photo models.Attachment
+	var task models.Task
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("photo_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo ID format"})
+		return photo, task, false
+	}
+
+	err = attachmentsCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&photo)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Photo not found"})
+		return photo, task, false
+	}
+
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": photo.TaskID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return photo, task, false
+	}
+	return photo, task, true
+}
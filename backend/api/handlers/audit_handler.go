@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+	"ufpeerassist/backend/api/middleware"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// taskEventsCollection is the append-only MongoDB collection of task lifecycle events
+var taskEventsCollection *mongo.Collection
+
+// auditIgnoredFields are task fields that change too often or carry no meaning for a dispute
+var auditIgnoredFields = map[string]bool{
+	"views":               true,
+	"updated_at":          true,
+	"assignment_revision": true,
+}
+
+// auditPrivateFields are task fields only the poster and moderators see in the history, since
+// they name other workers or pin down where the poster is
+var auditPrivateFields = []string{"applicants", "selected_users", "pending_confirmations", "place_of_work", "coordinates"}
+
+// InitTaskEventsCollection initializes the task events collection
+func InitTaskEventsCollection() {
+	if client != nil {
+		db := client.Database(databaseName)
+		taskEventsCollection = db.Collection("task_events")
+
+		// Index on task_id and timestamp for reading a task's history in order
+		indexModel := mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "task_id", Value: 1},
+				{Key: "timestamp", Value: 1},
+			},
+		}
+
+		_, err := taskEventsCollection.Indexes().CreateOne(context.TODO(), indexModel)
+		if err != nil {
+			fmt.Println("Error creating task_events index:", err)
+		}
+
+		fmt.Println("Task events collection initialized!")
+	}
+}
+
+// systemActor is recorded as the actor of events caused by background jobs
+const systemActor = "system"
+
+// recordTaskEvent appends an event to the task's audit log. Snapshots of the task before
+// and after the mutation are reduced to the fields that changed. Failures are logged and
+// never fail the request that caused the event. Background jobs pass a nil context.
+func recordTaskEvent(c *gin.Context, taskID primitive.ObjectID, eventType models.TaskEventType, actor string, before, after *models.Task, details bson.M) {
+	requestID := ""
+	if c != nil {
+		requestID = c.GetString(middleware.RequestIDKey)
+	}
+
+	beforeDiff, afterDiff, err := diffTasks(before, after)
+	if err != nil {
+		fmt.Printf("Error computing diff for task %s event %s: %v\n", taskID.Hex(), eventType, err)
+	}
+
+	event := models.TaskEvent{
+		TaskID:    taskID,
+		Type:      eventType,
+		Actor:     actor,
+		Timestamp: time.Now(),
+		Before:    beforeDiff,
+		After:     afterDiff,
+		Details:   details,
+		RequestID: requestID,
+	}
+
+	if _, err := taskEventsCollection.InsertOne(context.TODO(), event); err != nil {
+		fmt.Printf("Error recording event %s for task %s: %v\n", eventType, taskID.Hex(), err)
+	}
+}
+
+// diffTasks returns the fields that differ between two task snapshots. A nil
+// snapshot is treated as empty, so creation records every field as added.
+func diffTasks(before, after *models.Task) (bson.M, bson.M, error) {
+	beforeDoc, err := taskToDoc(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterDoc, err := taskToDoc(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	beforeDiff := bson.M{}
+	afterDiff := bson.M{}
+
+	for key, value := range afterDoc {
+		if auditIgnoredFields[key] {
+			continue
+		}
+		if old, ok := beforeDoc[key]; !ok || !reflect.DeepEqual(old, value) {
+			if ok {
+				beforeDiff[key] = old
+			}
+			afterDiff[key] = value
+		}
+	}
+	for key, value := range beforeDoc {
+		if auditIgnoredFields[key] {
+			continue
+		}
+		if _, ok := afterDoc[key]; !ok {
+			beforeDiff[key] = value
+		}
+	}
+
+	if len(beforeDiff) == 0 {
+		beforeDiff = nil
+	}
+	if len(afterDiff) == 0 {
+		afterDiff = nil
+	}
+	return beforeDiff, afterDiff, nil
+}
+
+// taskToDoc converts a task to a plain document keyed by its bson field names
+func taskToDoc(task *models.Task) (bson.M, error) {
+	doc := bson.M{}
+	if task == nil {
+		return doc, nil
+	}
+
+	raw, err := bson.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	delete(doc, "_id")
+	return doc, nil
+}
+
+// GetTaskHistory returns the audit log of a task to its poster, selected workers and moderators.
+// Selected workers get it without the fields in auditPrivateFields.
+func GetTaskHistory(c *gin.Context) {
+	taskID := c.Param("task_id")
+	viewerEmail := c.Param("email")
+
+	// Verify that viewer exists
+	var viewer models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": viewerEmail}).Decode(&viewer)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required. User not found."})
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	var task models.Task
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	if task.CreatorEmail != viewerEmail && !contains(task.SelectedUsers, viewerEmail) && viewer.Role != models.UserRoleModerator {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to view this task's history"})
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"timestamp": 1})
+
+	cursor, err := taskEventsCollection.Find(context.TODO(), bson.M{"task_id": objectID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task history", "details": err.Error()})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var events []models.TaskEvent
+	if err := cursor.All(context.TODO(), &events); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode task history", "details": err.Error()})
+		return
+	}
+
+	if events == nil {
+		events = []models.TaskEvent{}
+	}
+
+	// Selected workers see what happened to the task, but not who else applied or where it is
+	if task.CreatorEmail != viewerEmail && viewer.Role != models.UserRoleModerator {
+		for i := range events {
+			redactTaskEvent(&events[i])
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"task_id": taskID,
+		"events":  events,
+		"count":   len(events),
+	})
+}
+
+// redactTaskEvent drops the private fields from an event's before and after snapshots
+func redactTaskEvent(event *models.TaskEvent) {
+	for _, field := range auditPrivateFields {
+		delete(event.Before, field)
+		delete(event.After, field)
+	}
+	if len(event.Before) == 0 {
+		event.Before = nil
+	}
+	if len(event.After) == 0 {
+		event.After = nil
+	}
+}
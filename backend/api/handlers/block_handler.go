@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// blocksCollection is the MongoDB collection for per-user block lists
+var blocksCollection *mongo.Collection
+
+// InitBlocksCollection initializes the blocks collection
+func InitBlocksCollection() {
+	if client != nil {
+		db := client.Database(databaseName)
+		blocksCollection = db.Collection("blocks")
+
+		// A user can block another user only once
+		indexModel := mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "blocker_email", Value: 1},
+				{Key: "blocked_email", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		}
+
+		_, err := blocksCollection.Indexes().CreateOne(context.TODO(), indexModel)
+		if err != nil {
+			fmt.Println("Error creating blocks index:", err)
+		}
+
+		// Index on blocked_email for looking up who blocked a user
+		blockedIndex := mongo.IndexModel{
+			Keys: bson.M{"blocked_email": 1},
+		}
+
+		_, err = blocksCollection.Indexes().CreateOne(context.TODO(), blockedIndex)
+		if err != nil {
+			fmt.Println("Error creating blocked_email index:", err)
+		}
+
+		fmt.Println("Blocks collection initialized!")
+	}
+}
+
+// BlockUser adds a user to the caller's block list
+func BlockUser(c *gin.Context) {
+	email := c.Param("email")
+	blockedEmail := c.Param("blocked_email")
+
+	// Verify that user exists
+	var user models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required. User not found."})
+		return
+	}
+
+	// Verify that the user being blocked exists
+	var blocked models.Users
+	err = usersCollection.FindOne(context.TODO(), bson.M{"email": blockedEmail}).Decode(&blocked)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User to block not found"})
+		return
+	}
+
+	if email == blockedEmail {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot block yourself"})
+		return
+	}
+
+	_, err = blocksCollection.InsertOne(context.TODO(), models.UserBlock{
+		BlockerEmail: email,
+		BlockedEmail: blockedEmail,
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "User is already blocked"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block user", "details": err.Error()})
+		return
+	}
+
+	// Drop the blocked user's pending applications on my open tasks
+	_, err = tasksCollection.UpdateMany(
+		context.TODO(),
+		bson.M{
+			"creator_email":  email,
+			"status":         models.Open,
+			"selected_users": bson.M{"$ne": blockedEmail},
+		},
+		bson.M{"$pull": bson.M{"applicants": blockedEmail}},
+	)
+	if err != nil {
+		fmt.Printf("Error removing blocked applicant %s from tasks of %s: %v\n", blockedEmail, email, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User blocked successfully",
+	})
+}
+
+// UnblockUser removes a user from the caller's block list
+func UnblockUser(c *gin.Context) {
+	email := c.Param("email")
+	blockedEmail := c.Param("blocked_email")
+
+	result, err := blocksCollection.DeleteOne(
+		context.TODO(),
+		bson.M{"blocker_email": email, "blocked_email": blockedEmail},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unblock user", "details": err.Error()})
+		return
+	}
+
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not blocked"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User unblocked successfully",
+	})
+}
+
+// GetBlockedUsers returns the users the caller has blocked
+func GetBlockedUsers(c *gin.Context) {
+	email := c.Param("email")
+
+	// Verify that user exists
+	var user models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required. User not found."})
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"created_at": -1})
+
+	cursor, err := blocksCollection.Find(context.TODO(), bson.M{"blocker_email": email}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve blocked users", "details": err.Error()})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var blocks []models.UserBlock
+	if err := cursor.All(context.TODO(), &blocks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode blocked users", "details": err.Error()})
+		return
+	}
+
+	if blocks == nil {
+		blocks = []models.UserBlock{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"blocked_users": blocks,
+		"count":         len(blocks),
+	})
+}
+
+// blockedCounterparts returns every user that has blocked, or been blocked by, the given user
+func blockedCounterparts(email string) ([]string, error) {
+	cursor, err := blocksCollection.Find(context.TODO(), bson.M{
+		"$or": []bson.M{
+			{"blocker_email": email},
+			{"blocked_email": email},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var blocks []models.UserBlock
+	if err := cursor.All(context.TODO(), &blocks); err != nil {
+		return nil, err
+	}
+
+	counterparts := []string{}
+	for _, block := range blocks {
+		if block.BlockerEmail == email {
+			counterparts = append(counterparts, block.BlockedEmail)
+		} else {
+			counterparts = append(counterparts, block.BlockerEmail)
+		}
+	}
+	return counterparts, nil
+}
+
+// isBlockedBetween reports whether either user has blocked the other
+func isBlockedBetween(emailA, emailB string) (bool, error) {
+	count, err := blocksCollection.CountDocuments(context.TODO(), bson.M{
+		"$or": []bson.M{
+			{"blocker_email": emailA, "blocked_email": emailB},
+			{"blocker_email": emailB, "blocked_email": emailA},
+		},
+	})
+	return count > 0, err
+}
@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// bookmarksCollection is the MongoDB collection for tasks users shortlisted
+var bookmarksCollection *mongo.Collection
+
+// Bookmark limits
+const (
+	maxBookmarks         = 100
+	bookmarkExpiryNotice = 24 * time.Hour // How long before an open task starts bookmarkers are warned
+)
+
+// BookmarkNotifier delivers an update on a bookmarked task to one bookmarker
+type BookmarkNotifier func(email string, task models.Task, message string) error
+
+// notifyBookmarker emails bookmark updates; tests swap it out through SendBookmarkExpiryAlertsWith
+var notifyBookmarker BookmarkNotifier = func(email string, task models.Task, message string) error {
+	return utils.SendBookmarkNotification(email, task.Title, task.ID.Hex(), message)
+}
+
+// InitBookmarksCollection initializes the bookmarks collection
+func InitBookmarksCollection() {
+	if client != nil {
+		db := client.Database(databaseName)
+		bookmarksCollection = db.Collection("bookmarks")
+
+		// A user can bookmark a task only once
+		indexModel := mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "email", Value: 1},
+				{Key: "task_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		}
+
+		_, err := bookmarksCollection.Indexes().CreateOne(context.TODO(), indexModel)
+		if err != nil {
+			fmt.Println("Error creating bookmarks index:", err)
+		}
+
+		// Index on task_id for notifying a task's bookmarkers
+		taskIndex := mongo.IndexModel{
+			Keys: bson.M{"task_id": 1},
+		}
+
+		_, err = bookmarksCollection.Indexes().CreateOne(context.TODO(), taskIndex)
+		if err != nil {
+			fmt.Println("Error creating bookmarks task_id index:", err)
+		}
+
+		fmt.Println("Bookmarks collection initialized!")
+	}
+}
+
+// AddBookmark shortlists an open task for a user without applying
+func AddBookmark(c *gin.Context) {
+	email := c.Param("email")
+
+	var user models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required. User not found."})
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("task_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	var task models.Task
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID, "hidden": bson.M{"$ne": true}}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	if task.CreatorEmail == email {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot bookmark your own task"})
+		return
+	}
+
+	if task.Status != models.Open {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only open tasks can be bookmarked"})
+		return
+	}
+
+	blocked, err := isBlockedBetween(task.CreatorEmail, email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bookmark task", "details": err.Error()})
+		return
+	}
+	if blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot bookmark this task"})
+		return
+	}
+
+	count, err := bookmarksCollection.CountDocuments(context.TODO(), bson.M{"email": email})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count bookmarks"})
+		return
+	}
+	if count >= maxBookmarks {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("You can bookmark up to %d tasks", maxBookmarks)})
+		return
+	}
+
+	bookmark := models.Bookmark{
+		Email:     email,
+		TaskID:    objectID,
+		CreatedAt: time.Now(),
+	}
+
+	result, err := bookmarksCollection.InsertOne(context.TODO(), bookmark)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Task is already bookmarked"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bookmark task", "details": err.Error()})
+		return
+	}
+	bookmark.ID = result.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Task bookmarked",
+		"bookmark": bookmark,
+	})
+}
+
+// RemoveBookmark takes a task off the user's shortlist
+func RemoveBookmark(c *gin.Context) {
+	email := c.Param("email")
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("task_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	result, err := bookmarksCollection.DeleteOne(context.TODO(), bson.M{"email": email, "task_id": objectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove bookmark", "details": err.Error()})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark removed"})
+}
+
+// GetBookmarks lists a user's bookmarked tasks, newest bookmark first. Tasks that are no longer
+// open stay listed with their status so the user can tidy them up.
+func GetBookmarks(c *gin.Context) {
+	email := c.Param("email")
+
+	cursor, err := bookmarksCollection.Find(
+		context.TODO(),
+		bson.M{"email": email},
+		options.Find().SetSort(bson.M{"created_at": -1}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bookmarks", "details": err.Error()})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var bookmarks []models.Bookmark
+	if err := cursor.All(context.TODO(), &bookmarks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode bookmarks", "details": err.Error()})
+		return
+	}
+
+	taskIDs := []primitive.ObjectID{}
+	for _, bookmark := range bookmarks {
+		taskIDs = append(taskIDs, bookmark.TaskID)
+	}
+
+	tasksByID := map[primitive.ObjectID]models.Task{}
+	if len(taskIDs) > 0 {
+		tasks, err := findTasks(bson.M{"_id": bson.M{"$in": taskIDs}, "hidden": bson.M{"$ne": true}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks", "details": err.Error()})
+			return
+		}
+		for _, task := range tasks {
+			tasksByID[task.ID] = task
+		}
+	}
+
+	now := time.Now()
+	results := []gin.H{}
+	for _, bookmark := range bookmarks {
+		task, ok := tasksByID[bookmark.TaskID]
+		if !ok {
+			continue
+		}
+		redactTaskLocation(&task, email, now)
+		task.Bookmarked = true
+		results = append(results, gin.H{
+			"bookmarked_at": bookmark.CreatedAt,
+			"task":          task,
+			"has_applied":   contains(task.Applicants, email),
+			"spots_left":    task.SpotsLeft(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bookmarks": results,
+		"count":     len(results),
+	})
+}
+
+// bookmarkedTaskIDs returns which of the given tasks a user has bookmarked
+func bookmarkedTaskIDs(email string, taskIDs []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	bookmarked := map[primitive.ObjectID]bool{}
+	if bookmarksCollection == nil || len(taskIDs) == 0 {
+		return bookmarked, nil
+	}
+
+	cursor, err := bookmarksCollection.Find(
+		context.TODO(),
+		bson.M{"email": email, "task_id": bson.M{"$in": taskIDs}},
+		options.Find().SetProjection(bson.M{"task_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var bookmarks []models.Bookmark
+	if err := cursor.All(context.TODO(), &bookmarks); err != nil {
+		return nil, err
+	}
+	for _, bookmark := range bookmarks {
+		bookmarked[bookmark.TaskID] = true
+	}
+	return bookmarked, nil
+}
+
+// notifyBookmarkers tells everyone who bookmarked a task about it, skipping the given users.
+// One-off alerts are claimed per bookmark first so each user hears about them only once.
+func notifyBookmarkers(task models.Task, alert models.BookmarkAlert, message string, skip []string) {
+	if bookmarksCollection == nil {
+		return
+	}
+
+	filter := bson.M{"task_id": task.ID, "email": bson.M{"$nin": append(append([]string{}, skip...), task.CreatorEmail)}}
+	claimField := ""
+	switch alert {
+	case models.BookmarkNearlyFull:
+		claimField = "nearly_full_notified"
+	case models.BookmarkExpiring:
+		claimField = "expiring_notified"
+	}
+	if claimField != "" {
+		filter[claimField] = bson.M{"$ne": true}
+	}
+
+	cursor, err := bookmarksCollection.Find(context.TODO(), filter)
+	if err != nil {
+		log.Printf("Failed to find bookmarks for task %s: %v\n", task.ID.Hex(), err)
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var bookmarks []models.Bookmark
+	if err := cursor.All(context.TODO(), &bookmarks); err != nil {
+		log.Printf("Failed to decode bookmarks for task %s: %v\n", task.ID.Hex(), err)
+		return
+	}
+
+	for _, bookmark := range bookmarks {
+		if claimField != "" {
+			result, err := bookmarksCollection.UpdateOne(
+				context.TODO(),
+				bson.M{"_id": bookmark.ID, claimField: bson.M{"$ne": true}},
+				bson.M{"$set": bson.M{claimField: true}},
+			)
+			if err != nil || result.ModifiedCount == 0 {
+				continue // Failed, or another run already sent it
+			}
+		}
+
+		liveMessages.send(bookmark.Email, gin.H{"type": "bookmark_update", "alert": alert, "task_id": task.ID, "message": message})
+		_ = notifyBookmarker(bookmark.Email, task, message)
+	}
+}
+
+// bookmarkEditIgnoredFields are bookkeeping or private fields left out of edit notices
+var bookmarkEditIgnoredFields = map[string]bool{
+	"version":               true,
+	"pending_confirmations": true,
+	"coordinates":           true,
+}
+
+// notifyBookmarkersOfEdit lists the fields a poster changed for a task's bookmarkers
+func notifyBookmarkersOfEdit(before, after models.Task, skip []string) {
+	beforeDiff, afterDiff, err := diffTasks(&before, &after)
+	if err != nil {
+		log.Printf("Failed to compare task %s for bookmarkers: %v\n", after.ID.Hex(), err)
+		return
+	}
+
+	fields := []string{}
+	for field := range afterDiff {
+		if !bookmarkEditIgnoredFields[field] {
+			fields = append(fields, field)
+		}
+	}
+	for field := range beforeDiff {
+		if _, ok := afterDiff[field]; !ok && !bookmarkEditIgnoredFields[field] {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+	sort.Strings(fields)
+
+	notifyBookmarkers(after, models.BookmarkEdited,
+		fmt.Sprintf("The poster edited a task you bookmarked. Changed: %s.", strings.Join(fields, ", ")), skip)
+}
+
+// StartBookmarkAlertJob warns bookmarkers about tasks about to expire right away and then on every tick, in the background
+func StartBookmarkAlertJob(interval time.Duration) {
+	if bookmarksCollection == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			sendBookmarkExpiryAlerts(time.Now())
+			<-ticker.C
+		}
+	}()
+
+	fmt.Printf("Bookmark alert job started, checking every %s\n", interval)
+}
+
+// SendBookmarkExpiryAlertsWith runs one expiry check at now, delivering through notify instead
+// of email. Tests use it in place of the alert job; it must not run alongside it.
+func SendBookmarkExpiryAlertsWith(now time.Time, notify BookmarkNotifier) {
+	previous := notifyBookmarker
+	notifyBookmarker = notify
+	defer func() { notifyBookmarker = previous }()
+
+	sendBookmarkExpiryAlerts(now)
+}
+
+// sendBookmarkExpiryAlerts warns bookmarkers of open tasks that start soon and will expire if
+// nobody is selected
+func sendBookmarkExpiryAlerts(now time.Time) {
+	tasks, err := findTasks(bson.M{
+		"status":         models.Open,
+		"hidden":         bson.M{"$ne": true},
+		"selected_users": bson.M{"$size": 0}, // Tasks with a worker go ahead rather than expire
+		"start_at":       bson.M{"$gt": now, "$lte": now.Add(bookmarkExpiryNotice)},
+	})
+	if err != nil {
+		log.Printf("Failed to find tasks about to expire: %v\n", err)
+		return
+	}
+
+	for _, task := range tasks {
+		if task.SpotsLeft() == 0 {
+			continue
+		}
+		message := fmt.Sprintf("A task you bookmarked starts %s and is still open with %d spot(s) left. Apply soon if you're interested.",
+			formatTaskStart(task), task.SpotsLeft())
+		// Applicants already know about the task through their applications
+		notifyBookmarkers(task, models.BookmarkExpiring, message, task.Applicants)
+	}
+}
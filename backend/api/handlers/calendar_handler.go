@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetCalendarSubscription returns the user's private iCal subscription URL, creating the token on first use
+func GetCalendarSubscription(c *gin.Context) {
+	email := c.Param("email")
+
+	var user models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	token := user.CalendarToken
+	if token == "" {
+		token = utils.GenerateToken()
+		_, err = usersCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"email": email},
+			bson.M{"$set": bson.M{"calendar_token": token}},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar link", "details": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, calendarLinks(c, token))
+}
+
+// RotateCalendarSubscription replaces the user's calendar token, breaking any previously shared URL
+func RotateCalendarSubscription(c *gin.Context) {
+	email := c.Param("email")
+
+	token := utils.GenerateToken()
+	result, err := usersCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"email": email},
+		bson.M{"$set": bson.M{"calendar_token": token}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate calendar link", "details": err.Error()})
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	links := calendarLinks(c, token)
+	links["message"] = "Calendar link rotated. Update your calendar app with the new URL"
+	c.JSON(http.StatusOK, links)
+}
+
+// GetCalendarFeed serves the iCal feed behind a user's private token: tasks they are
+// scheduled to work on, plus tasks they posted that have selected workers
+func GetCalendarFeed(c *gin.Context) {
+	token := c.Param("token")
+
+	var user models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"calendar_token": token}).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calendar not found"})
+		return
+	}
+
+	// Tasks the user is scheduled to work on
+	cursor, err := scheduledTasksCollection.Find(context.TODO(), bson.M{"worker_email": user.Email})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query scheduled_tasks"})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var scheduledTasks []models.ScheduledTask
+	if err := cursor.All(context.TODO(), &scheduledTasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode scheduled_tasks"})
+		return
+	}
+
+	taskIDs := []primitive.ObjectID{}
+	for _, scheduled := range scheduledTasks {
+		taskIDs = append(taskIDs, scheduled.TaskID)
+	}
+
+	// Plus tasks the user posted that have someone coming
+	filter := bson.M{
+		"$or": []bson.M{
+			{"_id": bson.M{"$in": taskIDs}},
+			{"creator_email": user.Email, "selected_users.0": bson.M{"$exists": true}},
+		},
+	}
+
+	taskCursor, err := tasksCollection.Find(context.TODO(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch full task details"})
+		return
+	}
+	defer taskCursor.Close(context.TODO())
+
+	var tasks []models.Task
+	if err := taskCursor.All(context.TODO(), &tasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode tasks"})
+		return
+	}
+
+	// Workers whose own schedule entry was cancelled see the event as cancelled,
+	// unless they were selected again and hold a live entry for the same task
+	cancelledForWorker := map[primitive.ObjectID]bool{}
+	for _, scheduled := range scheduledTasks {
+		if scheduled.Status != models.Cancelled {
+			cancelledForWorker[scheduled.TaskID] = false
+		} else if _, seen := cancelledForWorker[scheduled.TaskID]; !seen {
+			cancelledForWorker[scheduled.TaskID] = true
+		}
+	}
+
+	events := []utils.CalendarEvent{}
+	for _, task := range tasks {
+		if task.StartAt.IsZero() {
+			continue
+		}
+		event := taskCalendarEvent(task, user.Email)
+		if cancelledForWorker[task.ID] && !event.Cancelled {
+			// The task itself didn't change, so bump the sequence for calendar apps to notice
+			event.Cancelled = true
+			event.Sequence++
+		}
+		events = append(events, event)
+	}
+
+	c.Header("Content-Disposition", "inline; filename=\"ufpeerassist.ics\"")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(utils.BuildICalendar("UFPeerAssist tasks", events)))
+}
+
+// DownloadTaskICS returns a single task as an .ics file for its poster or a selected worker
+func DownloadTaskICS(c *gin.Context) {
+	taskID := c.Param("task_id")
+	email := c.Param("email")
+
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	var task models.Task
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	if task.CreatorEmail != email && !contains(task.SelectedUsers, email) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the poster and selected workers can download this task"})
+		return
+	}
+
+	if task.StartAt.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Task has no start time yet"})
+		return
+	}
+
+	event := taskCalendarEvent(task, email)
+	filename := fmt.Sprintf("task-%s.ics", task.ID.Hex())
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(utils.BuildICalendar(task.Title, []utils.CalendarEvent{event})))
+}
+
+// taskCalendarEvent describes a task as a calendar event from the viewer's point of view
+func taskCalendarEvent(task models.Task, viewerEmail string) utils.CalendarEvent {
+	now := time.Now()
+	var poster models.Users
+	posterErr := usersCollection.FindOne(context.TODO(), bson.M{"email": task.CreatorEmail}).Decode(&poster)
+
+	lines := []string{task.Description, ""}
+	lines = append(lines, fmt.Sprintf("Category: %s", task.WorkType))
+	lines = append(lines, fmt.Sprintf("Pay: $%.2f/hour", task.EstimatedPayRate))
+
+	if viewerEmail == task.CreatorEmail {
+		lines = append(lines, fmt.Sprintf("Workers: %s", strings.Join(task.SelectedUsers, ", ")))
+	} else {
+		contact := task.CreatorEmail
+		if posterErr == nil {
+			contact = fmt.Sprintf("%s <%s>", poster.Name, poster.Email)
+			if mobile := visibleMobile(poster.Mobile, poster.HideMobile, task.ContactWindowOpen(viewerEmail, now)); mobile != "" {
+				contact += ", " + mobile
+			}
+		}
+		lines = append(lines, "Poster: "+contact)
+	}
+
+	// Past and cancelled tasks no longer carry the exact address
+	redactTaskLocation(&task, viewerEmail, now)
+
+	summary := task.Title
+	if viewerEmail != task.CreatorEmail {
+		summary = "Work: " + task.Title
+	}
+
+	return utils.CalendarEvent{
+		UID:            task.ID.Hex() + "@ufpeerassist",
+		Summary:        summary,
+		Description:    strings.Join(lines, "\n"),
+		Location:       task.PlaceOfWork,
+		Start:          task.StartAt,
+		End:            task.EndAt,
+		Cancelled:      task.Status == models.Cancelled,
+		Sequence:       task.Version,
+		LastModified:   task.UpdatedAt,
+		OrganizerName:  poster.Name,
+		OrganizerEmail: task.CreatorEmail,
+	}
+}
+
+// calendarLinks builds the https and webcal subscription URLs for a calendar token
+func calendarLinks(c *gin.Context, token string) gin.H {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	path := fmt.Sprintf("%s/calendar/%s/tasks.ics", c.Request.Host, token)
+
+	return gin.H{
+		"subscription_url": scheme + "://" + path,
+		"webcal_url":       "webcal://" + path,
+	}
+}
@@ -0,0 +1,471 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// commentsCollection is the MongoDB collection for public task Q&A
+var commentsCollection *mongo.Collection
+
+// maxCommentLength caps a single question or answer
+const maxCommentLength = 1000
+
+// InitCommentsCollection initializes the comments collection
+func InitCommentsCollection() {
+	if client != nil {
+		db := client.Database(databaseName)
+		commentsCollection = db.Collection("comments")
+
+		// Index on task_id and created_at for reading a task's Q&A in order
+		taskIndex := mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "task_id", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+		}
+
+		_, err := commentsCollection.Indexes().CreateOne(context.TODO(), taskIndex)
+		if err != nil {
+			fmt.Println("Error creating comments task index:", err)
+		}
+
+		fmt.Println("Comments collection initialized!")
+	}
+}
+
+// GetTaskComments returns the public Q&A on a task. Comments are only shown while the task is
+// Open; moderators can still read them afterwards.
+func GetTaskComments(c *gin.Context) {
+	taskID := c.Param("task_id")
+	viewerEmail := c.Param("email")
+
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	var task models.Task
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	moderator := isModerator(viewerEmail)
+	if task.Status != models.Open && !moderator {
+		c.JSON(http.StatusOK, gin.H{
+			"task_id":  taskID,
+			"comments": []models.TaskComment{},
+			"count":    0,
+			"closed":   true,
+		})
+		return
+	}
+
+	filter := bson.M{"task_id": objectID}
+	if !moderator {
+		filter["hidden"] = false
+		filter["removed_at"] = bson.M{"$exists": false}
+
+		// Leave out comments from anyone the viewer has blocked or been blocked by
+		blocked, err := blockedCounterparts(viewerEmail)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check blocked users", "details": err.Error()})
+			return
+		}
+		if len(blocked) > 0 {
+			filter["author_email"] = bson.M{"$nin": blocked}
+		}
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"created_at": 1})
+
+	cursor, err := commentsCollection.Find(context.TODO(), filter, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments", "details": err.Error()})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	comments := []models.TaskComment{}
+	if err := cursor.All(context.TODO(), &comments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode comments", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"task_id":  taskID,
+		"comments": comments,
+		"count":    len(comments),
+		"closed":   task.Status != models.Open,
+	})
+}
+
+// PostTaskComment lets any student ask a question on an open task, and the poster answer it
+func PostTaskComment(c *gin.Context) {
+	taskID := c.Param("task_id")
+	authorEmail := c.Param("email")
+
+	var author models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": authorEmail}).Decode(&author)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required. User not found."})
+		return
+	}
+
+	var input struct {
+		Body    string `json:"body" binding:"required"`
+		ReplyTo string `json:"reply_to"` // The question being answered
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	body := strings.TrimSpace(input.Body)
+	if body == "" || len(body) > maxCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Comment must be between 1 and %d characters", maxCommentLength)})
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	var task models.Task
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	if task.Status != models.Open || task.Hidden {
+		c.JSON(http.StatusConflict, gin.H{"error": "Questions can only be asked on open tasks"})
+		return
+	}
+
+	blocked, err := isBlockedBetween(authorEmail, task.CreatorEmail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post comment", "details": err.Error()})
+		return
+	}
+	if blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot comment on this task"})
+		return
+	}
+
+	comment := models.TaskComment{
+		TaskID:      objectID,
+		AuthorEmail: authorEmail,
+		AuthorName:  author.Name,
+		Body:        body,
+		FromPoster:  authorEmail == task.CreatorEmail,
+		CreatedAt:   time.Now(),
+	}
+
+	if input.ReplyTo != "" {
+		question, ok := findComment(c, input.ReplyTo)
+		if !ok {
+			return
+		}
+		if question.TaskID != objectID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You can only reply to a question on the same task"})
+			return
+		}
+		comment.ReplyTo = &question.ID
+	}
+
+	result, err := commentsCollection.InsertOne(context.TODO(), comment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post comment", "details": err.Error()})
+		return
+	}
+	comment.ID = result.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Comment posted",
+		"comment": comment,
+	})
+}
+
+// PinTaskComment lets the poster copy one of their answers, with the question it replies to,
+// into the task description so later applicants see it up front
+func PinTaskComment(c *gin.Context) {
+	commentID := c.Param("comment_id")
+	email := c.Param("email")
+
+	answer, ok := findComment(c, commentID)
+	if !ok {
+		return
+	}
+
+	var task models.Task
+	err := tasksCollection.FindOne(context.TODO(), bson.M{"_id": answer.TaskID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	if task.CreatorEmail != email {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the poster can pin answers"})
+		return
+	}
+
+	if !answer.FromPoster || answer.RemovedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only your own answers can be pinned"})
+		return
+	}
+
+	if answer.Hidden {
+		c.JSON(http.StatusConflict, gin.H{"error": "This answer is hidden by moderation and can't be pinned"})
+		return
+	}
+
+	if answer.Pinned {
+		c.JSON(http.StatusConflict, gin.H{"error": "This answer is already pinned"})
+		return
+	}
+
+	if task.Status != models.Open {
+		c.JSON(http.StatusConflict, gin.H{"error": "Answers can only be pinned while the task is open"})
+		return
+	}
+
+	// A question that was moderated away mustn't come back through the description
+	question := ""
+	if answer.ReplyTo != nil {
+		var asked models.TaskComment
+		err := commentsCollection.FindOne(context.TODO(), bson.M{"_id": *answer.ReplyTo}).Decode(&asked)
+		if err != nil && err != mongo.ErrNoDocuments {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find the question", "details": err.Error()})
+			return
+		}
+		if err == mongo.ErrNoDocuments || asked.Hidden || asked.RemovedAt != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "The question this answers has been hidden or removed, so it can't be pinned"})
+			return
+		}
+		question = asked.Body
+	}
+
+	updatedTask := task
+	updatedTask.Description = utils.AppendPinnedAnswer(task.Description, question, answer.Body)
+	updatedTask.UpdatedAt = time.Now()
+	updatedTask.Version = task.Version + 1
+
+	// Guard on the version so a concurrent edit isn't overwritten
+	result, err := tasksCollection.UpdateOne(
+		context.TODO(),
+		taskVersionFilter(task.ID, task.Version),
+		bson.M{
+			"$set": bson.M{"description": updatedTask.Description, "updated_at": updatedTask.UpdatedAt},
+			"$inc": bson.M{"version": 1},
+		},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to pin answer", "details": err.Error()})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Task was modified by someone else. Try again"})
+		return
+	}
+
+	_, err = commentsCollection.UpdateOne(context.TODO(), bson.M{"_id": answer.ID}, bson.M{"$set": bson.M{"pinned": true}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark answer as pinned", "details": err.Error()})
+		return
+	}
+
+	recordTaskEvent(c, task.ID, models.TaskEdited, email, &task, &updatedTask, bson.M{"pinned_comment": answer.ID})
+
+	c.Header("ETag", taskETag(updatedTask.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Answer pinned to the task description",
+		"description": updatedTask.Description,
+		"version":     updatedTask.Version,
+	})
+}
+
+// DeleteTaskComment removes a comment. Authors can remove their own, posters can remove any on
+// their task and moderators can remove any comment.
+func DeleteTaskComment(c *gin.Context) {
+	commentID := c.Param("comment_id")
+	email := c.Param("email")
+
+	comment, ok := findComment(c, commentID)
+	if !ok {
+		return
+	}
+
+	if comment.RemovedAt != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+
+	allowed := comment.AuthorEmail == email || isModerator(email)
+	if !allowed {
+		var task models.Task
+		err := tasksCollection.FindOne(context.TODO(), bson.M{"_id": comment.TaskID}).Decode(&task)
+		allowed = err == nil && task.CreatorEmail == email
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to remove this comment"})
+		return
+	}
+
+	// Keep the document for any open reports against it
+	_, err := commentsCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": comment.ID},
+		bson.M{"$set": bson.M{"removed_by": email, "removed_at": time.Now()}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove comment", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment removed"})
+}
+
+// ReportComment lets a user flag an abusive comment for the moderation queue
+func ReportComment(c *gin.Context) {
+	commentID := c.Param("comment_id")
+	reporterEmail := c.Param("email")
+
+	var reporter models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": reporterEmail}).Decode(&reporter)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required. User not found."})
+		return
+	}
+
+	var input reportInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !isValidReportReason(input.Reason) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report reason", "valid_reasons": models.ValidReportReasons()})
+		return
+	}
+
+	comment, ok := findComment(c, commentID)
+	if !ok {
+		return
+	}
+
+	if comment.AuthorEmail == reporterEmail {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot report your own comment"})
+		return
+	}
+
+	// Only one open report per reporter per comment
+	existing, err := reportsCollection.CountDocuments(context.TODO(), bson.M{
+		"target_type":    models.ReportTargetComment,
+		"comment_id":     comment.ID,
+		"reporter_email": reporterEmail,
+		"status":         models.ReportOpen,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing reports"})
+		return
+	}
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already reported this comment"})
+		return
+	}
+
+	report := models.Report{
+		TargetType:    models.ReportTargetComment,
+		TaskID:        &comment.TaskID,
+		CommentID:     &comment.ID,
+		ReportedEmail: comment.AuthorEmail,
+		ReporterEmail: reporterEmail,
+		Reason:        models.ReportReason(input.Reason),
+		Details:       input.Details,
+		Status:        models.ReportOpen,
+		CreatedAt:     time.Now(),
+	}
+
+	result, err := reportsCollection.InsertOne(context.TODO(), report)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to file report", "details": err.Error()})
+		return
+	}
+
+	hidden, err := refreshCommentVisibility(comment.ID)
+	if err != nil {
+		fmt.Printf("Error refreshing visibility for comment %s: %v\n", commentID, err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Report submitted successfully",
+		"report_id": result.InsertedID.(primitive.ObjectID).Hex(),
+		"hidden":    hidden,
+	})
+}
+
+// refreshCommentVisibility hides a comment once it reaches the open report threshold and shows
+// it again when it drops below, unless a moderator has already actioned it
+func refreshCommentVisibility(commentID primitive.ObjectID) (bool, error) {
+	openReports, err := reportsCollection.CountDocuments(context.TODO(), bson.M{
+		"target_type": models.ReportTargetComment,
+		"comment_id":  commentID,
+		"status":      models.ReportOpen,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	actioned, err := reportsCollection.CountDocuments(context.TODO(), bson.M{
+		"target_type": models.ReportTargetComment,
+		"comment_id":  commentID,
+		"status":      models.ReportActionTaken,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	hidden := openReports >= reportAutoHideThreshold || actioned > 0
+	_, err = commentsCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": commentID},
+		bson.M{"$set": bson.M{"hidden": hidden}},
+	)
+	return hidden, err
+}
+
+// findComment loads a comment by its hex ID, writing the error response when it can't
+func findComment(c *gin.Context, commentID string) (models.TaskComment, bool) {
+	var comment models.TaskComment
+
+	objectID, err := primitive.ObjectIDFromHex(commentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID format"})
+		return comment, false
+	}
+
+	err = commentsCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&comment)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return comment, false
+	}
+	return comment, true
+}
@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// invitationsCollection is the MongoDB collection for directory invitations
+var invitationsCollection *mongo.Collection
+
+// Directory limits
+const (
+	directoryDefaultLimit = 50
+	directoryMaxLimit     = 100
+	maxInvitesPerTask     = 20 // Keeps a poster from inviting the whole directory
+	maxInviteMessage      = 500
+)
+
+// InitInvitationsCollection initializes the invitations collection
+func InitInvitationsCollection() {
+	if client != nil {
+		db := client.Database(databaseName)
+		invitationsCollection = db.Collection("invitations")
+
+		// A worker is invited to a task at most once
+		uniqueIndex := mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "task_id", Value: 1},
+				{Key: "worker_email", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		}
+
+		_, err := invitationsCollection.Indexes().CreateOne(context.TODO(), uniqueIndex)
+		if err != nil {
+			fmt.Println("Error creating invitations unique index:", err)
+		}
+
+		// Index on worker_email and created_at for a worker's invitation list
+		workerIndex := mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "worker_email", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+		}
+
+		_, err = invitationsCollection.Indexes().CreateOne(context.TODO(), workerIndex)
+		if err != nil {
+			fmt.Println("Error creating invitations worker index:", err)
+		}
+
+		fmt.Println("Invitations collection initialized!")
+	}
+}
+
+// SearchDirectory lists users who opted in to the worker directory. Filters: skill,
+// min_rating, min_completed and available_at (a local start time like 2025-04-26T10:00), or
+// task_id to check availability against one of the viewer's tasks.
+func SearchDirectory(c *gin.Context) {
+	viewerEmail := c.Param("email")
+
+	var viewer models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": viewerEmail}).Decode(&viewer)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required. User not found."})
+		return
+	}
+
+	blocked, err := blockedCounterparts(viewerEmail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check blocked users", "details": err.Error()})
+		return
+	}
+
+	filter := bson.M{
+		"in_directory": true,
+		"email":        bson.M{"$ne": viewerEmail, "$nin": blocked},
+	}
+
+	if skill := c.Query("skill"); skill != "" {
+		if !isValidWorkType(skill) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid skill", "valid_skills": models.ValidTaskCategories()})
+			return
+		}
+		filter["skills"] = skill
+	}
+
+	if value := c.Query("min_completed"); value != "" {
+		minCompleted, err := strconv.Atoi(value)
+		if err != nil || minCompleted < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_completed must be a non-negative number"})
+			return
+		}
+		filter["completed_tasks"] = bson.M{"$gte": minCompleted}
+	}
+
+	// Ratings are stored as text, so the minimum is applied after the query
+	minRating := -1.0
+	if value := c.Query("min_rating"); value != "" {
+		minRating, err = strconv.ParseFloat(value, 64)
+		if err != nil || minRating < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_rating must be a non-negative number"})
+			return
+		}
+	}
+
+	availableAt, ok := directoryAvailability(c, viewerEmail)
+	if !ok {
+		return
+	}
+
+	limit := directoryDefaultLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > directoryMaxLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", directoryMaxLimit)})
+			return
+		}
+	}
+
+	cursor, err := usersCollection.Find(context.TODO(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search the directory", "details": err.Error()})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var users []models.Users
+	if err := cursor.All(context.TODO(), &users); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode users", "details": err.Error()})
+		return
+	}
+
+	matches := []models.Users{}
+	for _, user := range users {
+		if minRating >= 0 {
+			rating, rated := user.RatingValue()
+			if !rated || rating < minRating {
+				continue
+			}
+		}
+		if availableAt != nil && !user.AvailableAt(*availableAt) {
+			continue
+		}
+		matches = append(matches, user)
+	}
+
+	// Most experienced first, then best rated
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].CompletedTasks != matches[j].CompletedTasks {
+			return matches[i].CompletedTasks > matches[j].CompletedTasks
+		}
+		ri, _ := matches[i].RatingValue()
+		rj, _ := matches[j].RatingValue()
+		return ri > rj
+	})
+
+	total := len(matches)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	profiles := []models.PublicProfile{}
+	for _, user := range matches {
+		profiles = append(profiles, user.PublicProfile())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"workers": profiles,
+		"count":   len(profiles),
+		"total":   total,
+	})
+}
+
+// directoryAvailability works out the moment the directory should check availability for,
+// from task_id or available_at. It returns nil when neither is given and writes the error
+// response when a value is invalid.
+func directoryAvailability(c *gin.Context, viewerEmail string) (*time.Time, bool) {
+	if taskID := c.Query("task_id"); taskID != "" {
+		objectID, err := primitive.ObjectIDFromHex(taskID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+			return nil, false
+		}
+
+		var task models.Task
+		err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID, "creator_email": viewerEmail}).Decode(&task)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found or you don't have permission to search for it"})
+			return nil, false
+		}
+		if task.StartAt.IsZero() {
+			return nil, true
+		}
+
+		loc, err := utils.LoadTaskLocation(task.TimeZone)
+		if err != nil {
+			loc, _ = utils.LoadTaskLocation("")
+		}
+		start := task.StartAt.In(loc)
+		return &start, true
+	}
+
+	if value := c.Query("available_at"); value != "" {
+		loc, _ := utils.LoadTaskLocation("")
+		start, err := utils.ParseTaskStart(value, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "available_at must look like 2025-04-26T10:00"})
+			return nil, false
+		}
+		start = start.In(loc)
+		return &start, true
+	}
+
+	return nil, true
+}
+
+// InviteToApply lets a poster invite a worker from the directory to apply for one of their open tasks
+func InviteToApply(c *gin.Context) {
+	taskID := c.Param("task_id")
+	posterEmail := c.Param("email")
+
+	var input struct {
+		WorkerEmail string `json:"worker_email" binding:"required"`
+		Message     string `json:"message"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message := strings.TrimSpace(input.Message)
+	if len(message) > maxInviteMessage {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Message must be at most %d characters", maxInviteMessage)})
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	var task models.Task
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID, "creator_email": posterEmail}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found or you don't have permission to invite for it"})
+		return
+	}
+
+	if task.Status != models.Open || task.Hidden {
+		c.JSON(http.StatusConflict, gin.H{"error": "Workers can only be invited to open tasks"})
+		return
+	}
+
+	var poster models.Users
+	if err := usersCollection.FindOne(context.TODO(), bson.M{"email": posterEmail}).Decode(&poster); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required. User not found."})
+		return
+	}
+
+	// Only workers who chose to be found can be invited
+	var worker models.Users
+	err = usersCollection.FindOne(context.TODO(), bson.M{"email": input.WorkerEmail, "in_directory": true}).Decode(&worker)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Worker not found in the directory"})
+		return
+	}
+
+	if contains(task.Applicants, worker.Email) || contains(task.SelectedUsers, worker.Email) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This worker has already applied"})
+		return
+	}
+
+	blocked, err := isBlockedBetween(posterEmail, worker.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send invitation", "details": err.Error()})
+		return
+	}
+	if blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot invite a blocked user"})
+		return
+	}
+
+	sent, err := invitationsCollection.CountDocuments(context.TODO(), bson.M{"task_id": objectID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count invitations"})
+		return
+	}
+	if sent >= maxInvitesPerTask {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("You can invite up to %d workers per task", maxInvitesPerTask)})
+		return
+	}
+
+	invitation := models.TaskInvitation{
+		TaskID:      objectID,
+		TaskTitle:   task.Title,
+		PosterEmail: posterEmail,
+		WorkerEmail: worker.Email,
+		Message:     message,
+		CreatedAt:   time.Now(),
+	}
+
+	result, err := invitationsCollection.InsertOne(context.TODO(), invitation)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "You have already invited this worker"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send invitation", "details": err.Error()})
+		return
+	}
+	invitation.ID = result.InsertedID.(primitive.ObjectID)
+
+	// Tell the worker right away if they're online, and by email either way
+	liveMessages.send(worker.Email, gin.H{"type": "invitation", "invitation": invitation})
+	go func() {
+		if err := utils.SendTaskInvitation(worker.Email, poster.Name, task.Title, message, taskID); err != nil {
+			log.Printf("Failed to send invitation for task %s to %s: %v\n", taskID, worker.Email, err)
+		}
+	}()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Invitation sent",
+		"invitation": invitation,
+	})
+}
+
+// GetInvitations lists a worker's invitations to tasks that are still open
+func GetInvitations(c *gin.Context) {
+	email := c.Param("email")
+
+	cursor, err := invitationsCollection.Find(
+		context.TODO(),
+		bson.M{"worker_email": email},
+		options.Find().SetSort(bson.M{"created_at": -1}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve invitations", "details": err.Error()})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var invitations []models.TaskInvitation
+	if err := cursor.All(context.TODO(), &invitations); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode invitations", "details": err.Error()})
+		return
+	}
+
+	taskIDs := []primitive.ObjectID{}
+	for _, invitation := range invitations {
+		taskIDs = append(taskIDs, invitation.TaskID)
+	}
+
+	openTasks := map[primitive.ObjectID]models.Task{}
+	if len(taskIDs) > 0 {
+		tasks, err := findTasks(bson.M{"_id": bson.M{"$in": taskIDs}, "status": models.Open, "hidden": bson.M{"$ne": true}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks", "details": err.Error()})
+			return
+		}
+		for _, task := range tasks {
+			openTasks[task.ID] = task
+		}
+	}
+
+	now := time.Now()
+	results := []gin.H{}
+	for _, invitation := range invitations {
+		task, ok := openTasks[invitation.TaskID]
+		if !ok {
+			continue
+		}
+		redactTaskLocation(&task, email, now)
+		results = append(results, gin.H{
+			"invitation":  invitation,
+			"task":        task,
+			"has_applied": contains(task.Applicants, email),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invitations": results,
+		"count":       len(results),
+	})
+}
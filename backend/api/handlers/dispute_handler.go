@@ -0,0 +1,603 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// disputesCollection is the MongoDB collection for completion disputes
+var disputesCollection *mongo.Collection
+
+// maxDisputeAttachments caps the attachments on a single statement
+const maxDisputeAttachments = 5
+
+// InitDisputesCollection initializes the disputes collection
+func InitDisputesCollection() {
+	if client != nil {
+		db := client.Database(databaseName)
+		disputesCollection = db.Collection("disputes")
+
+		// One dispute per worker assignment
+		assignmentIndex := mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "task_id", Value: 1},
+				{Key: "worker_email", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		}
+
+		_, err := disputesCollection.Indexes().CreateOne(context.TODO(), assignmentIndex)
+		if err != nil {
+			fmt.Println("Error creating disputes assignment index:", err)
+		}
+
+		// Index on status and created_at for the moderation queue
+		queueIndex := mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+		}
+
+		_, err = disputesCollection.Indexes().CreateOne(context.TODO(), queueIndex)
+		if err != nil {
+			fmt.Println("Error creating disputes queue index:", err)
+		}
+
+		fmt.Println("Disputes collection initialized!")
+	}
+}
+
+// disputeStatementInput is the body for opening a dispute or adding a statement to one
+type disputeStatementInput struct {
+	Statement   string   `json:"statement" binding:"required"`
+	Attachments []string `json:"attachments"`
+}
+
+// OpenDispute lets a worker who ended their part of a task dispute the poster's refusal to confirm it
+func OpenDispute(c *gin.Context) {
+	taskID := c.Param("task_id")
+	workerEmail := c.Param("email")
+
+	var input disputeStatementInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(input.Attachments) > maxDisputeAttachments {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d attachments per statement", maxDisputeAttachments)})
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	var task models.Task
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	if !contains(task.SelectedUsers, workerEmail) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only a selected worker can dispute this task"})
+		return
+	}
+
+	// The worker's assignment must still be waiting on the poster
+	var assignment models.ScheduledTask
+	err = scheduledTasksCollection.FindOne(context.TODO(), activeAssignmentFilter(objectID, workerEmail)).Decode(&assignment)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Your part of this task is already settled"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check your assignment"})
+		return
+	}
+
+	// Disputes are only for work the worker has already marked as done
+	if assignment.EndedAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End the task before opening a dispute"})
+		return
+	}
+
+	now := time.Now()
+	dispute := models.Dispute{
+		TaskID:         objectID,
+		TaskTitle:      task.Title,
+		WorkerEmail:    workerEmail,
+		PosterEmail:    task.CreatorEmail,
+		Status:         models.DisputeOpen,
+		PreviousStatus: task.Status,
+		Statements: []models.DisputeStatement{{
+			AuthorEmail: workerEmail,
+			Text:        input.Statement,
+			Attachments: input.Attachments,
+			SubmittedAt: now,
+		}},
+		CreatedAt: now,
+	}
+
+	result, err := disputesCollection.InsertOne(context.TODO(), dispute)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already disputed this task"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open dispute", "details": err.Error()})
+		return
+	}
+	dispute.ID = result.InsertedID.(primitive.ObjectID)
+
+	// The task is on hold until a moderator rules
+	if task.Status != models.Disputed {
+		_, err = tasksCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": objectID},
+			bson.M{"$set": bson.M{"status": models.Disputed, "updated_at": now}},
+		)
+		if err != nil {
+			log.Printf("Failed to mark task %s as disputed: %v\n", taskID, err)
+		}
+	}
+
+	disputedTask := task
+	disputedTask.Status = models.Disputed
+	recordTaskEvent(c, objectID, models.TaskDisputed, workerEmail, &task, &disputedTask, bson.M{"dispute_id": dispute.ID})
+
+	go func() {
+		_ = utils.SendDisputeNotification(task.CreatorEmail, task.Title,
+			fmt.Sprintf("%s has opened a dispute because their work was not confirmed. Please submit your side of what happened.", workerEmail))
+	}()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Dispute opened. A moderator will review it",
+		"dispute_id": dispute.ID.Hex(),
+	})
+}
+
+// AddDisputeStatement lets either side add a statement and attachments to an open dispute
+func AddDisputeStatement(c *gin.Context) {
+	disputeID := c.Param("dispute_id")
+	email := c.Param("email")
+
+	var input disputeStatementInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(input.Attachments) > maxDisputeAttachments {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d attachments per statement", maxDisputeAttachments)})
+		return
+	}
+
+	dispute, ok := findDispute(c, disputeID)
+	if !ok {
+		return
+	}
+
+	if email != dispute.WorkerEmail && email != dispute.PosterEmail {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the poster and the worker can add statements"})
+		return
+	}
+	if dispute.Status != models.DisputeOpen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dispute has already been resolved"})
+		return
+	}
+
+	statement := models.DisputeStatement{
+		AuthorEmail: email,
+		Text:        input.Statement,
+		Attachments: input.Attachments,
+		SubmittedAt: time.Now(),
+	}
+
+	_, err := disputesCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": dispute.ID, "status": models.DisputeOpen},
+		bson.M{"$push": bson.M{"statements": statement}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add statement", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Statement added",
+		"dispute_id": disputeID,
+	})
+}
+
+// GetDispute returns a dispute to either side of it or a moderator
+func GetDispute(c *gin.Context) {
+	disputeID := c.Param("dispute_id")
+	email := c.Param("email")
+
+	dispute, ok := findDispute(c, disputeID)
+	if !ok {
+		return
+	}
+
+	if email != dispute.WorkerEmail && email != dispute.PosterEmail && !isModerator(email) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to view this dispute"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"dispute": dispute})
+}
+
+// GetDisputeQueue lists open disputes for moderators, oldest first
+func GetDisputeQueue(c *gin.Context) {
+	moderatorEmail := c.Param("email")
+
+	if !isModerator(moderatorEmail) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Moderator access required"})
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"created_at": 1})
+
+	cursor, err := disputesCollection.Find(context.TODO(), bson.M{"status": models.DisputeOpen}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve disputes", "details": err.Error()})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var disputes []models.Dispute
+	if err := cursor.All(context.TODO(), &disputes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode disputes", "details": err.Error()})
+		return
+	}
+
+	if disputes == nil {
+		disputes = []models.Dispute{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"disputes": disputes,
+		"count":    len(disputes),
+	})
+}
+
+// ResolveDispute records a moderator's ruling and applies it to the worker's assignment,
+// the task and both users' records
+func ResolveDispute(c *gin.Context) {
+	disputeID := c.Param("dispute_id")
+	moderatorEmail := c.Param("email")
+
+	if !isModerator(moderatorEmail) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Moderator access required"})
+		return
+	}
+
+	var input struct {
+		Outcome     string   `json:"outcome" binding:"required"` // complete, incomplete or partial
+		Note        string   `json:"note"`
+		AmountCents *int64   `json:"amount_cents"` // Partial rulings: what the worker is paid out of the escrow
+		Fraction    *float64 `json:"fraction"`     // Partial rulings: or the share of the escrow they are paid
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	outcome := models.DisputeOutcome(input.Outcome)
+	if !isValidDisputeOutcome(outcome) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":          "Invalid outcome",
+			"valid_outcomes": models.ValidDisputeOutcomes(),
+		})
+		return
+	}
+
+	if outcome == models.DisputePartial && (input.AmountCents == nil) == (input.Fraction == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A partial ruling needs either amount_cents or fraction"})
+		return
+	}
+	if outcome != models.DisputePartial && (input.AmountCents != nil || input.Fraction != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount_cents and fraction only apply to partial rulings"})
+		return
+	}
+
+	dispute, ok := findDispute(c, disputeID)
+	if !ok {
+		return
+	}
+
+	if dispute.Status != models.DisputeOpen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dispute has already been resolved"})
+		return
+	}
+
+	var task models.Task
+	err := tasksCollection.FindOne(context.TODO(), bson.M{"_id": dispute.TaskID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	// What the worker is paid comes from their assignment, so it must be read before anything is settled
+	var assignment models.ScheduledTask
+	if outcome != models.DisputeIncomplete {
+		err = scheduledTasksCollection.FindOne(
+			context.TODO(),
+			bson.M{"task_id": task.ID, "worker_email": dispute.WorkerEmail, "status": bson.M{"$ne": models.Cancelled}},
+		).Decode(&assignment)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find the worker's assignment", "details": err.Error()})
+			return
+		}
+	}
+
+	// A partial ruling pays the worker part of what is held for them; the poster gets the rest back
+	var paidCents *int64
+	if outcome == models.DisputePartial {
+		_, held, err := latestHold(models.EscrowAccount(task.ID, dispute.WorkerEmail))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read the escrow held for the worker", "details": err.Error()})
+			return
+		}
+
+		paid := int64(-1) // Out of range until a valid share is given
+		switch {
+		case input.AmountCents != nil:
+			paid = *input.AmountCents
+		case *input.Fraction >= 0 && *input.Fraction <= 1:
+			paid = int64(math.Round(float64(held) * *input.Fraction))
+		}
+		if paid < 0 || paid > held {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":      "The worker's share must be between nothing and the escrow held for them",
+				"held_cents": held,
+			})
+			return
+		}
+		paidCents = &paid
+	}
+
+	session, err := client.StartSession()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+	defer session.EndSession(context.TODO())
+
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		now := time.Now()
+
+		resolved := bson.M{
+			"status":          models.DisputeResolved,
+			"outcome":         outcome,
+			"resolved_by":     moderatorEmail,
+			"resolved_at":     now,
+			"resolution_note": input.Note,
+		}
+		if paidCents != nil {
+			resolved["paid_cents"] = *paidCents
+		}
+
+		result, err := disputesCollection.UpdateOne(
+			sessCtx,
+			bson.M{"_id": dispute.ID, "status": models.DisputeOpen},
+			bson.M{"$set": resolved},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return nil, errDisputeResolved
+		}
+
+		return applyDisputeOutcome(sessCtx, dispute, outcome, now)
+	}
+
+	newStatus, err := session.WithTransaction(context.TODO(), callback)
+	if errors.Is(err, errDisputeResolved) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dispute has already been resolved"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve dispute", "details": err.Error()})
+		return
+	}
+
+	// Settle the worker's escrow the way the ruling says
+	switch outcome {
+	case models.DisputeIncomplete:
+		settleEscrowLater("refund escrow for "+dispute.WorkerEmail, func() error { return refundEscrow(task, dispute.WorkerEmail) })
+	case models.DisputePartial:
+		settleEscrowLater("release escrow to "+dispute.WorkerEmail, func() error {
+			return releaseEscrow(task, dispute.WorkerEmail, *paidCents)
+		})
+	default:
+		_, _, amountDue := assignmentPay(task, assignment)
+		settleEscrowLater("release escrow to "+dispute.WorkerEmail, func() error {
+			return releaseEscrow(task, dispute.WorkerEmail, dollarsToCents(amountDue))
+		})
+	}
+
+	resolvedTask := task
+	resolvedTask.Status = newStatus.(models.TaskStatus)
+	recordTaskEvent(c, task.ID, models.TaskDisputeRuled, moderatorEmail, &task, &resolvedTask, bson.M{
+		"dispute_id":   dispute.ID,
+		"worker_email": dispute.WorkerEmail,
+		"outcome":      outcome,
+	})
+
+	go func() {
+		message := fmt.Sprintf("A moderator has resolved the dispute between %s and %s as %s.", dispute.PosterEmail, dispute.WorkerEmail, outcome)
+		if input.Note != "" {
+			message += "\n\nModerator's note: " + input.Note
+		}
+		_ = utils.SendDisputeNotification(dispute.WorkerEmail, dispute.TaskTitle, message)
+		_ = utils.SendDisputeNotification(dispute.PosterEmail, dispute.TaskTitle, message)
+	}()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Dispute resolved successfully",
+		"dispute_id":  disputeID,
+		"outcome":     outcome,
+		"task_status": newStatus,
+		"paid_cents":  paidCents,
+	})
+}
+
+// errDisputeResolved means another moderator resolved the dispute first
+var errDisputeResolved = errors.New("dispute already resolved")
+
+// applyDisputeOutcome settles the worker's assignment, updates both users' records and
+// works out the task's new status, which it returns
+func applyDisputeOutcome(ctx context.Context, dispute models.Dispute, outcome models.DisputeOutcome, now time.Time) (models.TaskStatus, error) {
+	assignment := bson.M{
+		"task_id":      dispute.TaskID,
+		"worker_email": dispute.WorkerEmail,
+		"status":       bson.M{"$nin": []models.TaskStatus{models.Completed, models.Cancelled}},
+	}
+
+	// Complete and partial rulings count the work as done; incomplete releases the worker
+	settled := bson.M{"status": models.Completed, "completed_at": now, "dispute_outcome": outcome}
+	if outcome == models.DisputeIncomplete {
+		settled = bson.M{"status": models.Cancelled, "cancelled_at": now, "dispute_outcome": outcome}
+	}
+
+	// The poster may have confirmed completion after all while the dispute was open
+	result, err := scheduledTasksCollection.UpdateOne(ctx, assignment, bson.M{"$set": settled})
+	if err != nil {
+		return "", err
+	}
+
+	if result.ModifiedCount > 0 {
+		if outcome != models.DisputeIncomplete {
+			_, err = usersCollection.UpdateOne(ctx, bson.M{"email": dispute.WorkerEmail}, bson.M{"$inc": bson.M{"completed_tasks": 1}})
+			if err != nil {
+				return "", err
+			}
+
+			// The worker's pending completion OTP is no longer needed
+			_, err = otpCollection.DeleteMany(ctx, bson.M{
+				"task_id":      dispute.TaskID,
+				"context":      "task_completion",
+				"worker_email": dispute.WorkerEmail,
+			})
+			if err != nil {
+				return "", err
+			}
+		}
+
+		// A clear ruling counts for one side and against the other
+		winner, loser := "", ""
+		switch outcome {
+		case models.DisputeComplete:
+			winner, loser = dispute.WorkerEmail, dispute.PosterEmail
+		case models.DisputeIncomplete:
+			winner, loser = dispute.PosterEmail, dispute.WorkerEmail
+		}
+		if winner != "" {
+			if _, err = usersCollection.UpdateOne(ctx, bson.M{"email": winner}, bson.M{"$inc": bson.M{"disputes_won": 1}}); err != nil {
+				return "", err
+			}
+			if _, err = usersCollection.UpdateOne(ctx, bson.M{"email": loser}, bson.M{"$inc": bson.M{"disputes_lost": 1}}); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	completed, err := completeTaskIfAssignmentsDone(ctx, dispute.TaskID, now)
+	if err != nil {
+		return "", err
+	}
+	if completed {
+		return models.Completed, nil
+	}
+
+	var task models.Task
+	if err := tasksCollection.FindOne(ctx, bson.M{"_id": dispute.TaskID}).Decode(&task); err != nil {
+		return "", err
+	}
+	if task.Status != models.Disputed {
+		return task.Status, nil
+	}
+
+	// Other disputes on the same task keep it on hold
+	pending, err := disputesCollection.CountDocuments(ctx, bson.M{"task_id": dispute.TaskID, "status": models.DisputeOpen})
+	if err != nil {
+		return "", err
+	}
+	newStatus := models.Disputed
+	if pending == 0 {
+		// A dispute opened while another was pending only saw the task as Disputed
+		newStatus = dispute.PreviousStatus
+		if newStatus == "" || newStatus == models.Disputed {
+			newStatus = models.Open
+		}
+
+		// Nobody is left on the task and nothing was done, so it didn't happen
+		remaining, err := scheduledTasksCollection.CountDocuments(ctx, bson.M{
+			"task_id": dispute.TaskID,
+			"status":  bson.M{"$ne": models.Cancelled},
+		})
+		if err != nil {
+			return "", err
+		}
+		if remaining == 0 {
+			newStatus = models.Cancelled
+		}
+	}
+
+	_, err = tasksCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": dispute.TaskID},
+		bson.M{"$set": bson.M{"status": newStatus, "updated_at": now}},
+	)
+	if err != nil {
+		return "", err
+	}
+	return newStatus, nil
+}
+
+// findDispute loads a dispute by its hex ID, writing the error response when it can't
+func findDispute(c *gin.Context, disputeID string) (models.Dispute, bool) {
+	var dispute models.Dispute
+
+	objectID, err := primitive.ObjectIDFromHex(disputeID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispute ID format"})
+		return dispute, false
+	}
+
+	err = disputesCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&dispute)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dispute not found"})
+		return dispute, false
+	}
+	return dispute, true
+}
+
+// isValidDisputeOutcome checks an outcome against the known rulings
+func isValidDisputeOutcome(outcome models.DisputeOutcome) bool {
+	for _, valid := range models.ValidDisputeOutcomes() {
+		if outcome == valid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// periodSummary totals one month or semester of earnings or spending
+type periodSummary struct {
+	Period     string             `json:"period"`
+	Start      time.Time          `json:"start"`
+	Total      float64            `json:"total"`
+	Tasks      int                `json:"tasks"`
+	ByWorkType map[string]float64 `json:"by_work_type"`
+}
+
+// GetEarningsReport summarizes what a user earned as a worker and spent as a poster on
+// completed work, by month or semester and work type, as JSON, CSV or PDF
+func GetEarningsReport(c *gin.Context) {
+	email := c.Param("email")
+	period := c.DefaultQuery("period", utils.ReportPeriodMonth)
+	format := c.DefaultQuery("format", "json")
+
+	if period != utils.ReportPeriodMonth && period != utils.ReportPeriodSemester {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period. Use month or semester"})
+		return
+	}
+	if format != "json" && format != "csv" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format. Use json, csv or pdf"})
+		return
+	}
+
+	var user models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	earnings, err := summarizeCompletedWork(bson.M{"worker_email": email}, period)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute earnings", "details": err.Error()})
+		return
+	}
+	spending, err := summarizeCompletedWork(bson.M{"poster_email": email}, period)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute spending", "details": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("ufpeerassist-%s-%s", period, time.Now().Format("2006-01-02"))
+
+	switch format {
+	case "csv":
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".csv"))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", earningsCSV(earnings, spending))
+	case "pdf":
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".pdf"))
+		c.Data(http.StatusOK, "application/pdf", earningsPDF(user, period, earnings, spending))
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"email":          email,
+			"period":         period,
+			"earnings":       earnings,
+			"spending":       spending,
+			"total_earned":   sumSummaries(earnings),
+			"total_spent":    sumSummaries(spending),
+			"tasks_worked":   countSummaries(earnings),
+			"tasks_paid_for": countSummaries(spending),
+		})
+	}
+}
+
+// summarizeCompletedWork groups the completed assignments matching the filter by period,
+// newest period first. Each assignment counts for what the worker was due on it.
+func summarizeCompletedWork(filter bson.M, period string) ([]periodSummary, error) {
+	filter["status"] = models.Completed
+
+	cursor, err := scheduledTasksCollection.Find(context.TODO(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var assignments []models.ScheduledTask
+	if err := cursor.All(context.TODO(), &assignments); err != nil {
+		return nil, err
+	}
+
+	taskIDs := []primitive.ObjectID{}
+	for _, assignment := range assignments {
+		taskIDs = append(taskIDs, assignment.TaskID)
+	}
+	tasks, err := findTasks(bson.M{"_id": bson.M{"$in": taskIDs}})
+	if err != nil {
+		return nil, err
+	}
+	tasksByID := map[primitive.ObjectID]models.Task{}
+	for _, task := range tasks {
+		tasksByID[task.ID] = task
+	}
+
+	byPeriod := map[string]*periodSummary{}
+	for _, assignment := range assignments {
+		task, ok := tasksByID[assignment.TaskID]
+		if !ok {
+			continue
+		}
+
+		amount := assignment.AmountDue
+		if amount == 0 {
+			_, _, amount = assignmentPay(task, assignment)
+		}
+
+		// Older completions have no completed_at; the task's last update is the closest thing
+		completedAt := assignment.CompletedAt
+		if completedAt.IsZero() {
+			completedAt = task.UpdatedAt
+		}
+		if loc, err := utils.LoadTaskLocation(task.TimeZone); err == nil {
+			completedAt = completedAt.In(loc)
+		}
+
+		label := utils.ReportPeriodLabel(completedAt, period)
+		summary, ok := byPeriod[label]
+		if !ok {
+			summary = &periodSummary{
+				Period:     label,
+				Start:      utils.ReportPeriodStart(completedAt, period),
+				ByWorkType: map[string]float64{},
+			}
+			byPeriod[label] = summary
+		}
+		summary.Total += amount
+		summary.Tasks++
+		summary.ByWorkType[string(task.WorkType)] += amount
+	}
+
+	summaries := []periodSummary{}
+	for _, summary := range byPeriod {
+		summary.Total = roundCents(summary.Total)
+		for workType, amount := range summary.ByWorkType {
+			summary.ByWorkType[workType] = roundCents(amount)
+		}
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Start.After(summaries[j].Start)
+	})
+	return summaries, nil
+}
+
+// earningsCSV writes one row per period, role and work type
+func earningsCSV(earnings, spending []periodSummary) []byte {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+
+	_ = w.Write([]string{"role", "period", "work_type", "amount", "period_total", "period_tasks"})
+	for _, section := range []struct {
+		role      string
+		summaries []periodSummary
+	}{
+		{"worker", earnings},
+		{"poster", spending},
+	} {
+		for _, summary := range section.summaries {
+			for _, workType := range sortedWorkTypes(summary) {
+				_ = w.Write([]string{
+					section.role,
+					summary.Period,
+					workType,
+					strconv.FormatFloat(summary.ByWorkType[workType], 'f', 2, 64),
+					strconv.FormatFloat(summary.Total, 'f', 2, 64),
+					strconv.Itoa(summary.Tasks),
+				})
+			}
+		}
+	}
+
+	w.Flush()
+	return b.Bytes()
+}
+
+// earningsPDF lays the summaries out as a plain text report
+func earningsPDF(user models.Users, period string, earnings, spending []periodSummary) []byte {
+	lines := []string{
+		fmt.Sprintf("%s <%s>", user.Name, user.Email),
+		fmt.Sprintf("Generated %s, by %s", time.Now().Format("January 2, 2006"), period),
+		"",
+	}
+
+	for _, section := range []struct {
+		heading   string
+		summaries []periodSummary
+	}{
+		{"Earnings as a worker", earnings},
+		{"Spending as a poster", spending},
+	} {
+		lines = append(lines, fmt.Sprintf("%s: $%.2f over %d tasks", section.heading, sumSummaries(section.summaries), countSummaries(section.summaries)))
+		if len(section.summaries) == 0 {
+			lines = append(lines, "    Nothing yet")
+		}
+		for _, summary := range section.summaries {
+			lines = append(lines, fmt.Sprintf("  %s: $%.2f (%d tasks)", summary.Period, summary.Total, summary.Tasks))
+			for _, workType := range sortedWorkTypes(summary) {
+				lines = append(lines, fmt.Sprintf("      %s: $%.2f", workType, summary.ByWorkType[workType]))
+			}
+		}
+		lines = append(lines, "")
+	}
+
+	return utils.BuildTextPDF("UFPeerAssist earnings and spending", lines)
+}
+
+// sortedWorkTypes lists a summary's work types alphabetically
+func sortedWorkTypes(summary periodSummary) []string {
+	workTypes := []string{}
+	for workType := range summary.ByWorkType {
+		workTypes = append(workTypes, workType)
+	}
+	sort.Strings(workTypes)
+	return workTypes
+}
+
+// sumSummaries totals the amounts across periods
+func sumSummaries(summaries []periodSummary) float64 {
+	var total float64
+	for _, summary := range summaries {
+		total += summary.Total
+	}
+	return roundCents(total)
+}
+
+// countSummaries totals the tasks across periods
+func countSummaries(summaries []periodSummary) int {
+	count := 0
+	for _, summary := range summaries {
+		count += summary.Tasks
+	}
+	return count
+}
+
+// roundCents rounds a dollar amount to the cent
+func roundCents(amount float64) float64 {
+	return centsToDollars(dollarsToCents(amount))
+}
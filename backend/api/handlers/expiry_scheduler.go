@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// StartTaskExpiryJob expires stale open tasks right away and then on every tick, in the background
+func StartTaskExpiryJob(interval time.Duration) {
+	if tasksCollection == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			expireStaleTasks(time.Now())
+			<-ticker.C
+		}
+	}()
+
+	fmt.Printf("Task expiry job started, checking every %s\n", interval)
+}
+
+// expireStaleTasks marks open tasks whose start has passed with nobody selected as Expired
+// and lets each poster know they can repost it
+func expireStaleTasks(now time.Time) {
+	tasks, err := findTasks(bson.M{
+		"status":           models.Open,
+		"selected_users.0": bson.M{"$exists": false},
+		"start_at":         bson.M{"$gt": time.Time{}, "$lte": now},
+	})
+	if err != nil {
+		log.Printf("Failed to find stale tasks: %v\n", err)
+		return
+	}
+
+	for _, task := range tasks {
+		// Only expire the task if nobody was selected or changed it since it was read
+		result, err := tasksCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": task.ID, "status": models.Open, "selected_users.0": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{
+				"status":     models.Expired,
+				"expired_at": now,
+				"updated_at": now,
+			}},
+		)
+		if err != nil {
+			log.Printf("Failed to expire task %s: %v\n", task.ID.Hex(), err)
+			continue
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+
+		expired := task
+		expired.Status = models.Expired
+		expired.UpdatedAt = now
+		recordTaskEvent(nil, task.ID, models.TaskExpired, systemActor, &task, &expired, nil)
+
+		_ = utils.SendTaskExpiredNotification(task.CreatorEmail, task.Title, task.ID.Hex())
+	}
+}
@@ -0,0 +1,524 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+	"ufpeerassist/backend/api/payments"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ledgerCollection is the append-only MongoDB collection of ledger transactions
+var ledgerCollection *mongo.Collection
+
+// paymentProvider moves money in and out of the platform. The fake provider keeps everything offline.
+var paymentProvider payments.PaymentProvider = payments.NewFakeProvider()
+
+// errUnbalancedTransaction guards against postings that create or destroy money
+var errUnbalancedTransaction = errors.New("ledger transaction postings must sum to zero")
+
+// InitLedgerCollection initializes the ledger collection
+func InitLedgerCollection() {
+	if client != nil {
+		db := client.Database(databaseName)
+		ledgerCollection = db.Collection("ledger")
+
+		// Each hold, settlement and payout is posted at most once
+		keyIndex := mongo.IndexModel{
+			Keys:    bson.M{"idempotency_key": 1},
+			Options: options.Index().SetUnique(true),
+		}
+
+		_, err := ledgerCollection.Indexes().CreateOne(context.TODO(), keyIndex)
+		if err != nil {
+			fmt.Println("Error creating ledger idempotency index:", err)
+		}
+
+		// Index on posting accounts for balances
+		accountIndex := mongo.IndexModel{
+			Keys: bson.M{"postings.account": 1},
+		}
+
+		_, err = ledgerCollection.Indexes().CreateOne(context.TODO(), accountIndex)
+		if err != nil {
+			fmt.Println("Error creating ledger account index:", err)
+		}
+
+		// Indexes on both parties for transaction history
+		for _, field := range []string{"poster_email", "worker_email"} {
+			partyIndex := mongo.IndexModel{
+				Keys: bson.D{
+					{Key: field, Value: 1},
+					{Key: "created_at", Value: -1},
+				},
+			}
+
+			_, err = ledgerCollection.Indexes().CreateOne(context.TODO(), partyIndex)
+			if err != nil {
+				fmt.Printf("Error creating ledger %s index: %v\n", field, err)
+			}
+		}
+
+		fmt.Println("Ledger collection initialized!")
+	}
+}
+
+// GetBalance returns a user's withdrawable balance, plus money held in escrow on their tasks
+func GetBalance(c *gin.Context) {
+	email := c.Param("email")
+
+	var user models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	available, err := accountBalance(models.WalletAccount(email))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute balance", "details": err.Error()})
+		return
+	}
+
+	transactions, err := userTransactions(email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve transactions", "details": err.Error()})
+		return
+	}
+
+	// Escrow funded by the user as a poster, and escrow waiting to be released to them as a worker
+	var heldAsPoster, pendingAsWorker int64
+	for _, transaction := range transactions {
+		for _, posting := range transaction.Postings {
+			if !strings.HasPrefix(posting.Account, "escrow:") {
+				continue
+			}
+			if transaction.PosterEmail == email {
+				heldAsPoster += posting.AmountCents
+			}
+			if transaction.WorkerEmail == email {
+				pendingAsWorker += posting.AmountCents
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"email":                  email,
+		"available_cents":        available,
+		"held_in_escrow_cents":   heldAsPoster,
+		"pending_earnings_cents": pendingAsWorker,
+		"available":              centsToDollars(available),
+		"held_in_escrow":         centsToDollars(heldAsPoster),
+		"pending_earnings":       centsToDollars(pendingAsWorker),
+		"payment_provider":       paymentProvider.Name(),
+	})
+}
+
+// GetTransactions returns a user's ledger history, newest first, with each transaction's
+// net effect on them
+func GetTransactions(c *gin.Context) {
+	email := c.Param("email")
+
+	var user models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	transactions, err := userTransactions(email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve transactions", "details": err.Error()})
+		return
+	}
+
+	entries := []gin.H{}
+	for _, transaction := range transactions {
+		net := transactionNetFor(transaction, email)
+		entries = append(entries, gin.H{
+			"transaction": transaction,
+			"net_cents":   net,
+			"net":         centsToDollars(net),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": entries,
+		"count":        len(entries),
+	})
+}
+
+// RequestPayout sends some or all of a worker's balance to their bank through the provider.
+// The balance is debited before the provider is called, so money never leaves unrecorded.
+func RequestPayout(c *gin.Context) {
+	email := c.Param("email")
+	wallet := models.WalletAccount(email)
+
+	var input struct {
+		AmountCents int64 `json:"amount_cents"` // Leave out to withdraw the whole balance
+	}
+	// The amount is optional, so an empty body is fine
+	_ = c.ShouldBindJSON(&input)
+
+	// Payouts from a wallet are numbered like holds, so two at once claim the same idempotency key
+	// and only one is posted. The count is read before the balance so the balance includes every
+	// payout counted.
+	previous, err := ledgerCollection.CountDocuments(context.TODO(), bson.M{
+		"kind":             models.LedgerPayout,
+		"postings.account": wallet,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count payouts", "details": err.Error()})
+		return
+	}
+
+	available, err := accountBalance(wallet)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute balance", "details": err.Error()})
+		return
+	}
+
+	amount := input.AmountCents
+	if amount == 0 {
+		amount = available
+	}
+	if amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to pay out"})
+		return
+	}
+	if amount > available {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payout exceeds your available balance", "available_cents": available})
+		return
+	}
+
+	key := fmt.Sprintf("payout:%s:%d", email, previous)
+	posted, err := postLedgerTransaction(models.LedgerTransaction{
+		Kind:           models.LedgerPayout,
+		IdempotencyKey: key,
+		WorkerEmail:    email,
+		Postings: []models.LedgerPosting{
+			{Account: wallet, AmountCents: -amount},
+			{Account: models.ExternalAccount, AmountCents: amount},
+		},
+		Description: "Payout to bank account",
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record payout", "details": err.Error()})
+		return
+	}
+	if !posted {
+		c.JSON(http.StatusConflict, gin.H{"error": "Another payout is already in progress. Check your balance and try again"})
+		return
+	}
+
+	ref, err := paymentProvider.Payout(email, amount, key)
+	if err != nil {
+		// The money never left, so put it back in the wallet
+		settleEscrowLater("return rejected payout "+key, func() error {
+			_, err := postLedgerTransaction(models.LedgerTransaction{
+				Kind:           models.LedgerPayoutReturn,
+				IdempotencyKey: "return:" + key,
+				WorkerEmail:    email,
+				Postings: []models.LedgerPosting{
+					{Account: models.ExternalAccount, AmountCents: -amount},
+					{Account: wallet, AmountCents: amount},
+				},
+				Description: "Returned payout rejected by the payment provider",
+			})
+			return err
+		})
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Payment provider rejected the payout", "details": err.Error()})
+		return
+	}
+
+	// Postings are never changed; only the provider's reference is filled in
+	_, err = ledgerCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"idempotency_key": key},
+		bson.M{"$set": bson.M{"provider_refs": []string{ref}}},
+	)
+	if err != nil {
+		log.Printf("Failed to save provider reference %s for payout %s: %v\n", ref, key, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Payout sent",
+		"amount_cents":    amount,
+		"available_cents": available - amount,
+	})
+}
+
+// holdEscrow charges the poster the task's estimated pay for one worker and holds it in escrow.
+// Nothing is charged for unpaid tasks or when the worker's pay is already held.
+func holdEscrow(task models.Task, workerEmail string) (*models.LedgerTransaction, error) {
+	amount := dollarsToCents(utils.AmountDue(task.DurationMinutes, task.EstimatedPayRate))
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	escrow := models.EscrowAccount(task.ID, workerEmail)
+	held, err := accountBalance(escrow)
+	if err != nil {
+		return nil, err
+	}
+	if held > 0 {
+		return nil, nil
+	}
+
+	// A worker can be selected again after a refund, so holds are numbered
+	previous, err := ledgerCollection.CountDocuments(context.TODO(), bson.M{
+		"kind":             models.LedgerEscrowHold,
+		"postings.account": escrow,
+	})
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("hold:%s:%s:%d", task.ID.Hex(), workerEmail, previous)
+
+	ref, err := paymentProvider.Charge(task.CreatorEmail, amount, key)
+	if err != nil {
+		return nil, err
+	}
+
+	taskID := task.ID
+	transaction := models.LedgerTransaction{
+		Kind:           models.LedgerEscrowHold,
+		IdempotencyKey: key,
+		TaskID:         &taskID,
+		PosterEmail:    task.CreatorEmail,
+		WorkerEmail:    workerEmail,
+		Postings: []models.LedgerPosting{
+			{Account: models.ExternalAccount, AmountCents: -amount},
+			{Account: escrow, AmountCents: amount},
+		},
+		ProviderRefs: []string{ref},
+		Description:  fmt.Sprintf("Escrow for %s on %s", workerEmail, task.Title),
+	}
+
+	posted, err := postLedgerTransaction(transaction)
+	if err == nil && !posted {
+		// Someone else placed the same hold first; give this charge back
+		_, err = paymentProvider.Refund(task.CreatorEmail, amount, key+":duplicate")
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// releaseEscrow pays a worker what they are due out of the escrow held for them. The poster
+// is refunded what wasn't needed, or charged the difference when the approved time ran over.
+func releaseEscrow(task models.Task, workerEmail string, dueCents int64) error {
+	escrow := models.EscrowAccount(task.ID, workerEmail)
+	hold, held, err := latestHold(escrow)
+	if err != nil || hold == nil || held <= 0 {
+		return err
+	}
+	if dueCents < 0 {
+		dueCents = 0
+	}
+
+	key := "settle:" + hold.ID.Hex()
+	refs := []string{}
+	difference := held - dueCents
+	switch {
+	case difference > 0:
+		ref, err := paymentProvider.Refund(task.CreatorEmail, difference, key)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, ref)
+	case difference < 0:
+		ref, err := paymentProvider.Charge(task.CreatorEmail, -difference, key)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, ref)
+	}
+
+	postings := []models.LedgerPosting{{Account: escrow, AmountCents: -held}}
+	if dueCents > 0 {
+		postings = append(postings, models.LedgerPosting{Account: models.WalletAccount(workerEmail), AmountCents: dueCents})
+	}
+	if difference != 0 {
+		postings = append(postings, models.LedgerPosting{Account: models.ExternalAccount, AmountCents: difference})
+	}
+
+	taskID := task.ID
+	_, err = postLedgerTransaction(models.LedgerTransaction{
+		Kind:           models.LedgerEscrowRelease,
+		IdempotencyKey: key,
+		TaskID:         &taskID,
+		PosterEmail:    task.CreatorEmail,
+		WorkerEmail:    workerEmail,
+		Postings:       postings,
+		ProviderRefs:   refs,
+		Description:    fmt.Sprintf("Payment to %s for %s", workerEmail, task.Title),
+	})
+	return err
+}
+
+// refundEscrow gives the poster back everything held for a worker
+func refundEscrow(task models.Task, workerEmail string) error {
+	escrow := models.EscrowAccount(task.ID, workerEmail)
+	hold, held, err := latestHold(escrow)
+	if err != nil || hold == nil || held <= 0 {
+		return err
+	}
+
+	key := "settle:" + hold.ID.Hex()
+	ref, err := paymentProvider.Refund(task.CreatorEmail, held, key)
+	if err != nil {
+		return err
+	}
+
+	taskID := task.ID
+	_, err = postLedgerTransaction(models.LedgerTransaction{
+		Kind:           models.LedgerEscrowRefund,
+		IdempotencyKey: key,
+		TaskID:         &taskID,
+		PosterEmail:    task.CreatorEmail,
+		WorkerEmail:    workerEmail,
+		Postings: []models.LedgerPosting{
+			{Account: escrow, AmountCents: -held},
+			{Account: models.ExternalAccount, AmountCents: held},
+		},
+		ProviderRefs: []string{ref},
+		Description:  fmt.Sprintf("Refund of escrow for %s on %s", workerEmail, task.Title),
+	})
+	return err
+}
+
+// settleEscrowLater runs a release or refund after the main change has been saved, logging
+// failures instead of undoing work that already happened
+func settleEscrowLater(description string, settle func() error) {
+	if err := settle(); err != nil {
+		log.Printf("Failed to %s: %v\n", description, err)
+	}
+}
+
+// postLedgerTransaction records a balanced transaction. It returns false when a transaction
+// with the same idempotency key was already posted.
+func postLedgerTransaction(transaction models.LedgerTransaction) (bool, error) {
+	if !transaction.IsBalanced() {
+		return false, errUnbalancedTransaction
+	}
+	if transaction.Provider == "" {
+		transaction.Provider = paymentProvider.Name()
+	}
+	transaction.CreatedAt = time.Now()
+
+	_, err := ledgerCollection.InsertOne(context.TODO(), transaction)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// accountBalance sums every posting to an account
+func accountBalance(account string) (int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"postings.account": account}}},
+		{{Key: "$unwind", Value: "$postings"}},
+		{{Key: "$match", Value: bson.M{"postings.account": account}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "balance": bson.M{"$sum": "$postings.amount_cents"}}}},
+	}
+
+	cursor, err := ledgerCollection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var results []struct {
+		Balance int64 `bson:"balance"`
+	}
+	if err := cursor.All(context.TODO(), &results); err != nil {
+		return 0, err
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].Balance, nil
+}
+
+// latestHold returns the most recent hold on an escrow account and what is still held there
+func latestHold(escrow string) (*models.LedgerTransaction, int64, error) {
+	var hold models.LedgerTransaction
+	err := ledgerCollection.FindOne(
+		context.TODO(),
+		bson.M{"kind": models.LedgerEscrowHold, "postings.account": escrow},
+		options.FindOne().SetSort(bson.M{"created_at": -1}),
+	).Decode(&hold)
+	if err == mongo.ErrNoDocuments {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	held, err := accountBalance(escrow)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &hold, held, nil
+}
+
+// userTransactions returns every transaction a user is a party to, newest first
+func userTransactions(email string) ([]models.LedgerTransaction, error) {
+	findOptions := options.Find().SetSort(bson.M{"created_at": -1})
+
+	cursor, err := ledgerCollection.Find(
+		context.TODO(),
+		bson.M{"$or": []bson.M{{"poster_email": email}, {"worker_email": email}}},
+		findOptions,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	transactions := []models.LedgerTransaction{}
+	if err := cursor.All(context.TODO(), &transactions); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// transactionNetFor is how much a transaction put into (positive) or took out of a user's
+// pocket: card charges and refunds for posters, balance changes for workers
+func transactionNetFor(transaction models.LedgerTransaction, email string) int64 {
+	var net int64
+	if transaction.PosterEmail == email {
+		net += transaction.AmountFor(models.ExternalAccount)
+	}
+	if transaction.WorkerEmail == email {
+		net += transaction.AmountFor(models.WalletAccount(email))
+	}
+	return net
+}
+
+// dollarsToCents converts a dollar amount to whole cents
+func dollarsToCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// centsToDollars converts cents to a dollar amount for display
+func centsToDollars(cents int64) float64 {
+	return float64(cents) / 100
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// messagesCollection is the MongoDB collection for task conversations
+var messagesCollection *mongo.Collection
+
+// maxMessageLength caps a single message's body
+const maxMessageLength = 2000
+
+// InitMessagesCollection initializes the messages collection
+func InitMessagesCollection() {
+	if client != nil {
+		db := client.Database(databaseName)
+		messagesCollection = db.Collection("messages")
+
+		// Index on the thread and created_at for reading a conversation in order
+		threadIndex := mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "task_id", Value: 1},
+				{Key: "participant_email", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+		}
+
+		_, err := messagesCollection.Indexes().CreateOne(context.TODO(), threadIndex)
+		if err != nil {
+			fmt.Println("Error creating messages thread index:", err)
+		}
+
+		fmt.Println("Messages collection initialized!")
+	}
+}
+
+// SendMessage posts a message in a task's thread. Applicants and workers always write to the
+// poster; the poster says which applicant or worker they are writing to.
+func SendMessage(c *gin.Context) {
+	taskID := c.Param("task_id")
+	senderEmail := c.Param("email")
+
+	var input struct {
+		To   string `json:"to"` // Required when the poster sends
+		Body string `json:"body" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	body := strings.TrimSpace(input.Body)
+	if body == "" || len(body) > maxMessageLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Message must be between 1 and %d characters", maxMessageLength)})
+		return
+	}
+
+	task, participant, ok := resolveThread(c, taskID, senderEmail, input.To)
+	if !ok {
+		return
+	}
+
+	if task.Status == models.Completed || task.Status == models.Cancelled {
+		c.JSON(http.StatusConflict, gin.H{"error": "This conversation is closed because the task is " + strings.ToLower(string(task.Status))})
+		return
+	}
+
+	recipient := task.CreatorEmail
+	if senderEmail == task.CreatorEmail {
+		recipient = participant
+	}
+
+	blocked, err := isBlockedBetween(senderEmail, recipient)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send message", "details": err.Error()})
+		return
+	}
+	if blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot message a blocked user"})
+		return
+	}
+
+	message := models.Message{
+		TaskID:           task.ID,
+		ParticipantEmail: participant,
+		SenderEmail:      senderEmail,
+		RecipientEmail:   recipient,
+		Body:             body,
+		CreatedAt:        time.Now(),
+	}
+
+	result, err := messagesCollection.InsertOne(context.TODO(), message)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send message", "details": err.Error()})
+		return
+	}
+	message.ID = result.InsertedID.(primitive.ObjectID)
+
+	liveMessages.send(recipient, gin.H{"type": "message", "message": message})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Message sent",
+		"data":    message,
+	})
+}
+
+// GetMessages lists a task thread oldest first. The poster picks the thread with ?with=.
+func GetMessages(c *gin.Context) {
+	taskID := c.Param("task_id")
+	email := c.Param("email")
+
+	task, participant, ok := resolveThread(c, taskID, email, c.Query("with"))
+	if !ok {
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"created_at": 1})
+
+	cursor, err := messagesCollection.Find(
+		context.TODO(),
+		bson.M{"task_id": task.ID, "participant_email": participant},
+		findOptions,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve messages", "details": err.Error()})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	messages := []models.Message{}
+	if err := cursor.All(context.TODO(), &messages); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode messages", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"task_id":     taskID,
+		"participant": participant,
+		"messages":    messages,
+		"count":       len(messages),
+		"closed":      task.Status == models.Completed || task.Status == models.Cancelled,
+	})
+}
+
+// MarkMessagesRead marks every message the user received in a thread as read
+func MarkMessagesRead(c *gin.Context) {
+	taskID := c.Param("task_id")
+	email := c.Param("email")
+
+	var input struct {
+		With string `json:"with"` // Required when the poster marks a thread
+	}
+	// The body is only needed by the poster
+	_ = c.ShouldBindJSON(&input)
+
+	task, participant, ok := resolveThread(c, taskID, email, input.With)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	result, err := messagesCollection.UpdateMany(
+		context.TODO(),
+		bson.M{
+			"task_id":           task.ID,
+			"participant_email": participant,
+			"recipient_email":   email,
+			"read_at":           bson.M{"$exists": false},
+		},
+		bson.M{"$set": bson.M{"read_at": now}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark messages as read", "details": err.Error()})
+		return
+	}
+
+	// Let the other side see their messages were read
+	if result.ModifiedCount > 0 {
+		other := task.CreatorEmail
+		if email == task.CreatorEmail {
+			other = participant
+		}
+		liveMessages.send(other, gin.H{"type": "read", "task_id": task.ID, "reader": email, "read_at": now})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Messages marked as read",
+		"marked_read": result.ModifiedCount,
+	})
+}
+
+// GetMessageThreads lists the conversations on a task for its poster, with unread counts
+func GetMessageThreads(c *gin.Context) {
+	taskID := c.Param("task_id")
+	email := c.Param("email")
+
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	var task models.Task
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID, "creator_email": email}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found or you don't have permission to view its conversations"})
+		return
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"task_id": objectID}}},
+		{{Key: "$sort", Value: bson.M{"created_at": 1}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":          "$participant_email",
+			"last_message": bson.M{"$last": "$$ROOT"},
+			"count":        bson.M{"$sum": 1},
+			"unread": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$recipient_email", email}},
+					bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$read_at", nil}}, nil}},
+				}},
+				1, 0,
+			}}},
+		}}},
+		{{Key: "$sort", Value: bson.M{"last_message.created_at": -1}}},
+	}
+
+	cursor, err := messagesCollection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve conversations", "details": err.Error()})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var results []struct {
+		Participant string         `bson:"_id"`
+		LastMessage models.Message `bson:"last_message"`
+		Count       int            `bson:"count"`
+		Unread      int            `bson:"unread"`
+	}
+	if err := cursor.All(context.TODO(), &results); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode conversations", "details": err.Error()})
+		return
+	}
+
+	threads := []gin.H{}
+	for _, result := range results {
+		threads = append(threads, gin.H{
+			"participant":  result.Participant,
+			"selected":     contains(task.SelectedUsers, result.Participant),
+			"last_message": result.LastMessage,
+			"count":        result.Count,
+			"unread":       result.Unread,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"task_id": taskID,
+		"threads": threads,
+		"count":   len(threads),
+	})
+}
+
+// resolveThread loads the task and works out whose thread the user means: their own for an
+// applicant or worker, the named one for the poster. It writes the error response when it can't.
+func resolveThread(c *gin.Context, taskID, email, with string) (models.Task, string, bool) {
+	var task models.Task
+
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return task, "", false
+	}
+
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return task, "", false
+	}
+
+	participant := email
+	if email == task.CreatorEmail {
+		if with == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Say which applicant or worker the conversation is with"})
+			return task, "", false
+		}
+		participant = with
+	}
+
+	if !contains(task.Applicants, participant) && !contains(task.SelectedUsers, participant) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Conversations are only between the poster and applicants or workers"})
+		return task, "", false
+	}
+	return task, participant, true
+}
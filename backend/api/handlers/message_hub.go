@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// AllowedOrigins are the web origins allowed to call the API and open WebSockets
+var AllowedOrigins = []string{"http://localhost:3000"} // where reactfrontend is running
+
+const (
+	liveWriteTimeout = 10 * time.Second // A client that can't take an event this fast is dropped
+	liveQueueSize    = 32               // Events waiting for a slow client before it is dropped
+)
+
+// liveConn is one open WebSocket with its own queue, so a slow client never holds up the sender
+type liveConn struct {
+	conn   *websocket.Conn
+	events chan gin.H
+	done   chan struct{}
+	once   sync.Once
+}
+
+// close shuts the connection down; the read loop in MessageSocket then unregisters it
+func (lc *liveConn) close() {
+	lc.once.Do(func() {
+		close(lc.done)
+		lc.conn.Close()
+	})
+}
+
+// writeLoop delivers queued events until the connection closes or a write stalls
+func (lc *liveConn) writeLoop(email string) {
+	for {
+		select {
+		case event := <-lc.events:
+			lc.conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			if err := websocket.JSON.Send(lc.conn, event); err != nil {
+				log.Printf("Failed to push message event to %s: %v\n", email, err)
+				lc.close()
+				return
+			}
+		case <-lc.done:
+			return
+		}
+	}
+}
+
+// messageHub tracks each user's open WebSocket connections so messages can be pushed live
+type messageHub struct {
+	mu    sync.Mutex
+	conns map[string]map[*liveConn]bool
+}
+
+// liveMessages delivers new messages, read receipts and invitations to connected users
+var liveMessages = &messageHub{conns: map[string]map[*liveConn]bool{}}
+
+// add registers a connection for a user
+func (h *messageHub) add(email string, lc *liveConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.conns[email] == nil {
+		h.conns[email] = map[*liveConn]bool{}
+	}
+	h.conns[email][lc] = true
+}
+
+// remove forgets a connection once it closes
+func (h *messageHub) remove(email string, lc *liveConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.conns[email], lc)
+	if len(h.conns[email]) == 0 {
+		delete(h.conns, email)
+	}
+}
+
+// send queues an event for every open connection of a user without waiting on the network.
+// Users who aren't connected pick messages up from the list endpoint instead.
+func (h *messageHub) send(email string, event gin.H) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for lc := range h.conns[email] {
+		select {
+		case lc.events <- event:
+		default:
+			log.Printf("Dropping live connection of %s, which has fallen behind\n", email)
+			lc.close()
+		}
+	}
+}
+
+// checkSocketOrigin rejects WebSocket handshakes from pages outside the allowed origins,
+// since browsers don't apply CORS to them
+func checkSocketOrigin(config *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	for _, allowed := range AllowedOrigins {
+		if origin == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("origin %q is not allowed", origin)
+}
+
+// MessageSocket upgrades to a WebSocket that receives the user's new messages and read receipts
+// as they happen. The client only listens; sending goes through the REST endpoint.
+func MessageSocket(c *gin.Context) {
+	email := c.Param("email")
+
+	server := websocket.Server{
+		Handshake: checkSocketOrigin,
+		Handler: func(conn *websocket.Conn) {
+			lc := &liveConn{conn: conn, events: make(chan gin.H, liveQueueSize), done: make(chan struct{})}
+			liveMessages.add(email, lc)
+			defer liveMessages.remove(email, lc)
+			defer lc.close()
+
+			go lc.writeLoop(email)
+
+			// Block until the client goes away, ignoring anything it sends
+			var discard string
+			for websocket.Message.Receive(conn, &discard) == nil {
+			}
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
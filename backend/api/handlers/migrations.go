@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MigrateTaskTimes fills in start_at, end_at, duration and time zone for tasks posted
+// before they existed, parsing the free-form task_time on a best-effort basis. Tasks
+// whose time can't be read start at midnight on their task_date.
+func MigrateTaskTimes() {
+	if tasksCollection == nil {
+		return
+	}
+
+	cursor, err := tasksCollection.Find(context.TODO(), bson.M{"start_at": bson.M{"$exists": false}})
+	if err != nil {
+		fmt.Println("Error finding tasks to migrate:", err)
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var tasks []models.Task
+	if err := cursor.All(context.TODO(), &tasks); err != nil {
+		fmt.Println("Error decoding tasks to migrate:", err)
+		return
+	}
+
+	loc, err := utils.LoadTaskLocation("")
+	if err != nil {
+		fmt.Println("Error loading default time zone:", err)
+		return
+	}
+
+	migrated, unparsed := 0, 0
+	for _, task := range tasks {
+		start, ok := utils.CombineTaskDateTime(task.TaskDate, task.TaskTime, loc)
+		if !ok {
+			unparsed++
+			fmt.Printf("Could not parse task_time %q for task %s, using midnight\n", task.TaskTime, task.ID.Hex())
+		}
+		end := start.Add(utils.DefaultTaskDurationMinutes * time.Minute)
+
+		schedule := bson.M{
+			"start_at":  start.UTC(),
+			"end_at":    end.UTC(),
+			"time_zone": loc.String(),
+		}
+
+		_, err := tasksCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": task.ID},
+			bson.M{"$set": bson.M{
+				"start_at":         schedule["start_at"],
+				"end_at":           schedule["end_at"],
+				"time_zone":        schedule["time_zone"],
+				"duration_minutes": utils.DefaultTaskDurationMinutes,
+			}},
+		)
+		if err != nil {
+			fmt.Printf("Error migrating task %s: %v\n", task.ID.Hex(), err)
+			continue
+		}
+
+		// Keep the workers' schedule entries in line with the task
+		if scheduledTasksCollection != nil {
+			_, err = scheduledTasksCollection.UpdateMany(
+				context.TODO(),
+				bson.M{"task_id": task.ID},
+				bson.M{"$set": schedule},
+			)
+			if err != nil {
+				fmt.Printf("Error migrating scheduled tasks for %s: %v\n", task.ID.Hex(), err)
+			}
+		}
+		migrated++
+	}
+
+	if migrated > 0 {
+		fmt.Printf("Migrated start times for %d tasks (%d with unreadable task_time)\n", migrated, unparsed)
+	}
+}
+
+// MigrateAssignmentEnds marks active assignments as ended when their worker ended the task
+// before assignments recorded it, going by the TaskEnded events in the audit log
+func MigrateAssignmentEnds() {
+	if scheduledTasksCollection == nil || taskEventsCollection == nil {
+		return
+	}
+
+	cursor, err := scheduledTasksCollection.Find(context.TODO(), bson.M{
+		"ended_at": bson.M{"$exists": false},
+		"status":   bson.M{"$nin": []models.TaskStatus{models.Completed, models.Cancelled}},
+	})
+	if err != nil {
+		fmt.Println("Error finding assignments to migrate:", err)
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var assignments []models.ScheduledTask
+	if err := cursor.All(context.TODO(), &assignments); err != nil {
+		fmt.Println("Error decoding assignments to migrate:", err)
+		return
+	}
+
+	migrated := 0
+	for _, assignment := range assignments {
+		var event models.TaskEvent
+		err := taskEventsCollection.FindOne(
+			context.TODO(),
+			bson.M{"task_id": assignment.TaskID, "type": models.TaskEnded, "actor": assignment.Worker},
+			options.FindOne().SetSort(bson.M{"timestamp": -1}),
+		).Decode(&event)
+		if err != nil {
+			continue // Not ended, or the event wasn't recorded
+		}
+
+		_, err = scheduledTasksCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": assignment.ID, "ended_at": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"ended_at": event.Timestamp}},
+		)
+		if err != nil {
+			fmt.Printf("Error migrating assignment %s: %v\n", assignment.ID.Hex(), err)
+			continue
+		}
+		migrated++
+	}
+
+	if migrated > 0 {
+		fmt.Printf("Recorded the end of %d assignments from the audit log\n", migrated)
+	}
+}
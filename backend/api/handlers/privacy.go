@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"time"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+)
+
+// redactTaskLocation swaps the exact place of work for an approximate one unless the viewer
+// is the poster or a selected worker inside the task's active window
+func redactTaskLocation(task *models.Task, viewer string, now time.Time) {
+	if task.ShowsExactLocationTo(viewer, now) {
+		return
+	}
+	task.PlaceOfWork = utils.ApproximateLocation(task.PlaceOfWork)
+	task.LocationApproximate = true
+}
+
+// visibleMobile returns the mobile number to show the other side of a task, or nothing when the
+// contact window is closed or its owner never shares it
+func visibleMobile(mobile string, hideMobile, windowOpen bool) string {
+	if hideMobile || !windowOpen {
+		return ""
+	}
+	return mobile
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"ufpeerassist/backend/api/storage"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// isValidAcademicYear checks the year against the known academic years
+func isValidAcademicYear(year string) bool {
+	for _, y := range models.ValidAcademicYears() {
+		if string(y) == year {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPublicProfile returns the part of a user's profile anyone can see, without contact
+// details or account settings
+func GetPublicProfile(c *gin.Context) {
+	email := c.Param("email")
+
+	var user models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve profile", "details": err.Error()})
+		return
+	}
+
+	response := gin.H{"profile": user.PublicProfile()}
+	if user.AvatarKey != "" {
+		response["avatar_url"] = fmt.Sprintf("/users/%s/avatar", email)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UploadAvatar sets the user's profile picture from the multipart field "photo". The image
+// gets the same checks and EXIF stripping as task photos and is stored at thumbnail size.
+func UploadAvatar(c *gin.Context) {
+	email := c.Param("email")
+
+	var user models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	data, ok := readPhotoUpload(c)
+	if !ok {
+		return
+	}
+
+	photo, err := utils.ProcessPhoto(data)
+	if err != nil {
+		c.JSON(photoErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// A fresh key per upload so cached copies of the old avatar don't linger
+	extension := ".png"
+	if photo.ThumbnailType == "image/jpeg" {
+		extension = ".jpg"
+	}
+	key := fmt.Sprintf("avatars/%s%s", primitive.NewObjectID().Hex(), extension)
+
+	if err := blobStore.Put(key, bytes.NewReader(photo.Thumbnail)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store avatar", "details": err.Error()})
+		return
+	}
+
+	_, err = usersCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"email": email},
+		bson.M{"$set": bson.M{"avatar_key": key, "avatar_type": photo.ThumbnailType}},
+	)
+	if err != nil {
+		blobStore.Delete(key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile", "details": err.Error()})
+		return
+	}
+
+	if user.AvatarKey != "" {
+		if err := blobStore.Delete(user.AvatarKey); err != nil {
+			log.Printf("Failed to delete old avatar %s: %v\n", user.AvatarKey, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Avatar updated",
+		"avatar_url": fmt.Sprintf("/users/%s/avatar", email),
+	})
+}
+
+// GetAvatar streams a user's profile picture
+func GetAvatar(c *gin.Context) {
+	email := c.Param("email")
+
+	var user models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+	if err != nil || user.AvatarKey == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Avatar not found"})
+		return
+	}
+
+	blob, err := blobStore.Get(user.AvatarKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Avatar not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read avatar", "details": err.Error()})
+		return
+	}
+	defer blob.Close()
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, -1, user.AvatarType, blob, nil)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"ufpeerassist/backend/api/recommend"
+	"ufpeerassist/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// recommendationHistory gathers the completed work and applications the feed ranks tasks on
+func recommendationHistory(viewer models.Users) (recommend.History, error) {
+	cursor, err := scheduledTasksCollection.Find(context.TODO(), bson.M{
+		"worker_email": viewer.Email,
+		"status":       models.Completed,
+	})
+	if err != nil {
+		return recommend.History{}, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var assignments []models.ScheduledTask
+	if err := cursor.All(context.TODO(), &assignments); err != nil {
+		return recommend.History{}, err
+	}
+
+	completedIDs := []primitive.ObjectID{}
+	for _, assignment := range assignments {
+		completedIDs = append(completedIDs, assignment.TaskID)
+	}
+
+	completed := []models.Task{}
+	if len(completedIDs) > 0 {
+		completed, err = findTasks(bson.M{"_id": bson.M{"$in": completedIDs}})
+		if err != nil {
+			return recommend.History{}, err
+		}
+	}
+
+	applied, err := findTasks(bson.M{"applicants": viewer.Email})
+	if err != nil {
+		return recommend.History{}, err
+	}
+
+	return recommend.NewHistory(viewer, completed, applied), nil
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// remindersCollection tracks which reminders have been delivered
+var remindersCollection *mongo.Collection
+
+// reminderMaxAttempts caps how often a failing reminder is retried
+const reminderMaxAttempts = 3
+
+// completionNudgeGrace is how long after a task's end the poster is nudged to complete it
+const completionNudgeGrace = 2 * time.Hour
+
+// ReminderSender delivers one reminder of a task to a recipient
+type ReminderSender func(recipient string, task models.Task, kind models.ReminderKind) error
+
+// sendReminder emails reminders; tests swap it out through SendDueRemindersWith
+var sendReminder ReminderSender = emailReminder
+
+// emailReminder sends the completion nudge to posters and the start reminders to everyone
+func emailReminder(recipient string, task models.Task, kind models.ReminderKind) error {
+	if kind == models.ReminderCompletion {
+		return utils.SendCompletionNudge(recipient, task.Title, formatTaskStart(task))
+	}
+	return utils.SendTaskReminder(recipient, task.Title, formatTaskStart(task), task.PlaceOfWork, string(kind))
+}
+
+// InitRemindersCollection initializes the reminders collection
+func InitRemindersCollection() {
+	if client != nil {
+		db := client.Database(databaseName)
+		remindersCollection = db.Collection("reminders")
+
+		// One reminder of each kind per task and recipient
+		indexModel := mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "task_id", Value: 1},
+				{Key: "recipient_email", Value: 1},
+				{Key: "kind", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		}
+
+		_, err := remindersCollection.Indexes().CreateOne(context.TODO(), indexModel)
+		if err != nil {
+			fmt.Println("Error creating reminders index:", err)
+		}
+
+		fmt.Println("Reminders collection initialized!")
+	}
+}
+
+// StartReminderScheduler checks for due reminders right away and then on every tick, in the background
+func StartReminderScheduler(interval time.Duration) {
+	if remindersCollection == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			sendDueReminders(time.Now())
+			<-ticker.C
+		}
+	}()
+
+	fmt.Printf("Reminder scheduler started, checking every %s\n", interval)
+}
+
+// SendDueRemindersWith runs one reminder check at now, delivering through send instead of
+// email. Tests use it in place of the scheduler; it must not run alongside it.
+func SendDueRemindersWith(now time.Time, send ReminderSender) {
+	previous := sendReminder
+	sendReminder = send
+	defer func() { sendReminder = previous }()
+
+	sendDueReminders(now)
+}
+
+// sendDueReminders sends the 24h and 1h reminders and the completion nudges that are due at now
+func sendDueReminders(now time.Time) {
+	active := bson.M{"$in": []models.TaskStatus{models.Open, models.InProgress}}
+	hasWorkers := bson.M{"$exists": true}
+
+	// Tasks starting between 1 and 24 hours from now, and within the next hour. The windows
+	// don't overlap, so a task first seen inside the hour only gets the 1h reminder.
+	windows := []struct {
+		kind   models.ReminderKind
+		after  time.Duration
+		within time.Duration
+	}{
+		{models.Reminder24Hours, time.Hour, 24 * time.Hour},
+		{models.Reminder1Hour, 0, time.Hour},
+	}
+
+	for _, window := range windows {
+		tasks, err := findTasks(bson.M{
+			"status":           active,
+			"selected_users.0": hasWorkers,
+			"start_at":         bson.M{"$gt": now.Add(window.after), "$lte": now.Add(window.within)},
+		})
+		if err != nil {
+			log.Printf("Failed to find tasks for %s reminders: %v\n", window.kind, err)
+			continue
+		}
+
+		for _, task := range tasks {
+			recipients := append([]string{task.CreatorEmail}, task.SelectedUsers...)
+			for _, recipient := range recipients {
+				deliverReminder(task, recipient, window.kind)
+			}
+		}
+	}
+
+	// Tasks that ended a while ago but were never completed
+	tasks, err := findTasks(bson.M{
+		"status":           active,
+		"selected_users.0": hasWorkers,
+		"end_at":           bson.M{"$gt": time.Time{}, "$lte": now.Add(-completionNudgeGrace)},
+	})
+	if err != nil {
+		log.Printf("Failed to find tasks for completion nudges: %v\n", err)
+		return
+	}
+
+	for _, task := range tasks {
+		deliverReminder(task, task.CreatorEmail, models.ReminderCompletion)
+	}
+}
+
+// deliverReminder sends a reminder of each kind to each recipient of a task until one gets
+// through, at most reminderMaxAttempts times. Delivery is at least once: an attempt is claimed
+// before sending, but a run that stops after sending and before marking the reminder delivered,
+// or two overlapping runs, can send it again.
+func deliverReminder(task models.Task, recipient string, kind models.ReminderKind) {
+	key := bson.M{"task_id": task.ID, "recipient_email": recipient, "kind": kind}
+
+	// Matches only undelivered reminders with attempts left; anything else collides with
+	// the unique index on upsert, which means there is nothing to do
+	filter := bson.M{
+		"task_id":         task.ID,
+		"recipient_email": recipient,
+		"kind":            kind,
+		"delivered":       false,
+		"attempts":        bson.M{"$lt": reminderMaxAttempts},
+	}
+	_, err := remindersCollection.UpdateOne(
+		context.TODO(),
+		filter,
+		bson.M{
+			"$inc":         bson.M{"attempts": 1},
+			"$setOnInsert": bson.M{"created_at": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to claim %s reminder for %s: %v\n", kind, recipient, err)
+		return
+	}
+
+	if err := sendReminder(recipient, task, kind); err != nil {
+		_, _ = remindersCollection.UpdateOne(context.TODO(), key, bson.M{"$set": bson.M{"last_error": err.Error()}})
+		return
+	}
+
+	_, err = remindersCollection.UpdateOne(
+		context.TODO(),
+		key,
+		bson.M{"$set": bson.M{"delivered": true, "delivered_at": time.Now()}},
+	)
+	if err != nil {
+		log.Printf("Failed to mark %s reminder for %s as delivered: %v\n", kind, recipient, err)
+	}
+}
+
+// findTasks returns all tasks matching the filter
+func findTasks(filter bson.M) ([]models.Task, error) {
+	cursor, err := tasksCollection.Find(context.TODO(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var tasks []models.Task
+	if err := cursor.All(context.TODO(), &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// formatTaskStart renders a task's start in its own time zone for emails
+func formatTaskStart(task models.Task) string {
+	loc, err := utils.LoadTaskLocation(task.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return task.StartAt.In(loc).Format("Mon Jan 2, 3:04 PM MST")
+}
@@ -0,0 +1,446 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// reportsCollection is the MongoDB collection for task and user reports
+var reportsCollection *mongo.Collection
+
+// reportAutoHideThreshold is the number of open reports after which a task is hidden from the feed
+const reportAutoHideThreshold = 3
+
+// InitReportsCollection initializes the reports collection
+func InitReportsCollection() {
+	if client != nil {
+		db := client.Database(databaseName)
+		reportsCollection = db.Collection("reports")
+
+		// Index on status and created_at for the moderation queue
+		queueIndex := mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+		}
+
+		_, err := reportsCollection.Indexes().CreateOne(context.TODO(), queueIndex)
+		if err != nil {
+			fmt.Println("Error creating reports queue index:", err)
+		}
+
+		// Index on task_id and status for counting open reports per task
+		taskIndex := mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "task_id", Value: 1},
+				{Key: "status", Value: 1},
+			},
+		}
+
+		_, err = reportsCollection.Indexes().CreateOne(context.TODO(), taskIndex)
+		if err != nil {
+			fmt.Println("Error creating reports task index:", err)
+		}
+
+		fmt.Println("Reports collection initialized!")
+	}
+}
+
+// reportInput is the request body shared by the report endpoints
+type reportInput struct {
+	Reason  string `json:"reason" binding:"required"`
+	Details string `json:"details"`
+	TaskID  string `json:"task_id"` // Only used for user reports raised from a task
+}
+
+// isValidReportReason checks the reason against the known report reasons
+func isValidReportReason(reason string) bool {
+	for _, r := range models.ValidReportReasons() {
+		if string(r) == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// isModerator checks whether the given user has the moderator role
+func isModerator(email string) bool {
+	count, err := usersCollection.CountDocuments(
+		context.TODO(),
+		bson.M{"email": email, "role": models.UserRoleModerator},
+	)
+	return err == nil && count > 0
+}
+
+// ReportTask allows a user to report a task from the feed or a task they took part in
+func ReportTask(c *gin.Context) {
+	taskID := c.Param("task_id")
+	reporterEmail := c.Param("email")
+
+	// Verify that reporter exists
+	var reporter models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": reporterEmail}).Decode(&reporter)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required. User not found."})
+		return
+	}
+
+	var input reportInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !isValidReportReason(input.Reason) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report reason", "valid_reasons": models.ValidReportReasons()})
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	var task models.Task
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	if task.CreatorEmail == reporterEmail {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot report your own task"})
+		return
+	}
+
+	// Only one open report per reporter per task
+	existing, err := reportsCollection.CountDocuments(context.TODO(), bson.M{
+		"target_type":    models.ReportTargetTask,
+		"task_id":        objectID,
+		"reporter_email": reporterEmail,
+		"status":         models.ReportOpen,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing reports"})
+		return
+	}
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already reported this task"})
+		return
+	}
+
+	report := models.Report{
+		TargetType:    models.ReportTargetTask,
+		TaskID:        &objectID,
+		ReportedEmail: task.CreatorEmail,
+		ReporterEmail: reporterEmail,
+		Reason:        models.ReportReason(input.Reason),
+		Details:       input.Details,
+		Status:        models.ReportOpen,
+		CreatedAt:     time.Now(),
+	}
+
+	result, err := reportsCollection.InsertOne(context.TODO(), report)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to file report", "details": err.Error()})
+		return
+	}
+
+	hidden, err := refreshTaskVisibility(objectID)
+	if err != nil {
+		fmt.Printf("Error refreshing visibility for task %s: %v\n", taskID, err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Report submitted successfully",
+		"report_id": result.InsertedID.(primitive.ObjectID).Hex(),
+		"hidden":    hidden,
+	})
+}
+
+// ReportUser allows a user to report another user, optionally from a shared task
+func ReportUser(c *gin.Context) {
+	reporterEmail := c.Param("email")
+	reportedEmail := c.Param("reported_email")
+
+	// Verify that reporter exists
+	var reporter models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": reporterEmail}).Decode(&reporter)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required. User not found."})
+		return
+	}
+
+	// Verify that reported user exists
+	var reported models.Users
+	err = usersCollection.FindOne(context.TODO(), bson.M{"email": reportedEmail}).Decode(&reported)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reported user not found"})
+		return
+	}
+
+	if reporterEmail == reportedEmail {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot report yourself"})
+		return
+	}
+
+	var input reportInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !isValidReportReason(input.Reason) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report reason", "valid_reasons": models.ValidReportReasons()})
+		return
+	}
+
+	// Only one open report per reporter per user
+	existing, err := reportsCollection.CountDocuments(context.TODO(), bson.M{
+		"target_type":    models.ReportTargetUser,
+		"reported_email": reportedEmail,
+		"reporter_email": reporterEmail,
+		"status":         models.ReportOpen,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing reports"})
+		return
+	}
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already reported this user"})
+		return
+	}
+
+	report := models.Report{
+		TargetType:    models.ReportTargetUser,
+		ReportedEmail: reportedEmail,
+		ReporterEmail: reporterEmail,
+		Reason:        models.ReportReason(input.Reason),
+		Details:       input.Details,
+		Status:        models.ReportOpen,
+		CreatedAt:     time.Now(),
+	}
+
+	// If the report was raised from a task, both users must have taken part in it
+	if input.TaskID != "" {
+		objectID, err := primitive.ObjectIDFromHex(input.TaskID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+			return
+		}
+
+		var task models.Task
+		err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&task)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			return
+		}
+
+		participants := append([]string{task.CreatorEmail}, task.SelectedUsers...)
+		if !contains(participants, reporterEmail) || !contains(participants, reportedEmail) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Both users must be part of the task to report from it"})
+			return
+		}
+
+		report.TaskID = &objectID
+	}
+
+	result, err := reportsCollection.InsertOne(context.TODO(), report)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to file report", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Report submitted successfully",
+		"report_id": result.InsertedID.(primitive.ObjectID).Hex(),
+	})
+}
+
+// GetModerationQueue returns reports for moderators to triage, oldest first
+func GetModerationQueue(c *gin.Context) {
+	moderatorEmail := c.Param("email")
+
+	if !isModerator(moderatorEmail) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Moderator access required"})
+		return
+	}
+
+	// Default to the open queue
+	status := c.DefaultQuery("status", string(models.ReportOpen))
+	filter := bson.M{"status": status}
+
+	if targetType := c.Query("target_type"); targetType != "" {
+		filter["target_type"] = targetType
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"created_at": 1})
+
+	cursor, err := reportsCollection.Find(context.TODO(), filter, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reports", "details": err.Error()})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var reports []models.Report
+	if err := cursor.All(context.TODO(), &reports); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode reports", "details": err.Error()})
+		return
+	}
+
+	if reports == nil {
+		reports = []models.Report{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports": reports,
+		"count":   len(reports),
+	})
+}
+
+// ResolveReport lets a moderator dismiss a report or mark it as actioned
+func ResolveReport(c *gin.Context) {
+	reportID := c.Param("report_id")
+	moderatorEmail := c.Param("email")
+
+	if !isModerator(moderatorEmail) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Moderator access required"})
+		return
+	}
+
+	var input struct {
+		Status string `json:"status" binding:"required"` // Dismissed or Action Taken
+		Note   string `json:"note"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := models.ReportStatus(input.Status)
+	if status != models.ReportDismissed && status != models.ReportActionTaken {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":          "Invalid resolution status",
+			"valid_statuses": []models.ReportStatus{models.ReportDismissed, models.ReportActionTaken},
+		})
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID format"})
+		return
+	}
+
+	var report models.Report
+	err = reportsCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&report)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+
+	if report.Status != models.ReportOpen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Report has already been resolved"})
+		return
+	}
+
+	now := time.Now()
+	_, err = reportsCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": objectID},
+		bson.M{"$set": bson.M{
+			"status":          status,
+			"reviewed_by":     moderatorEmail,
+			"reviewed_at":     now,
+			"resolution_note": input.Note,
+		}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve report", "details": err.Error()})
+		return
+	}
+
+	// Task reports affect feed visibility: actioned tasks stay hidden for good,
+	// dismissed ones are re-evaluated against the remaining open reports
+	if report.TargetType == models.ReportTargetTask && report.TaskID != nil {
+		if status == models.ReportActionTaken {
+			_, err = tasksCollection.UpdateOne(
+				context.TODO(),
+				bson.M{"_id": *report.TaskID},
+				bson.M{"$set": bson.M{"hidden": true, "updated_at": now}},
+			)
+		} else {
+			_, err = refreshTaskVisibility(*report.TaskID)
+		}
+		if err != nil {
+			fmt.Printf("Error updating visibility for task %s: %v\n", report.TaskID.Hex(), err)
+		}
+	}
+
+	// Comment reports work the same way for the comment
+	if report.TargetType == models.ReportTargetComment && report.CommentID != nil {
+		if _, err := refreshCommentVisibility(*report.CommentID); err != nil {
+			fmt.Printf("Error updating visibility for comment %s: %v\n", report.CommentID.Hex(), err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Report resolved successfully",
+		"report_id": reportID,
+		"status":    status,
+	})
+}
+
+// refreshTaskVisibility hides a task once it reaches the open report threshold and
+// shows it again when it drops below, unless a moderator has already actioned it
+func refreshTaskVisibility(taskID primitive.ObjectID) (bool, error) {
+	openReports, err := reportsCollection.CountDocuments(context.TODO(), bson.M{
+		"target_type": models.ReportTargetTask,
+		"task_id":     taskID,
+		"status":      models.ReportOpen,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if openReports >= reportAutoHideThreshold {
+		_, err = tasksCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": taskID},
+			bson.M{"$set": bson.M{"hidden": true}},
+		)
+		return true, err
+	}
+
+	actioned, err := reportsCollection.CountDocuments(context.TODO(), bson.M{
+		"target_type": models.ReportTargetTask,
+		"task_id":     taskID,
+		"status":      models.ReportActionTaken,
+	})
+	if err != nil {
+		return false, err
+	}
+	if actioned > 0 {
+		return true, nil
+	}
+
+	_, err = tasksCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": taskID},
+		bson.M{"$set": bson.M{"hidden": false}},
+	)
+	return false, err
+}
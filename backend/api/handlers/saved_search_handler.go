@@ -0,0 +1,503 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// savedSearchesCollection is the MongoDB collection for saved feed filters
+var savedSearchesCollection *mongo.Collection
+
+// Saved search limits
+const (
+	maxSavedSearches      = 10
+	maxSavedSearchName    = 60
+	maxSavedSearchKeyword = 100
+	savedSearchDigestGap  = 24 * time.Hour // Time between digests for the same search
+)
+
+// InitSavedSearchesCollection initializes the saved searches collection
+func InitSavedSearchesCollection() {
+	if client != nil {
+		db := client.Database(databaseName)
+		savedSearchesCollection = db.Collection("saved_searches")
+
+		// Index on email for a user's saved searches
+		emailIndex := mongo.IndexModel{
+			Keys: bson.D{{Key: "email", Value: 1}},
+		}
+
+		_, err := savedSearchesCollection.Indexes().CreateOne(context.TODO(), emailIndex)
+		if err != nil {
+			fmt.Println("Error creating saved searches email index:", err)
+		}
+
+		// Index on alert and category for matching new tasks
+		alertIndex := mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "alert", Value: 1},
+				{Key: "filter.category", Value: 1},
+			},
+		}
+
+		_, err = savedSearchesCollection.Indexes().CreateOne(context.TODO(), alertIndex)
+		if err != nil {
+			fmt.Println("Error creating saved searches alert index:", err)
+		}
+
+		fmt.Println("Saved searches collection initialized!")
+	}
+}
+
+// CreateSavedSearch saves a feed filter and how the user wants to hear about new matches
+func CreateSavedSearch(c *gin.Context) {
+	email := c.Param("email")
+
+	var user models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var input struct {
+		Name      string   `json:"name" binding:"required"`
+		Category  string   `json:"category"`
+		MinPay    *float64 `json:"min_pay"`
+		MaxPay    *float64 `json:"max_pay"`
+		FromDate  string   `json:"from_date"` // YYYY-MM-DD
+		ToDate    string   `json:"to_date"`
+		Latitude  *float64 `json:"latitude"` // Center of the distance filter
+		Longitude *float64 `json:"longitude"`
+		RadiusKm  float64  `json:"radius_km"`
+		Keyword   string   `json:"keyword"`
+		Alert     string   `json:"alert"` // immediate (default), digest or none
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > maxSavedSearchName {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Name must be between 1 and %d characters", maxSavedSearchName)})
+		return
+	}
+
+	filter := models.TaskFilter{
+		MinPay:   input.MinPay,
+		MaxPay:   input.MaxPay,
+		RadiusKm: input.RadiusKm,
+		Keyword:  strings.TrimSpace(input.Keyword),
+	}
+
+	if input.Category != "" {
+		if !isValidWorkType(input.Category) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category", "valid_types": models.ValidTaskCategories()})
+			return
+		}
+		filter.Category = models.TaskCategory(input.Category)
+	}
+
+	if len(filter.Keyword) > maxSavedSearchKeyword {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Keyword must be at most %d characters", maxSavedSearchKeyword)})
+		return
+	}
+
+	for _, date := range []struct {
+		value  string
+		target **time.Time
+	}{{input.FromDate, &filter.FromDate}, {input.ToDate, &filter.ToDate}} {
+		if date.value == "" {
+			continue
+		}
+		parsed, err := time.Parse("2006-01-02", date.value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dates must use the YYYY-MM-DD format"})
+			return
+		}
+		*date.target = &parsed
+	}
+
+	point, err := coordinatesFromInput(input.Latitude, input.Longitude)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Near = point
+
+	if err := filter.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alert := models.AlertImmediate
+	if input.Alert != "" {
+		alert = models.SavedSearchAlert(input.Alert)
+		if alert != models.AlertImmediate && alert != models.AlertDigest && alert != models.AlertNone {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":        "Invalid alert",
+				"valid_alerts": []models.SavedSearchAlert{models.AlertImmediate, models.AlertDigest, models.AlertNone},
+			})
+			return
+		}
+	}
+
+	count, err := savedSearchesCollection.CountDocuments(context.TODO(), bson.M{"email": email})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count saved searches"})
+		return
+	}
+	if count >= maxSavedSearches {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("You can save up to %d searches", maxSavedSearches)})
+		return
+	}
+
+	search := models.SavedSearch{
+		Email:     email,
+		Name:      name,
+		Filter:    filter,
+		Alert:     alert,
+		CreatedAt: time.Now(),
+	}
+
+	result, err := savedSearchesCollection.InsertOne(context.TODO(), search)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save search", "details": err.Error()})
+		return
+	}
+	search.ID = result.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Search saved",
+		"saved_search": search,
+	})
+}
+
+// GetSavedSearches lists a user's saved searches
+func GetSavedSearches(c *gin.Context) {
+	email := c.Param("email")
+
+	cursor, err := savedSearchesCollection.Find(
+		context.TODO(),
+		bson.M{"email": email},
+		options.Find().SetSort(bson.M{"created_at": 1}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve saved searches", "details": err.Error()})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	searches := []models.SavedSearch{}
+	if err := cursor.All(context.TODO(), &searches); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode saved searches", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"saved_searches": searches,
+		"count":          len(searches),
+	})
+}
+
+// DeleteSavedSearch removes one of the user's saved searches
+func DeleteSavedSearch(c *gin.Context) {
+	email := c.Param("email")
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("search_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid saved search ID format"})
+		return
+	}
+
+	result, err := savedSearchesCollection.DeleteOne(context.TODO(), bson.M{"_id": objectID, "email": email})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete saved search", "details": err.Error()})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Saved search not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Saved search deleted"})
+}
+
+// notifySavedSearches alerts the owners of saved searches that match a newly posted task,
+// straight away or by queueing it for their digest. It runs in the background after posting.
+func notifySavedSearches(task models.Task) {
+	if savedSearchesCollection == nil {
+		return
+	}
+
+	// Nobody hears about their own tasks, or tasks from someone on either side of a block
+	excluded, err := blockedCounterparts(task.CreatorEmail)
+	if err != nil {
+		log.Printf("Failed to check blocks for saved search alerts on task %s: %v\n", task.ID.Hex(), err)
+		return
+	}
+	excluded = append(excluded, task.CreatorEmail)
+
+	cursor, err := savedSearchesCollection.Find(context.TODO(), bson.M{
+		"alert": bson.M{"$in": []models.SavedSearchAlert{models.AlertImmediate, models.AlertDigest}},
+		"email": bson.M{"$nin": excluded},
+		"$or": []bson.M{
+			{"filter.category": bson.M{"$exists": false}},
+			{"filter.category": task.WorkType},
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to find saved searches for task %s: %v\n", task.ID.Hex(), err)
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var searches []models.SavedSearch
+	if err := cursor.All(context.TODO(), &searches); err != nil {
+		log.Printf("Failed to decode saved searches for task %s: %v\n", task.ID.Hex(), err)
+		return
+	}
+
+	for _, search := range searches {
+		if !search.Filter.Matches(task) {
+			continue
+		}
+
+		if search.Alert == models.AlertDigest {
+			_, err := savedSearchesCollection.UpdateOne(
+				context.TODO(),
+				bson.M{"_id": search.ID},
+				bson.M{"$addToSet": bson.M{"pending_tasks": task.ID}},
+			)
+			if err != nil {
+				log.Printf("Failed to queue task %s for saved search %s: %v\n", task.ID.Hex(), search.ID.Hex(), err)
+			}
+			continue
+		}
+
+		liveMessages.send(search.Email, gin.H{"type": "saved_search_match", "saved_search_id": search.ID, "task_id": task.ID})
+		utils.SendSavedSearchAlert(search.Email, search.Name, []string{savedSearchTaskLine(task)}, false)
+	}
+}
+
+// StartSavedSearchDigestJob sends due saved search digests right away and then on every tick, in the background
+func StartSavedSearchDigestJob(interval time.Duration) {
+	if savedSearchesCollection == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			sendSavedSearchDigests(time.Now())
+			<-ticker.C
+		}
+	}()
+
+	fmt.Printf("Saved search digest job started, checking every %s\n", interval)
+}
+
+// sendSavedSearchDigests emails each digest search's queued matches, at most once a day.
+// Tasks that have since closed or started are left out.
+func sendSavedSearchDigests(now time.Time) {
+	cursor, err := savedSearchesCollection.Find(context.TODO(), bson.M{
+		"alert":           models.AlertDigest,
+		"pending_tasks.0": bson.M{"$exists": true},
+		"$or": []bson.M{
+			{"last_digest_at": bson.M{"$exists": false}},
+			{"last_digest_at": bson.M{"$lte": now.Add(-savedSearchDigestGap)}},
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to find due saved search digests: %v\n", err)
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var searches []models.SavedSearch
+	if err := cursor.All(context.TODO(), &searches); err != nil {
+		log.Printf("Failed to decode due saved search digests: %v\n", err)
+		return
+	}
+
+	for _, search := range searches {
+		tasks, err := findTasks(bson.M{
+			"_id":      bson.M{"$in": search.PendingTasks},
+			"status":   models.Open,
+			"hidden":   bson.M{"$ne": true},
+			"start_at": bson.M{"$gt": now},
+		})
+		if err != nil {
+			log.Printf("Failed to fetch digest tasks for saved search %s: %v\n", search.ID.Hex(), err)
+			continue
+		}
+
+		if len(tasks) > 0 {
+			lines := []string{}
+			for _, task := range tasks {
+				lines = append(lines, savedSearchTaskLine(task))
+			}
+			if err := utils.SendSavedSearchAlert(search.Email, search.Name, lines, true); err != nil {
+				continue // Keep the queue for the next run
+			}
+		}
+
+		// Only clear what this digest covered so matches queued meanwhile aren't lost
+		_, err = savedSearchesCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": search.ID},
+			bson.M{
+				"$set":     bson.M{"last_digest_at": now},
+				"$pullAll": bson.M{"pending_tasks": search.PendingTasks},
+			},
+		)
+		if err != nil {
+			log.Printf("Failed to update saved search %s after its digest: %v\n", search.ID.Hex(), err)
+		}
+	}
+}
+
+// savedSearchTaskLine describes a task in one line of an alert, without its exact address
+func savedSearchTaskLine(task models.Task) string {
+	return fmt.Sprintf("- %s: %s, $%.2f/hour, %s, %s (task ID %s)",
+		task.Title, task.WorkType, task.EstimatedPayRate, formatTaskStart(task),
+		utils.ApproximateLocation(task.PlaceOfWork), task.ID.Hex())
+}
+
+// coordinatesFromInput turns optional latitude and longitude fields into a point; both or
+// neither must be given
+func coordinatesFromInput(latitude, longitude *float64) (*models.GeoPoint, error) {
+	if latitude == nil && longitude == nil {
+		return nil, nil
+	}
+	if latitude == nil || longitude == nil {
+		return nil, fmt.Errorf("latitude and longitude must be given together")
+	}
+
+	point := models.GeoPoint{Latitude: *latitude, Longitude: *longitude}
+	if !point.Valid() {
+		return nil, fmt.Errorf("latitude or longitude is out of range")
+	}
+	return &point, nil
+}
+
+// feedFilterFromQuery reads the feed's filter from the query string: either one of the viewer's
+// saved searches with ?saved_search=, or category, from_date, to_date, min_pay, max_pay, keyword,
+// latitude, longitude and radius_km. It writes the error response when the filter is invalid.
+func feedFilterFromQuery(c *gin.Context, viewerEmail string) (models.TaskFilter, bool) {
+	var filter models.TaskFilter
+
+	if id := c.Query("saved_search"); id != "" {
+		searchID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid saved search ID format"})
+			return filter, false
+		}
+
+		var search models.SavedSearch
+		err = savedSearchesCollection.FindOne(context.TODO(), bson.M{"_id": searchID, "email": viewerEmail}).Decode(&search)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Saved search not found"})
+			return filter, false
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve saved search", "details": err.Error()})
+			return filter, false
+		}
+		return search.Filter, true
+	}
+
+	filter.Category = models.TaskCategory(c.Query("category"))
+	filter.Keyword = strings.TrimSpace(c.Query("keyword"))
+
+	// Unreadable dates are ignored, as the feed always has
+	if value := c.Query("from_date"); value != "" {
+		if parsed, err := time.Parse("2006-01-02", value); err == nil {
+			filter.FromDate = &parsed
+		}
+	}
+	if value := c.Query("to_date"); value != "" {
+		if parsed, err := time.Parse("2006-01-02", value); err == nil {
+			filter.ToDate = &parsed
+		}
+	}
+
+	numbers := map[string]*float64{}
+	for _, name := range []string{"min_pay", "max_pay", "latitude", "longitude", "radius_km"} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be a number"})
+			return filter, false
+		}
+		numbers[name] = &number
+	}
+	filter.MinPay = numbers["min_pay"]
+	filter.MaxPay = numbers["max_pay"]
+	if radius := numbers["radius_km"]; radius != nil {
+		filter.RadiusKm = *radius
+	}
+
+	point, err := coordinatesFromInput(numbers["latitude"], numbers["longitude"])
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	filter.Near = point
+
+	if err := filter.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, false
+	}
+	return filter, true
+}
+
+// addTaskFilterQuery narrows a task query by the parts of the filter MongoDB can check.
+// Keywords and distance are left to TaskFilter.Matches.
+func addTaskFilterQuery(query bson.M, filter models.TaskFilter) {
+	if filter.Category != "" {
+		query["work_type"] = filter.Category
+	}
+
+	pay := bson.M{}
+	if filter.MinPay != nil {
+		pay["$gte"] = *filter.MinPay
+	}
+	if filter.MaxPay != nil {
+		pay["$lte"] = *filter.MaxPay
+	}
+	if len(pay) > 0 {
+		query["estimated_pay_rate"] = pay
+	}
+
+	dates := bson.M{}
+	if filter.FromDate != nil {
+		dates["$gte"] = *filter.FromDate
+	}
+	if filter.ToDate != nil {
+		dates["$lte"] = *filter.ToDate
+	}
+	if len(dates) > 0 {
+		query["task_date"] = dates
+	}
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// scheduleEntry combines a schedule row with its task and the person on the other side of it
+type scheduleEntry struct {
+	models.ScheduledTask `json:"schedule"`
+	Task                 models.Task         `json:"task"`
+	Counterpart          *models.UserSummary `json:"counterpart"` // The poster for a worker, the worker for a poster
+}
+
+// GetSchedule returns a user's schedule as a worker (the default) or as a poster, split into
+// upcoming, past and cancelled entries
+func GetSchedule(c *gin.Context) {
+	email := c.Param("email")
+	role := c.DefaultQuery("role", "worker")
+
+	// Verify user exists
+	var user models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var filter bson.M
+	switch role {
+	case "worker":
+		filter = bson.M{"worker_email": email}
+	case "poster":
+		filter = bson.M{"poster_email": email}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role. Use worker or poster"})
+		return
+	}
+
+	cursor, err := scheduledTasksCollection.Find(context.TODO(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query scheduled_tasks"})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var scheduledTasks []models.ScheduledTask
+	if err := cursor.All(context.TODO(), &scheduledTasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode scheduled_tasks"})
+		return
+	}
+
+	// Fetch the tasks and the counterparts' profiles in one query each
+	taskIDs := []primitive.ObjectID{}
+	counterpartEmails := []string{}
+	for _, scheduled := range scheduledTasks {
+		taskIDs = append(taskIDs, scheduled.TaskID)
+		counterpartEmails = append(counterpartEmails, scheduleCounterpart(scheduled, role))
+	}
+
+	tasks, err := findTasks(bson.M{"_id": bson.M{"$in": taskIDs}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch full task details"})
+		return
+	}
+	tasksByID := map[primitive.ObjectID]models.Task{}
+	for _, task := range tasks {
+		tasksByID[task.ID] = task
+	}
+
+	summaries, err := findUserSummaries(counterpartEmails)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profiles"})
+		return
+	}
+
+	upcoming := []scheduleEntry{}
+	past := []scheduleEntry{}
+	cancelled := []scheduleEntry{}
+
+	now := time.Now()
+	for _, scheduled := range scheduledTasks {
+		task, ok := tasksByID[scheduled.TaskID]
+		if !ok {
+			continue
+		}
+
+		// Contact details flow both ways only while this worker's window is open
+		windowOpen := task.ContactWindowOpen(scheduled.Worker, now)
+		if role == "worker" {
+			redactTaskLocation(&task, email, now)
+			scheduled.Place = task.PlaceOfWork
+		}
+
+		entry := scheduleEntry{ScheduledTask: scheduled, Task: task}
+		if summary, ok := summaries[scheduleCounterpart(scheduled, role)]; ok {
+			summary.Mobile = visibleMobile(summary.Mobile, summary.HideMobile, windowOpen)
+			entry.Counterpart = &summary
+		}
+
+		switch {
+		case scheduled.Status == models.Cancelled || task.Status == models.Cancelled:
+			cancelled = append(cancelled, entry)
+		case scheduled.Status == models.Completed || task.Status == models.Completed || scheduleEntryEnd(entry).Before(now):
+			past = append(past, entry)
+		default:
+			upcoming = append(upcoming, entry)
+		}
+	}
+
+	// Soonest first for what's coming up, most recent first for the rest
+	sort.Slice(upcoming, func(i, j int) bool {
+		return scheduleEntryStart(upcoming[i]).Before(scheduleEntryStart(upcoming[j]))
+	})
+	sort.Slice(past, func(i, j int) bool {
+		return scheduleEntryStart(past[i]).After(scheduleEntryStart(past[j]))
+	})
+	sort.Slice(cancelled, func(i, j int) bool {
+		return scheduleEntryStart(cancelled[i]).After(scheduleEntryStart(cancelled[j]))
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"role":      role,
+		"upcoming":  upcoming,
+		"past":      past,
+		"cancelled": cancelled,
+		"count":     len(upcoming) + len(past) + len(cancelled),
+	})
+}
+
+// scheduleCounterpart returns the email of the other side of a schedule row
+func scheduleCounterpart(scheduled models.ScheduledTask, role string) string {
+	if role == "poster" {
+		return scheduled.Worker
+	}
+	return scheduled.Poster
+}
+
+// scheduleEntryStart prefers the task's current start, since edits may not have reached older schedule rows
+func scheduleEntryStart(entry scheduleEntry) time.Time {
+	if !entry.Task.StartAt.IsZero() {
+		return entry.Task.StartAt
+	}
+	return entry.ScheduledTask.StartAt
+}
+
+// scheduleEntryEnd is the end counterpart of scheduleEntryStart
+func scheduleEntryEnd(entry scheduleEntry) time.Time {
+	if !entry.Task.EndAt.IsZero() {
+		return entry.Task.EndAt
+	}
+	return entry.ScheduledTask.EndAt
+}
+
+// findUserSummaries returns the profile summaries of the given users, keyed by email
+func findUserSummaries(emails []string) (map[string]models.UserSummary, error) {
+	summaries := map[string]models.UserSummary{}
+	if len(emails) == 0 {
+		return summaries, nil
+	}
+
+	cursor, err := usersCollection.Find(context.TODO(), bson.M{"email": bson.M{"$in": emails}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var users []models.UserSummary
+	if err := cursor.All(context.TODO(), &users); err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		summaries[user.Email] = user
+	}
+	return summaries, nil
+}
@@ -2,10 +2,14 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+	"ufpeerassist/backend/api/recommend"
 	"ufpeerassist/backend/api/utils"
 	"ufpeerassist/backend/models"
 
@@ -24,7 +28,7 @@ var scheduledTasksCollection *mongo.Collection
 func InitTasksCollection() {
 	// Make sure this is called after InitMongoDB() in main.go
 	if client != nil {
-		db := client.Database("ufpeerassist")
+		db := client.Database(databaseName)
 		tasksCollection = db.Collection("tasks")
 
 		// Create compound index on creator_email and status for faster querying
@@ -60,197 +64,555 @@ func InitTasksCollection() {
 			fmt.Println("Error creating task_date index:", err)
 		}
 
+		// Create index on start_at for sorting by start time
+		startIndex := mongo.IndexModel{
+			Keys: bson.M{"start_at": 1},
+		}
+
+		_, err = tasksCollection.Indexes().CreateOne(context.TODO(), startIndex)
+		if err != nil {
+			fmt.Println("Error creating start_at index:", err)
+		}
+
 		fmt.Println("✅ Tasks collection initialized with indexes!")
 	}
 }
 
 func InitScheduledTasksCollection() {
 	if client != nil {
-		db := client.Database("ufpeerassist")
+		db := client.Database(databaseName)
 		scheduledTasksCollection = db.Collection("scheduled_tasks")
+
+		// Index on worker_email and start_at for schedule conflict checks
+		indexModel := mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "worker_email", Value: 1},
+				{Key: "start_at", Value: 1},
+			},
+		}
+
+		_, err := scheduledTasksCollection.Indexes().CreateOne(context.TODO(), indexModel)
+		if err != nil {
+			fmt.Println("Error creating scheduled_tasks index:", err)
+		}
+
+		// Index on poster_email for the poster's view of who is coming
+		posterIndex := mongo.IndexModel{
+			Keys: bson.M{"poster_email": 1},
+		}
+
+		_, err = scheduledTasksCollection.Indexes().CreateOne(context.TODO(), posterIndex)
+		if err != nil {
+			fmt.Println("Error creating scheduled_tasks poster index:", err)
+		}
+
 		fmt.Println("Scheduled tasks collection initialized!")
 	}
 }
 
-// PostATask handles the creation or updating of a task by a user
-func PostATask(c *gin.Context) {
-	fmt.Print("Hey in the method")
-	// Get email from URL parameter
-	email := c.Param("email")
-
-	// Check if user exists
-	var user models.Users
-	err := usersCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		return
-	}
-	fmt.Print("Hey in the method 11 ")
+// CreateTask handles the creation of a task by a user
+func CreateTask(c *gin.Context) {
 	// Define the input structure
 	var input struct {
-		ID               string  `json:"id"`
+		CreatorEmail     string  `json:"creator_email" binding:"required,email"`
 		Title            string  `json:"title" binding:"required"`
 		Description      string  `json:"description" binding:"required"`
-		TaskTime         string  `json:"task_time" binding:"required"`
-		TaskDate         string  `json:"task_date" binding:"required"` // Format: YYYY-MM-DD
 		EstimatedPayRate float64 `json:"estimated_pay_rate" binding:"required"`
 		PlaceOfWork      string  `json:"place_of_work" binding:"required"`
 		WorkType         string  `json:"work_type" binding:"required"`
 		PeopleNeeded     int     `json:"people_needed" binding:"required"`
+
+		// Optional exact position, only used to match distance-filtered saved searches
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
+
+		// Either start_at, or task_date with task_time
+		taskScheduleInput
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	fmt.Print("Hey in the method 22")
-	// Parse the task date
-	taskDate, err := time.Parse("2006-01-02", input.TaskDate)
+
+	email := input.CreatorEmail
+
+	// Check if user exists
+	var user models.Users
+	err := usersCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
 	// Check if work type is valid
-	validWorkType := false
-	for _, category := range []models.TaskCategory{
-		models.Plumbing,
-		models.HouseShifting,
-		models.Carpentry,
-		models.Cleaning,
-		models.Electrical,
-		models.Painting,
-		models.Gardening,
-		models.Tutoring,
-		models.ComputerHelp,
-		models.Other,
-	} {
-		if string(category) == input.WorkType {
-			validWorkType = true
-			break
-		}
-	}
-	fmt.Print("Hey in the method 33")
-	if !validWorkType {
+	if !isValidWorkType(input.WorkType) {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid work type",
-			"valid_types": []string{
-				string(models.Plumbing),
-				string(models.HouseShifting),
-				string(models.Carpentry),
-				string(models.Cleaning),
-				string(models.Electrical),
-				string(models.Painting),
-				string(models.Gardening),
-				string(models.Tutoring),
-				string(models.ComputerHelp),
-				string(models.Other),
-			},
+			"error":       "Invalid work type",
+			"valid_types": models.ValidTaskCategories(),
+		})
+		return
+	}
+
+	coordinates, err := coordinatesFromInput(input.Latitude, input.Longitude)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+
+	// Create a new task
+	newTask := models.Task{
+		Title:            input.Title,
+		Description:      input.Description,
+		EstimatedPayRate: input.EstimatedPayRate,
+		PlaceOfWork:      input.PlaceOfWork,
+		Coordinates:      coordinates,
+		WorkType:         models.TaskCategory(input.WorkType),
+		PeopleNeeded:     input.PeopleNeeded,
+
+		// Meta data
+		CreatorEmail:  email,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Status:        models.Open,
+		Views:         0,
+		Applicants:    []string{},
+		SelectedUsers: []string{},
+		Version:       1,
+	}
+
+	// Work out when the task happens
+	if err := applyTaskSchedule(&newTask, input.taskScheduleInput); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !newTask.StartAt.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Task start must be in the future"})
+		return
+	}
+
+	// Insert the task
+	result, err := tasksCollection.InsertOne(context.TODO(), newTask)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task", "details": err.Error()})
+		return
+	}
+
+	newTask.ID = result.InsertedID.(primitive.ObjectID)
+	recordTaskEvent(c, newTask.ID, models.TaskCreated, email, nil, &newTask, nil)
+	go notifySavedSearches(newTask)
+
+	// Convert the InsertedID to a string
+	insertedID := result.InsertedID.(primitive.ObjectID).Hex()
+
+	c.Header("ETag", taskETag(newTask.Version))
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Task created successfully",
+		"task_id": insertedID,
+		"version": newTask.Version,
+	})
+}
+
+// GetTask returns a single task along with its ETag for optimistic concurrency. The exact place of
+// work is only included when ?viewer= is the poster or a selected worker.
+func GetTask(c *gin.Context) {
+	taskID := c.Param("task_id")
+
+	// Convert string ID to ObjectID
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	var task models.Task
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	// Only the poster and selected workers see the exact place of work
+	redactTaskLocation(&task, c.Query("viewer"), time.Now())
+
+	c.Header("ETag", taskETag(task.Version))
+	c.JSON(http.StatusOK, gin.H{"task": task})
+}
+
+// UpdateTask applies a partial update to a task. The caller must send the version
+// they last saw in If-Match (or as "version") so concurrent edits don't clobber each other.
+func UpdateTask(c *gin.Context) {
+	taskID := c.Param("task_id")
+
+	// Only the fields that are present are updated
+	var input struct {
+		Email            string   `json:"email" binding:"required,email"` // Task owner's email
+		Version          *int     `json:"version"`
+		Title            *string  `json:"title"`
+		Description      *string  `json:"description"`
+		EstimatedPayRate *float64 `json:"estimated_pay_rate"`
+		PlaceOfWork      *string  `json:"place_of_work"`
+		Latitude         *float64 `json:"latitude"`
+		Longitude        *float64 `json:"longitude"`
+		WorkType         *string  `json:"work_type"`
+		PeopleNeeded     *int     `json:"people_needed"`
+		taskScheduleInput
+
+		// Required to change date, time, pay, place or people needed once a task has applicants
+		ConfirmMaterialChange bool `json:"confirm_material_change"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	email := input.Email
+
+	// Work out which version the caller is editing
+	expectedVersion, ok := parseTaskETag(c.GetHeader("If-Match"))
+	if !ok && input.Version != nil {
+		expectedVersion, ok = *input.Version, true
+	}
+	if !ok {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header with the task's ETag is required"})
+		return
+	}
+
+	// Convert string ID to ObjectID
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	// Find the existing task
+	var existingTask models.Task
+	err = tasksCollection.FindOne(
+		context.TODO(),
+		bson.M{
+			"_id":           objectID,
+			"creator_email": email,
+		},
+	).Decode(&existingTask)
+
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found or you don't have permission to update it"})
+		return
+	}
+
+	// Completed, cancelled, expired and disputed tasks are settled and can't change under anyone
+	if !isEditableStatus(existingTask.Status) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "Only open or in-progress tasks can be edited",
+			"status": existingTask.Status,
+		})
+		return
+	}
+
+	if existingTask.Version != expectedVersion {
+		c.Header("ETag", taskETag(existingTask.Version))
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"error":           "Task was modified by someone else. Reload it and try again",
+			"current_version": existingTask.Version,
 		})
 		return
 	}
 
 	now := time.Now()
+	updatedTask := existingTask
+	setFields := bson.M{}
 
-	// Check if we're updating an existing task or creating a new one
-	if input.ID != "" {
-		// Convert string ID to ObjectID
-		objectID, err := primitive.ObjectIDFromHex(input.ID)
+	if input.Title != nil {
+		updatedTask.Title = *input.Title
+		setFields["title"] = *input.Title
+	}
+	if input.Description != nil {
+		updatedTask.Description = *input.Description
+		setFields["description"] = *input.Description
+	}
+	if input.taskScheduleInput.isSet() {
+		if err := applyTaskSchedule(&updatedTask, input.taskScheduleInput); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		setFields["task_date"] = updatedTask.TaskDate
+		setFields["task_time"] = updatedTask.TaskTime
+		setFields["start_at"] = updatedTask.StartAt
+		setFields["end_at"] = updatedTask.EndAt
+		setFields["duration_minutes"] = updatedTask.DurationMinutes
+		setFields["time_zone"] = updatedTask.TimeZone
+	}
+	if input.EstimatedPayRate != nil {
+		updatedTask.EstimatedPayRate = *input.EstimatedPayRate
+		setFields["estimated_pay_rate"] = *input.EstimatedPayRate
+	}
+	if input.PlaceOfWork != nil {
+		updatedTask.PlaceOfWork = *input.PlaceOfWork
+		setFields["place_of_work"] = *input.PlaceOfWork
+	}
+	if input.Latitude != nil || input.Longitude != nil {
+		coordinates, err := coordinatesFromInput(input.Latitude, input.Longitude)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updatedTask.Coordinates = coordinates
+		setFields["coordinates"] = coordinates
+	}
+	if input.WorkType != nil {
+		// Check if work type is valid
+		if !isValidWorkType(*input.WorkType) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":       "Invalid work type",
+				"valid_types": models.ValidTaskCategories(),
+			})
 			return
 		}
+		updatedTask.WorkType = models.TaskCategory(*input.WorkType)
+		setFields["work_type"] = updatedTask.WorkType
+	}
+	if input.PeopleNeeded != nil {
+		// Can't ask for fewer people than have already been selected
+		if *input.PeopleNeeded < len(existingTask.SelectedUsers) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":          "People needed cannot be less than the number of selected workers",
+				"selected_count": len(existingTask.SelectedUsers),
+			})
+			return
+		}
+		updatedTask.PeopleNeeded = *input.PeopleNeeded
+		setFields["people_needed"] = *input.PeopleNeeded
+	}
 
-		// Find the existing task
-		var existingTask models.Task
-		err = tasksCollection.FindOne(
-			context.TODO(),
-			bson.M{
-				"_id":           objectID,
-				"creator_email": email,
-			},
-		).Decode(&existingTask)
+	// If no fields were provided to update
+	if len(setFields) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields provided for update"})
+		return
+	}
+
+	// Material edits after people have applied must be explicitly confirmed by the poster
+	changes, err := materialTaskChanges(&existingTask, &updatedTask)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare task changes", "details": err.Error()})
+		return
+	}
+	hasApplicants := len(existingTask.Applicants) > 0
+	if hasApplicants && len(changes) > 0 && !input.ConfirmMaterialChange {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Task already has applicants. Resend with confirm_material_change set to true to notify them of these changes",
+			"changes": changes,
+		})
+		return
+	}
+
+	// Selected workers have to re-confirm after a material change
+	if len(changes) > 0 && len(existingTask.SelectedUsers) > 0 {
+		setFields["pending_confirmations"] = existingTask.SelectedUsers
+		updatedTask.PendingConfirmations = existingTask.SelectedUsers
+	}
+
+	setFields["updated_at"] = now
+	updatedTask.UpdatedAt = now
+	updatedTask.Version = existingTask.Version + 1
+
+	// Only apply the update if nobody else has bumped the version or settled the task in the meantime
+	filter := taskVersionFilter(objectID, existingTask.Version)
+	filter["status"] = bson.M{"$in": []models.TaskStatus{models.Open, models.InProgress}}
+	result, err := tasksCollection.UpdateOne(
+		context.TODO(),
+		filter,
+		bson.M{
+			"$set": setFields,
+			"$inc": bson.M{"version": 1},
+		},
+	)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task", "details": err.Error()})
+		return
+	}
 
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Task was modified by someone else. Reload it and try again"})
+		return
+	}
+
+	// Keep the workers' schedule entries in line with the task
+	_, err = scheduledTasksCollection.UpdateMany(
+		context.TODO(),
+		bson.M{"task_id": objectID},
+		bson.M{"$set": bson.M{
+			"title":         updatedTask.Title,
+			"task_date":     updatedTask.TaskDate,
+			"task_time":     updatedTask.TaskTime,
+			"start_at":      updatedTask.StartAt,
+			"end_at":        updatedTask.EndAt,
+			"time_zone":     updatedTask.TimeZone,
+			"place_of_work": updatedTask.PlaceOfWork,
+		}},
+	)
+	if err != nil {
+		log.Printf("Failed to sync scheduled tasks for %s: %v\n", taskID, err)
+	}
+
+	recordTaskEvent(c, objectID, models.TaskEdited, email, &existingTask, &updatedTask, nil)
+
+	// Applicants told about material changes don't need the bookmark notice too
+	alreadyNotified := []string{}
+	if len(changes) > 0 && hasApplicants {
+		notifyTaskChange(existingTask, changes)
+		alreadyNotified = existingTask.Applicants
+	}
+	go notifyBookmarkersOfEdit(existingTask, updatedTask, alreadyNotified)
+
+	c.Header("ETag", taskETag(updatedTask.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "Task updated successfully",
+		"updated":               result.ModifiedCount > 0,
+		"task_id":               taskID,
+		"version":               updatedTask.Version,
+		"changes":               changes,
+		"pending_confirmations": updatedTask.PendingConfirmations,
+	})
+}
+
+// taskScheduleInput carries the ways a client can say when a task happens
+type taskScheduleInput struct {
+	TaskDate        *string `json:"task_date"` // Format: YYYY-MM-DD
+	TaskTime        *string `json:"task_time"` // e.g. 14:00 or 2 PM
+	StartAt         *string `json:"start_at"`  // Format: YYYY-MM-DDTHH:MM in time_zone, or RFC 3339
+	EndAt           *string `json:"end_at"`
+	DurationMinutes *int    `json:"duration_minutes"`
+	TimeZone        *string `json:"time_zone"` // IANA name, defaults to America/New_York
+}
+
+// isSet reports whether any scheduling field was sent
+func (in taskScheduleInput) isSet() bool {
+	return in.TaskDate != nil || in.TaskTime != nil || in.StartAt != nil ||
+		in.EndAt != nil || in.DurationMinutes != nil || in.TimeZone != nil
+}
+
+// applyTaskSchedule works out a task's start, end and time zone from the input,
+// falling back to the task's current values for anything not sent
+func applyTaskSchedule(task *models.Task, in taskScheduleInput) error {
+	zone := task.TimeZone
+	if in.TimeZone != nil {
+		zone = *in.TimeZone
+	}
+	loc, err := utils.LoadTaskLocation(zone)
+	if err != nil {
+		return errors.New("Invalid time zone. Use an IANA name like America/New_York")
+	}
+
+	start := task.StartAt
+	if in.StartAt != nil {
+		start, err = utils.ParseTaskStart(*in.StartAt, loc)
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found or you don't have permission to update it"})
-			return
+			return errors.New("Invalid start_at format. Use YYYY-MM-DDTHH:MM")
 		}
-
-		// Update the task
-		update := bson.M{
-			"$set": bson.M{
-				"title":              input.Title,
-				"description":        input.Description,
-				"task_time":          input.TaskTime,
-				"task_date":          taskDate,
-				"estimated_pay_rate": input.EstimatedPayRate,
-				"place_of_work":      input.PlaceOfWork,
-				"work_type":          models.TaskCategory(input.WorkType),
-				"people_needed":      input.PeopleNeeded,
-				"updated_at":         now,
-			},
+	} else if in.TaskDate != nil || in.TaskTime != nil || in.TimeZone != nil {
+		date := task.TaskDate
+		if in.TaskDate != nil {
+			date, err = time.Parse("2006-01-02", *in.TaskDate)
+			if err != nil {
+				return errors.New("Invalid date format. Use YYYY-MM-DD")
+			}
+		}
+		timeOfDay := task.TaskTime
+		if in.TaskTime != nil {
+			timeOfDay = *in.TaskTime
+		}
+		if date.IsZero() || timeOfDay == "" {
+			return errors.New("Provide start_at, or both task_date and task_time")
 		}
 
-		result, err := tasksCollection.UpdateOne(
-			context.TODO(),
-			bson.M{"_id": objectID},
-			update,
-		)
+		var ok bool
+		start, ok = utils.CombineTaskDateTime(date, timeOfDay, loc)
+		if !ok {
+			return errors.New("Invalid task time. Use HH:MM")
+		}
+	}
+	if start.IsZero() {
+		return errors.New("Provide start_at, or both task_date and task_time")
+	}
 
+	duration := task.DurationMinutes
+	if in.DurationMinutes != nil {
+		duration = *in.DurationMinutes
+		if duration <= 0 {
+			return errors.New("Duration must be a positive number of minutes")
+		}
+	} else if in.EndAt != nil {
+		end, err := utils.ParseTaskStart(*in.EndAt, loc)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task", "details": err.Error()})
-			return
+			return errors.New("Invalid end_at format. Use YYYY-MM-DDTHH:MM")
+		}
+		if !end.After(start) {
+			return errors.New("Task end must be after its start")
 		}
+		duration = int(end.Sub(start).Minutes())
+	}
+	if duration <= 0 {
+		duration = utils.DefaultTaskDurationMinutes
+	}
 
-		c.JSON(http.StatusOK, gin.H{
-			"message": "Task updated successfully",
-			"updated": result.ModifiedCount > 0,
-			"task_id": input.ID,
-		})
+	task.StartAt = start.UTC()
+	task.EndAt = start.Add(time.Duration(duration) * time.Minute).UTC()
+	task.DurationMinutes = duration
+	task.TimeZone = loc.String()
+	task.TaskDate = utils.TaskDateOf(start, loc)
+	task.TaskTime = start.In(loc).Format("15:04")
+	return nil
+}
 
-	} else {
-		fmt.Print("Hey in the method 55")
-		// Create a new task
-		newTask := models.Task{
-			Title:            input.Title,
-			Description:      input.Description,
-			TaskTime:         input.TaskTime,
-			TaskDate:         taskDate,
-			EstimatedPayRate: input.EstimatedPayRate,
-			PlaceOfWork:      input.PlaceOfWork,
-			WorkType:         models.TaskCategory(input.WorkType),
-			PeopleNeeded:     input.PeopleNeeded,
-
-			// Meta data
-			CreatorEmail:  email,
-			CreatedAt:     now,
-			UpdatedAt:     now,
-			Status:        models.Open,
-			Views:         0,
-			Applicants:    []string{},
-			SelectedUsers: []string{},
-		}
-		fmt.Print("Hey in the method 66")
-		// Insert the task
-		result, err := tasksCollection.InsertOne(context.TODO(), newTask)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task", "details": err.Error()})
-			return
+// isValidWorkType checks the work type against the known task categories
+func isValidWorkType(workType string) bool {
+	for _, category := range models.ValidTaskCategories() {
+		if string(category) == workType {
+			return true
 		}
+	}
+	return false
+}
 
-		// Convert the InsertedID to a string
-		insertedID := result.InsertedID.(primitive.ObjectID).Hex()
-		fmt.Print("Hey in the method 77")
-		c.JSON(http.StatusCreated, gin.H{
-			"message": "Task created successfully",
-			"task_id": insertedID,
-		})
+// taskETag renders a task version as an ETag header value
+func taskETag(version int) string {
+	return fmt.Sprintf("\"%d\"", version)
+}
+
+// isEditableStatus reports whether a poster may still edit a task in this status
+func isEditableStatus(status models.TaskStatus) bool {
+	return status == models.Open || status == models.InProgress
+}
+
+// taskVersionFilter matches a task only while it is still at the given version
+func taskVersionFilter(taskID primitive.ObjectID, version int) bson.M {
+	filter := bson.M{"_id": taskID, "version": version}
+	if version == 0 {
+		// Tasks created before versioning have no version field
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	return filter
+}
+
+// parseTaskETag reads a task version back out of an If-Match header value
+func parseTaskETag(header string) (int, bool) {
+	header = strings.TrimPrefix(strings.TrimSpace(header), "W/")
+	version, err := strconv.Atoi(strings.Trim(header, "\""))
+	if err != nil {
+		return 0, false
 	}
+	return version, true
 }
 
 /*
 GetAllTasksForUser : returns all the available tasks for a logged in user
-Excludes tasks created by the viewer and tasks the viewer has already applied for
+Excludes tasks created by the viewer and tasks the viewer has already applied for.
+Takes the same filters as a saved search, or ?saved_search=<id> to apply one of the viewer's own.
 */
 func GetAllTasksForUser(c *gin.Context) {
 	// Get viewer's email from URL parameter
@@ -271,50 +633,40 @@ func GetAllTasksForUser(c *gin.Context) {
 		"status": models.Open, // Only return open tasks by default
 	}
 
-	// Don't show user's own tasks in the feed
-	filter["creator_email"] = bson.M{"$ne": viewerEmail}
+	// Don't show user's own tasks in the feed, nor tasks from users on either side of a block
+	blocked, err := blockedCounterparts(viewerEmail)
+	if err != nil {
+		fmt.Printf("Error retrieving block list for %s: %v\n", viewerEmail, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks", "details": err.Error()})
+		return
+	}
+	filter["creator_email"] = bson.M{"$ne": viewerEmail, "$nin": blocked}
 
 	// Don't show tasks that the user has already applied for
 	filter["applicants"] = bson.M{"$nin": []string{viewerEmail}}
 
-	// Filter by category if provided
-	if category := c.Query("category"); category != "" {
-		filter["work_type"] = category
-		fmt.Printf("Filtering by category: %s\n", category)
-	}
+	// Don't show tasks hidden pending moderation review
+	filter["hidden"] = bson.M{"$ne": true}
 
-	// Filter by date range if provided
-	if fromDate := c.Query("from_date"); fromDate != "" {
-		parsedFromDate, err := time.Parse("2006-01-02", fromDate)
-		if err == nil {
-			if filter["task_date"] == nil {
-				filter["task_date"] = bson.M{}
-			}
-			filter["task_date"].(bson.M)["$gte"] = parsedFromDate
-			fmt.Printf("Filtering from date: %s\n", fromDate)
-		} else {
-			fmt.Printf("Invalid from_date format: %s\n", fromDate)
-		}
-	}
+	// Don't show tasks that have already started, even before the expiry job marks them Expired
+	filter["start_at"] = bson.M{"$gt": time.Now()}
 
-	if toDate := c.Query("to_date"); toDate != "" {
-		parsedToDate, err := time.Parse("2006-01-02", toDate)
-		if err == nil {
-			if filter["task_date"] == nil {
-				filter["task_date"] = bson.M{}
-			}
-			filter["task_date"].(bson.M)["$lte"] = parsedToDate
-			fmt.Printf("Filtering to date: %s\n", toDate)
-		} else {
-			fmt.Printf("Invalid to_date format: %s\n", toDate)
-		}
+	// Narrow the feed by the request's filter or one of the viewer's saved searches
+	feedFilter, ok := feedFilterFromQuery(c, viewerEmail)
+	if !ok {
+		return
 	}
+	addTaskFilterQuery(filter, feedFilter)
 
 	fmt.Printf("Query filter: %+v\n", filter)
 
-	// Define options for sorting - oldest first
+	// Define options for sorting - oldest first, or soonest start when asked.
+	// sort=recommended ranks the results in-process after the query.
 	findOptions := options.Find().
 		SetSort(bson.M{"created_at": 1}) // Sort by oldest first (ascending order)
+	if c.Query("sort") == "start_time" {
+		findOptions.SetSort(bson.M{"start_at": 1})
+	}
 
 	// Execute the query
 	cursor, err := tasksCollection.Find(context.TODO(), filter, findOptions)
@@ -333,13 +685,47 @@ func GetAllTasksForUser(c *gin.Context) {
 		return
 	}
 
-	// Ensure tasks is never null, use empty array instead
-	if tasks == nil {
-		tasks = []models.Task{}
+	// Keywords and distance are checked here, before the location is redacted
+	matching := []models.Task{}
+	for _, task := range tasks {
+		if feedFilter.Matches(task) {
+			matching = append(matching, task)
+		}
 	}
+	tasks = matching
 
 	fmt.Printf("Found %d tasks for user %s\n", len(tasks), viewerEmail)
 
+	// Rank by the viewer's own history, explaining each task's place
+	if c.Query("sort") == "recommended" {
+		history, err := recommendationHistory(viewer)
+		if err != nil {
+			fmt.Printf("Error building recommendation history for %s: %v\n", viewerEmail, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rank tasks", "details": err.Error()})
+			return
+		}
+		recommend.Rank(history, tasks)
+	}
+
+	// Flag the tasks the viewer has bookmarked
+	taskIDs := []primitive.ObjectID{}
+	for _, task := range tasks {
+		taskIDs = append(taskIDs, task.ID)
+	}
+	bookmarked, err := bookmarkedTaskIDs(viewerEmail, taskIDs)
+	if err != nil {
+		fmt.Printf("Error retrieving bookmarks for %s: %v\n", viewerEmail, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks", "details": err.Error()})
+		return
+	}
+
+	// Show an approximate location until the viewer is selected
+	now := time.Now()
+	for i := range tasks {
+		redactTaskLocation(&tasks[i], viewerEmail, now)
+		tasks[i].Bookmarked = bookmarked[tasks[i].ID]
+	}
+
 	// Increment view count for each task (do this in background to not slow down response)
 	go func() {
 		ctx := context.Background()
@@ -409,8 +795,19 @@ func ApplyForTask(c *gin.Context) {
 		return
 	}
 
-	// Check if user has already applied
-	for _, email := range task.Applicants {
+	// Check that neither user has blocked the other
+	blocked, err := isBlockedBetween(task.CreatorEmail, applicantEmail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply for task", "details": err.Error()})
+		return
+	}
+	if blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot apply for this task"})
+		return
+	}
+
+	// Check if user has already applied
+	for _, email := range task.Applicants {
 		if email == applicantEmail {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "You have already applied for this task"})
 			return
@@ -433,9 +830,23 @@ func ApplyForTask(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	appliedTask := task
+	appliedTask.Applicants = append(append([]string{}, task.Applicants...), applicantEmail)
+	recordTaskEvent(c, objectID, models.TaskApplied, applicantEmail, &task, &appliedTask, nil)
+
+	// Applying is allowed, but warn if it clashes with something already on the schedule
+	response := gin.H{
 		"message": "Successfully applied for task",
-	})
+	}
+	conflict, err := findScheduleConflict(applicantEmail, task)
+	if err != nil {
+		log.Printf("Failed to check schedule conflicts for %s: %v\n", applicantEmail, err)
+	} else if conflict != nil {
+		response["warning"] = "This task overlaps with a task you are already scheduled for"
+		response["conflicting_task"] = conflict
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 /* GetAppliedTasks: returns all tasks that a user has applied for
@@ -478,6 +889,7 @@ func GetAppliedTasks(c *gin.Context) {
 
 	// Get task creators info to include with each task
 	var tasksWithCreatorInfo []gin.H
+	now := time.Now()
 	for _, task := range appliedTasks {
 		var creator models.Users
 		err := usersCollection.FindOne(
@@ -492,10 +904,13 @@ func GetAppliedTasks(c *gin.Context) {
 			"mobile": "",
 		}
 
+		// Contact details are only shared with selected workers while the task is active
+		contactVisible := task.ContactWindowOpen(viewerEmail, now)
 		if err == nil {
 			creatorInfo["name"] = creator.Name
-			creatorInfo["mobile"] = creator.Mobile
+			creatorInfo["mobile"] = visibleMobile(creator.Mobile, creator.HideMobile, contactVisible)
 		}
+		redactTaskLocation(&task, viewerEmail, now)
 
 		// Create a sanitized task object without other applicants' information
 		// Only include information that the applicant should see
@@ -507,6 +922,7 @@ func GetAppliedTasks(c *gin.Context) {
 			"task_date":          task.TaskDate,
 			"estimated_pay_rate": task.EstimatedPayRate,
 			"place_of_work":      task.PlaceOfWork,
+			"location_exact":     !task.LocationApproximate,
 			"work_type":          task.WorkType,
 			"people_needed":      task.PeopleNeeded,
 			"creator_email":      task.CreatorEmail,
@@ -523,7 +939,8 @@ func GetAppliedTasks(c *gin.Context) {
 			"task":    sanitizedTask,
 			"creator": creatorInfo,
 			// Include status information for the application
-			"selected": contains(task.SelectedUsers, viewerEmail),
+			"selected":        contains(task.SelectedUsers, viewerEmail),
+			"contact_visible": contactVisible,
 		})
 	}
 
@@ -587,25 +1004,84 @@ func AcceptTask(c *gin.Context) {
 		return
 	}
 
-	// Add user to selected list
-	update := bson.M{
-		"$push": bson.M{"selected_users": applicantEmail},
+	// Check that neither the poster nor the applicant has blocked the other
+	blocked, err := isBlockedBetween(task.CreatorEmail, applicantEmail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to acept a task", "details": err.Error()})
+		return
+	}
+	if blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot accept a blocked user for this task"})
+		return
 	}
 
-	_, err = tasksCollection.UpdateOne(
+	// Don't double-book the worker unless the poster explicitly overrides it
+	conflict, err := findScheduleConflict(applicantEmail, task)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check schedule conflicts", "details": err.Error()})
+		return
+	}
+	if conflict != nil && c.Query("override_conflict") != "true" {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":            "Worker is already scheduled for an overlapping task. Retry with override_conflict=true to accept anyway",
+			"conflicting_task": conflict,
+		})
+		return
+	}
+
+	// Select the worker only if they applied, aren't selected yet and a spot is left. Checking
+	// in the update itself stops a repeated or concurrent accept from booking them twice.
+	result, err := tasksCollection.UpdateOne(
 		context.TODO(),
-		bson.M{"_id": objectID},
-		update,
+		bson.M{
+			"_id":            objectID,
+			"status":         models.Open,
+			"applicants":     applicantEmail,
+			"selected_users": bson.M{"$ne": applicantEmail},
+			"$expr": bson.M{"$lt": bson.A{
+				bson.M{"$size": bson.M{"$ifNull": bson.A{"$selected_users", bson.A{}}}},
+				"$people_needed",
+			}},
+		},
+		bson.M{"$push": bson.M{"selected_users": applicantEmail}},
 	)
-	// to-do: add task to scheduled tasks.
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to acept a task", "details": err.Error()})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "This applicant can't be selected: they haven't applied, are already selected, or the task is full"})
+		return
+	}
+
+	// Undo the selection so the task, the escrow and the worker's schedule agree
+	unselect := func() {
+		_, err := tasksCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": objectID},
+			bson.M{"$pull": bson.M{"selected_users": applicantEmail}},
+		)
+		if err != nil {
+			log.Printf("Failed to undo selection of %s on task %s: %v\n", applicantEmail, taskID, err)
+		}
+	}
+
+	// The poster funds the worker's pay before the worker is booked
+	hold, err := holdEscrow(task, applicantEmail)
+	if err != nil {
+		unselect()
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": "Failed to fund escrow for this worker", "details": err.Error()})
+		return
+	}
 
 	if err := addTaskToScheduledTasks(task, applicantEmail); err != nil {
+		unselect()
+		settleEscrowLater("refund escrow after failed scheduling", func() error { return refundEscrow(task, applicantEmail) })
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule task"})
 		return
 	}
 
 	// Send email notification
-
 	go func() {
 		if err := utils.SendEmailNotification(applicantEmail, task.Title); err != nil {
 			log.Printf("Failed to send email to %s: %v\n", applicantEmail, err)
@@ -614,16 +1090,52 @@ func AcceptTask(c *gin.Context) {
 		}
 	}()
 
-	// to-do: send email notification to applicant that poster accepted him
+	acceptedTask := task
+	acceptedTask.SelectedUsers = append(append([]string{}, task.SelectedUsers...), applicantEmail)
+	recordTaskEvent(c, objectID, models.TaskAccepted, task.CreatorEmail, &task, &acceptedTask, bson.M{"worker_email": applicantEmail})
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to acept a task", "details": err.Error()})
-		return
+	if acceptedTask.NearlyFull() {
+		go notifyBookmarkers(acceptedTask, models.BookmarkNearlyFull,
+			fmt.Sprintf("A task you bookmarked is filling up: only %d spot(s) left.", acceptedTask.SpotsLeft()), acceptedTask.Applicants)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"message": "Successfully accepted the task",
-	})
+	}
+	if hold != nil {
+		response["escrow_cents"] = hold.AmountFor(models.EscrowAccount(task.ID, applicantEmail))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// findScheduleConflict returns the first active schedule entry of the worker that overlaps the task
+func findScheduleConflict(workerEmail string, task models.Task) (*models.ScheduledTask, error) {
+	// Tasks without a structured start can't be compared
+	if task.StartAt.IsZero() || task.EndAt.IsZero() {
+		return nil, nil
+	}
+
+	filter := bson.M{
+		"worker_email": workerEmail,
+		"task_id":      bson.M{"$ne": task.ID},
+		"status":       bson.M{"$nin": []models.TaskStatus{models.Cancelled, models.Completed}},
+		"start_at":     bson.M{"$lt": task.EndAt},
+		"end_at":       bson.M{"$gt": task.StartAt},
+	}
+
+	var conflict models.ScheduledTask
+	err := scheduledTasksCollection.FindOne(
+		context.TODO(),
+		filter,
+		options.FindOne().SetSort(bson.M{"start_at": 1}),
+	).Decode(&conflict)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &conflict, nil
 }
 
 func addTaskToScheduledTasks(task models.Task, workerEmail string) error {
@@ -635,6 +1147,9 @@ func addTaskToScheduledTasks(task models.Task, workerEmail string) error {
 		ScheduledAt: time.Now(),
 		TaskDate:    task.TaskDate,
 		TaskTime:    task.TaskTime,
+		StartAt:     task.StartAt,
+		EndAt:       task.EndAt,
+		TimeZone:    task.TimeZone,
 		Place:       task.PlaceOfWork,
 	}
 	_, err := scheduledTasksCollection.InsertOne(context.TODO(), entry)
@@ -693,6 +1208,11 @@ func GetScheduledTasks(c *gin.Context) {
 		return
 	}
 
+	now := time.Now()
+	for i := range tasks {
+		redactTaskLocation(&tasks[i], email, now)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"scheduled_tasks": tasks,
 		"count":           len(tasks),
@@ -737,14 +1257,49 @@ func EndTask(c *gin.Context) {
 		return
 	}
 
+	// Each worker completes their own assignment, once
+	count, err := scheduledTasksCollection.CountDocuments(
+		context.TODO(),
+		bson.M{"task_id": objectID, "worker_email": workerEmail, "status": models.Completed},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check your assignment"})
+		return
+	}
+	if count > 0 || task.Status == models.Completed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Your part of this task is already completed"})
+		return
+	}
+
+	// Ending the task stops the worker's clock if they forgot to check out
+	if _, err := checkOutAssignment(objectID, workerEmail, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop your clock", "details": err.Error()})
+		return
+	}
+
+	// Record on the assignment that the worker says they're done; disputes rely on it
+	ended, err := scheduledTasksCollection.UpdateOne(
+		context.TODO(),
+		activeAssignmentFilter(objectID, workerEmail),
+		bson.M{"$set": bson.M{"ended_at": time.Now()}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record the end of your assignment"})
+		return
+	}
+	if ended.MatchedCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Your part of this task is already settled"})
+		return
+	}
+
 	// Generate OTP for task owner
 	otp := utils.GenerateOTP()
 	expirationTime := time.Now().Add(30 * time.Minute) // OTP valid for 30 minutes
 
-	// Store OTP with task context in the database
+	// Store OTP with task context in the database, one per worker so several can finish at once
 	_, err = otpCollection.UpdateOne(
 		context.TODO(),
-		bson.M{"email": task.CreatorEmail, "task_id": objectID},
+		bson.M{"email": task.CreatorEmail, "task_id": objectID, "worker_email": workerEmail},
 		bson.M{"$set": bson.M{
 			"code":         otp,
 			"expires_at":   expirationTime,
@@ -760,6 +1315,22 @@ func EndTask(c *gin.Context) {
 		return
 	}
 
+	// Any proof photos the worker uploaded go along with the completion request
+	proofPhotos, err := attachmentsCollection.CountDocuments(context.TODO(), bson.M{
+		"task_id":        objectID,
+		"kind":           models.AttachmentCompletion,
+		"uploader_email": workerEmail,
+	})
+	if err != nil {
+		log.Printf("Failed to count completion photos for %s on task %s: %v\n", workerEmail, taskID, err)
+	}
+
+	recordTaskEvent(c, objectID, models.TaskEnded, workerEmail, nil, nil, bson.M{"proof_photos": proofPhotos})
+	recordTaskEvent(c, objectID, models.TaskOTPIssued, workerEmail, nil, nil, bson.M{
+		"issued_to":  task.CreatorEmail,
+		"expires_at": expirationTime,
+	})
+
 	// Send OTP to task owner asynchronously
 	go func() {
 		if err := utils.SendTaskCompletionOTP(task.CreatorEmail, otp, task.Title); err != nil {
@@ -771,9 +1342,10 @@ func EndTask(c *gin.Context) {
 
 	// Return success response
 	c.JSON(http.StatusOK, gin.H{
-		"message":    "Task completion OTP sent to the task owner",
-		"task_title": task.Title,
-		"task_owner": task.CreatorEmail,
+		"message":      "Task completion OTP sent to the task owner",
+		"task_title":   task.Title,
+		"task_owner":   task.CreatorEmail,
+		"proof_photos": proofPhotos,
 	})
 }
 
@@ -815,6 +1387,32 @@ func ValidateTaskCompletionOTP(c *gin.Context) {
 		return
 	}
 
+	// Keep the task as it was before completion for the audit log
+	var task models.Task
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	if task.Status == models.Completed || task.Status == models.Cancelled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Task is already " + string(task.Status)})
+		return
+	}
+
+	// Work out what the worker is owed for their part
+	var assignment models.ScheduledTask
+	err = scheduledTasksCollection.FindOne(context.TODO(), activeAssignmentFilter(objectID, storedOTP.WorkerEmail)).Decode(&assignment)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusConflict, gin.H{"error": "This worker's part of the task is already settled"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find the worker's assignment", "details": err.Error()})
+		return
+	}
+	payableMinutes, hoursSource, amountDue := assignmentPay(task, assignment)
+
 	// Start MongoDB transaction for atomic updates
 	session, err := client.StartSession()
 	if err != nil {
@@ -825,31 +1423,28 @@ func ValidateTaskCompletionOTP(c *gin.Context) {
 
 	// Transaction Function - all operations succeed or fail together
 	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
-		// Update task status to Completed
-		_, err = tasksCollection.UpdateOne(
-			sessCtx,
-			bson.M{"_id": objectID},
-			bson.M{"$set": bson.M{
-				"status":     models.Completed,
-				"updated_at": time.Now(),
-			}},
-		)
-		if err != nil {
-			return nil, err
-		}
+		now := time.Now()
 
-		// Update scheduled task status
-		_, err = scheduledTasksCollection.UpdateOne(
+		// Complete only this worker's assignment
+		result, err := scheduledTasksCollection.UpdateOne(
 			sessCtx,
-			bson.M{"task_id": objectID},
+			bson.M{
+				"task_id":      objectID,
+				"worker_email": storedOTP.WorkerEmail,
+				"status":       bson.M{"$nin": []models.TaskStatus{models.Completed, models.Cancelled}},
+			},
 			bson.M{"$set": bson.M{
-				"status":       "Completed",
-				"completed_at": time.Now(),
+				"status":       models.Completed,
+				"completed_at": now,
+				"amount_due":   amountDue,
 			}},
 		)
 		if err != nil {
 			return nil, err
 		}
+		if result.MatchedCount == 0 {
+			return nil, errAssignmentNotActive
+		}
 
 		// Increment completed tasks count for the worker
 		_, err = usersCollection.UpdateOne(
@@ -865,28 +1460,548 @@ func ValidateTaskCompletionOTP(c *gin.Context) {
 		_, err = otpCollection.DeleteOne(
 			sessCtx,
 			bson.M{
-				"email":   request.Email,
-				"task_id": objectID,
-				"context": "task_completion",
+				"email":        request.Email,
+				"task_id":      objectID,
+				"context":      "task_completion",
+				"worker_email": storedOTP.WorkerEmail,
 			},
 		)
 		if err != nil {
 			return nil, err
 		}
 
-		return nil, nil
+		// The task itself is completed once no assignment is left open
+		return completeTaskIfAssignmentsDone(sessCtx, objectID, now)
 	}
 
 	// Execute transaction
-	_, err = session.WithTransaction(context.TODO(), callback)
+	completed, err := session.WithTransaction(context.TODO(), callback)
+	if errors.Is(err, errAssignmentNotActive) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This worker's assignment is already completed or was released"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed", "details": err.Error()})
 		return
 	}
+	taskCompleted := completed.(bool)
+
+	completedTask := task
+	if taskCompleted {
+		completedTask.Status = models.Completed
+	}
+	recordTaskEvent(c, objectID, models.TaskOTPValidated, request.Email, &task, &completedTask, bson.M{
+		"worker_email":   storedOTP.WorkerEmail,
+		"task_completed": taskCompleted,
+	})
+
+	// Pay the worker out of escrow
+	settleEscrowLater("release escrow to "+storedOTP.WorkerEmail, func() error {
+		return releaseEscrow(task, storedOTP.WorkerEmail, dollarsToCents(amountDue))
+	})
+
+	message := "Task completed successfully!"
+	if !taskCompleted {
+		message = "Worker's part of the task completed. The task completes once every worker has finished"
+	}
 
 	// Return success response
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Task completed successfully!",
-		"task_id": request.TaskID,
+		"message":         message,
+		"task_id":         request.TaskID,
+		"worker_email":    storedOTP.WorkerEmail,
+		"task_completed":  taskCompleted,
+		"payable_minutes": payableMinutes,
+		"hours_source":    hoursSource,
+		"pay_rate":        task.EstimatedPayRate,
+		"amount_due":      amountDue,
+	})
+}
+
+// errAssignmentNotActive means a worker's assignment was already completed, cancelled or released
+var errAssignmentNotActive = errors.New("assignment is not active")
+
+// completeTaskIfAssignmentsDone marks a task Completed once at least one assignment is completed
+// and none are still active; released assignments no longer have a schedule row. It reports
+// whether the task is now completed.
+func completeTaskIfAssignmentsDone(ctx context.Context, taskID primitive.ObjectID, now time.Time) (bool, error) {
+	// Write to the task before counting, so two transactions settling its last assignments at
+	// once conflict and one retries and sees the other's work instead of both counting it active
+	_, err := tasksCollection.UpdateOne(ctx, bson.M{"_id": taskID}, bson.M{"$inc": bson.M{"assignment_revision": 1}})
+	if err != nil {
+		return false, err
+	}
+
+	active, err := scheduledTasksCollection.CountDocuments(ctx, bson.M{
+		"task_id": taskID,
+		"status":  bson.M{"$nin": []models.TaskStatus{models.Completed, models.Cancelled}},
+	})
+	if err != nil {
+		return false, err
+	}
+	if active > 0 {
+		return false, nil
+	}
+
+	done, err := scheduledTasksCollection.CountDocuments(ctx, bson.M{"task_id": taskID, "status": models.Completed})
+	if err != nil {
+		return false, err
+	}
+	if done == 0 {
+		return false, nil
+	}
+
+	_, err = tasksCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": taskID, "status": bson.M{"$in": []models.TaskStatus{models.Open, models.InProgress, models.Disputed}}},
+		bson.M{"$set": bson.M{
+			"status":     models.Completed,
+			"updated_at": now,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// cancelAssignment marks a worker's active schedule entry as cancelled, keeping it in their history
+func cancelAssignment(taskID primitive.ObjectID, workerEmail string, now time.Time) error {
+	_, err := scheduledTasksCollection.UpdateOne(
+		context.TODO(),
+		activeAssignmentFilter(taskID, workerEmail),
+		bson.M{"$set": bson.M{
+			"status":       models.Cancelled,
+			"cancelled_at": now,
+		}},
+	)
+	return err
+}
+
+// WithdrawApplication lets an applicant take back their application, releasing their spot if selected
+func WithdrawApplication(c *gin.Context) {
+	taskID := c.Param("task_id")
+	applicantEmail := c.Param("email")
+
+	// Convert string ID to ObjectID
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	// Find the task
+	var task models.Task
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	if !contains(task.Applicants, applicantEmail) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You have not applied for this task"})
+		return
+	}
+
+	if task.Status != models.Open && task.Status != models.InProgress {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Task can no longer be withdrawn from"})
+		return
+	}
+
+	// A worker who already finished their part can't give it back
+	finished, err := scheduledTasksCollection.CountDocuments(
+		context.TODO(),
+		bson.M{"task_id": objectID, "worker_email": applicantEmail, "status": models.Completed},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check your assignment"})
+		return
+	}
+	if finished > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Your part of this task is already completed"})
+		return
+	}
+
+	_, err = tasksCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": objectID},
+		bson.M{
+			"$pull": bson.M{"applicants": applicantEmail, "selected_users": applicantEmail},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to withdraw application", "details": err.Error()})
+		return
+	}
+
+	// A selected worker also gives up their schedule entry
+	wasSelected := contains(task.SelectedUsers, applicantEmail)
+	if wasSelected {
+		if err := cancelAssignment(objectID, applicantEmail, time.Now()); err != nil {
+			log.Printf("Failed to cancel scheduled task for %s: %v\n", applicantEmail, err)
+		}
+
+		// The others may all have finished already
+		if _, err := completeTaskIfAssignmentsDone(context.TODO(), objectID, time.Now()); err != nil {
+			log.Printf("Failed to check completion of task %s: %v\n", taskID, err)
+		}
+
+		settleEscrowLater("refund escrow for "+applicantEmail, func() error { return refundEscrow(task, applicantEmail) })
+	}
+
+	withdrawnTask := task
+	withdrawnTask.Applicants = removeString(task.Applicants, applicantEmail)
+	withdrawnTask.SelectedUsers = removeString(task.SelectedUsers, applicantEmail)
+	recordTaskEvent(c, objectID, models.TaskWithdrawn, applicantEmail, &task, &withdrawnTask, bson.M{"was_selected": wasSelected})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Application withdrawn successfully",
+	})
+}
+
+// CancelTask lets the poster cancel a task that has not been completed
+func CancelTask(c *gin.Context) {
+	taskID := c.Param("task_id")
+	email := c.Param("email")
+
+	// Convert string ID to ObjectID
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	// Find the task owned by this user
+	var task models.Task
+	err = tasksCollection.FindOne(
+		context.TODO(),
+		bson.M{"_id": objectID, "creator_email": email},
+	).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found or you don't have permission to cancel it"})
+		return
+	}
+
+	if task.Status != models.Open && task.Status != models.InProgress {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only open or in-progress tasks can be cancelled"})
+		return
+	}
+
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	// The reason is optional, so an empty body is fine
+	_ = c.ShouldBindJSON(&input)
+
+	now := time.Now()
+	_, err = tasksCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": objectID},
+		bson.M{
+			"$set": bson.M{
+				"status":     models.Cancelled,
+				"updated_at": now,
+			},
+			// Cancelling is a change like any other, so cached copies and calendar entries refresh
+			"$inc": bson.M{"version": 1},
+		},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel task", "details": err.Error()})
+		return
+	}
+
+	// Mark every unfinished worker's schedule entry as cancelled
+	_, err = scheduledTasksCollection.UpdateMany(
+		context.TODO(),
+		bson.M{"task_id": objectID, "status": bson.M{"$ne": models.Completed}},
+		bson.M{"$set": bson.M{
+			"status":       models.Cancelled,
+			"cancelled_at": now,
+		}},
+	)
+	if err != nil {
+		log.Printf("Failed to cancel scheduled tasks for %s: %v\n", taskID, err)
+	}
+
+	cancelledTask := task
+	cancelledTask.Status = models.Cancelled
+	cancelledTask.Version = task.Version + 1
+	recordTaskEvent(c, objectID, models.TaskCancelled, email, &task, &cancelledTask, bson.M{"reason": input.Reason})
+
+	// Give the poster back whatever is still held; workers already paid have nothing left in escrow
+	for _, worker := range task.SelectedUsers {
+		settleEscrowLater("refund escrow for "+worker, func() error { return refundEscrow(task, worker) })
+	}
+
+	// Let selected workers know
+	go func() {
+		for _, worker := range task.SelectedUsers {
+			if err := utils.SendTaskCancelledNotification(worker, task.Title); err != nil {
+				log.Printf("Failed to send cancellation email to %s: %v\n", worker, err)
+			}
+		}
+	}()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task cancelled successfully",
+		"task_id": taskID,
+	})
+}
+
+// Helper function to return a copy of a slice without the given item
+func removeString(slice []string, item string) []string {
+	result := []string{}
+	for _, s := range slice {
+		if s != item {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// materialTaskFields are the task fields whose change affects people who already applied
+var materialTaskFields = []string{"task_date", "task_time", "start_at", "end_at", "time_zone", "estimated_pay_rate", "place_of_work", "people_needed"}
+
+// materialTaskChanges returns the material fields that differ between two task snapshots
+func materialTaskChanges(before, after *models.Task) (gin.H, error) {
+	beforeDiff, afterDiff, err := diffTasks(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := gin.H{}
+	for _, field := range materialTaskFields {
+		if value, ok := afterDiff[field]; ok {
+			changes[field] = gin.H{"from": beforeDiff[field], "to": value}
+		}
+	}
+	return changes, nil
+}
+
+// notifyTaskChange emails applicants about material edits, asking selected workers to re-confirm.
+// Only workers whose contact window is open see the exact old and new place of work.
+func notifyTaskChange(task models.Task, changes gin.H) {
+	now := time.Now()
+
+	go func() {
+		for _, applicant := range task.Applicants {
+			selected := contains(task.SelectedUsers, applicant)
+			lines := taskChangeLines(changes, task.ContactWindowOpen(applicant, now))
+			if err := utils.SendTaskChangeNotification(applicant, task.Title, lines, selected); err != nil {
+				log.Printf("Failed to send task change email to %s: %v\n", applicant, err)
+			}
+		}
+	}()
+}
+
+// taskChangeLines describes each material change on its own line, reducing the place of
+// work to an approximate location unless exactPlace is set
+func taskChangeLines(changes gin.H, exactPlace bool) []string {
+	lines := []string{}
+	for _, field := range materialTaskFields {
+		change, ok := changes[field].(gin.H)
+		if !ok {
+			continue
+		}
+		from, to := formatChangeValue(change["from"]), formatChangeValue(change["to"])
+		if field == "place_of_work" && !exactPlace {
+			from, to = utils.ApproximateLocation(from), utils.ApproximateLocation(to)
+			if from == to {
+				lines = append(lines, "place_of_work: changed, shared once you're selected")
+				continue
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s -> %s", field, from, to))
+	}
+	return lines
+}
+
+// formatChangeValue renders a changed field value for a notification email
+func formatChangeValue(value interface{}) string {
+	if date, ok := value.(primitive.DateTime); ok {
+		return date.Time().UTC().Format("2006-01-02")
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// RepostTask lets the poster post an expired or cancelled task again with a new date.
+// The original is left as it was; the copy starts fresh with no applicants.
+func RepostTask(c *gin.Context) {
+	taskID := c.Param("task_id")
+	email := c.Param("email")
+
+	// Convert string ID to ObjectID
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	// Find the task owned by this user
+	var original models.Task
+	err = tasksCollection.FindOne(
+		context.TODO(),
+		bson.M{"_id": objectID, "creator_email": email},
+	).Decode(&original)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found or you don't have permission to repost it"})
+		return
+	}
+
+	if original.Status != models.Expired && original.Status != models.Cancelled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only expired or cancelled tasks can be reposted"})
+		return
+	}
+
+	// The new date is required; anything else not sent is carried over
+	var input taskScheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !input.isSet() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide start_at, or task_date and task_time, for the reposted task"})
+		return
+	}
+
+	now := time.Now()
+
+	newTask := models.Task{
+		Title:            original.Title,
+		Description:      original.Description,
+		TaskDate:         original.TaskDate,
+		TaskTime:         original.TaskTime,
+		DurationMinutes:  original.DurationMinutes,
+		TimeZone:         original.TimeZone,
+		EstimatedPayRate: original.EstimatedPayRate,
+		PlaceOfWork:      original.PlaceOfWork,
+		Coordinates:      original.Coordinates,
+		WorkType:         original.WorkType,
+		PeopleNeeded:     original.PeopleNeeded,
+
+		// Meta data
+		CreatorEmail:  email,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Status:        models.Open,
+		Views:         0,
+		Applicants:    []string{},
+		SelectedUsers: []string{},
+		Version:       1,
+	}
+
+	if err := applyTaskSchedule(&newTask, input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !newTask.StartAt.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Task start must be in the future"})
+		return
+	}
+
+	result, err := tasksCollection.InsertOne(context.TODO(), newTask)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to repost task", "details": err.Error()})
+		return
+	}
+
+	newTask.ID = result.InsertedID.(primitive.ObjectID)
+	recordTaskEvent(c, newTask.ID, models.TaskCreated, email, nil, &newTask, bson.M{"reposted_from": objectID})
+	go notifySavedSearches(newTask)
+
+	c.Header("ETag", taskETag(newTask.Version))
+	c.JSON(http.StatusCreated, gin.H{
+		"message":       "Task reposted successfully",
+		"task_id":       newTask.ID.Hex(),
+		"reposted_from": taskID,
+		"version":       newTask.Version,
+	})
+}
+
+// ReconfirmTask lets a selected worker confirm they can still do a task after a material change, or drop out
+func ReconfirmTask(c *gin.Context) {
+	taskID := c.Param("task_id")
+	workerEmail := c.Param("email")
+
+	var input struct {
+		Confirm *bool `json:"confirm" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Convert string ID to ObjectID
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	// Find the task
+	var task models.Task
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	if !contains(task.PendingConfirmations, workerEmail) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No confirmation is pending for you on this task"})
+		return
+	}
+
+	update := bson.M{
+		"$pull": bson.M{"pending_confirmations": workerEmail},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+	if !*input.Confirm {
+		// Dropping out releases the worker's spot entirely
+		update["$pull"] = bson.M{
+			"pending_confirmations": workerEmail,
+			"selected_users":        workerEmail,
+			"applicants":            workerEmail,
+		}
+	}
+
+	_, err = tasksCollection.UpdateOne(context.TODO(), bson.M{"_id": objectID}, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record confirmation", "details": err.Error()})
+		return
+	}
+
+	updatedTask := task
+	updatedTask.PendingConfirmations = removeString(task.PendingConfirmations, workerEmail)
+
+	if *input.Confirm {
+		recordTaskEvent(c, objectID, models.TaskAccepted, workerEmail, &task, &updatedTask, bson.M{"reconfirmed": true})
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Task re-confirmed successfully",
+		})
+		return
+	}
+
+	if err := cancelAssignment(objectID, workerEmail, time.Now()); err != nil {
+		log.Printf("Failed to cancel scheduled task for %s: %v\n", workerEmail, err)
+	}
+
+	// The others may all have finished already
+	if _, err := completeTaskIfAssignmentsDone(context.TODO(), objectID, time.Now()); err != nil {
+		log.Printf("Failed to check completion of task %s: %v\n", taskID, err)
+	}
+
+	settleEscrowLater("refund escrow for "+workerEmail, func() error { return refundEscrow(task, workerEmail) })
+
+	updatedTask.SelectedUsers = removeString(task.SelectedUsers, workerEmail)
+	updatedTask.Applicants = removeString(task.Applicants, workerEmail)
+	recordTaskEvent(c, objectID, models.TaskWithdrawn, workerEmail, &task, &updatedTask, bson.M{"reason": "declined_task_change"})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "You have dropped out of the task",
 	})
 }
@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CheckIn starts the clock on a worker's assignment
+func CheckIn(c *gin.Context) {
+	changeWorkState(c, "", models.WorkWorking, "Checked in")
+}
+
+// PauseWork stops the clock for a break
+func PauseWork(c *gin.Context) {
+	changeWorkState(c, models.WorkWorking, models.WorkPaused, "Work paused")
+}
+
+// ResumeWork restarts the clock after a break
+func ResumeWork(c *gin.Context) {
+	changeWorkState(c, models.WorkPaused, models.WorkWorking, "Work resumed")
+}
+
+// CheckOut stops the clock for good and sends the logged time to the poster for approval
+func CheckOut(c *gin.Context) {
+	taskID := c.Param("task_id")
+	workerEmail := c.Param("email")
+
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	assignment, err := checkOutAssignment(objectID, workerEmail, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check out", "details": err.Error()})
+		return
+	}
+	if assignment == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You are not checked in to this task"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Checked out. Your time has been sent to the poster for approval",
+		"logged_minutes": assignment.LoggedMinutes,
+	})
+}
+
+// changeWorkState moves a worker's active assignment from one work state to the next,
+// closing or opening a work segment as needed
+func changeWorkState(c *gin.Context, from, to models.WorkState, message string) {
+	taskID := c.Param("task_id")
+	workerEmail := c.Param("email")
+
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	var task models.Task
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	if task.Status != models.Open && task.Status != models.InProgress {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Time can only be tracked on active tasks"})
+		return
+	}
+
+	var assignment models.ScheduledTask
+	err = scheduledTasksCollection.FindOne(context.TODO(), activeAssignmentFilter(objectID, workerEmail)).Decode(&assignment)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You are not scheduled for this task"})
+		return
+	}
+
+	if assignment.WorkState != from {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Can't do that while %s", describeWorkState(assignment.WorkState))})
+		return
+	}
+
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"work_state": to}}
+	if to == models.WorkWorking {
+		update["$push"] = bson.M{"segments": models.WorkSegment{Start: now}}
+	} else {
+		update["$set"].(bson.M)[fmt.Sprintf("segments.%d.end", len(assignment.Segments)-1)] = now
+	}
+
+	// Matching on the current state keeps two quick taps from both applying
+	filter := activeAssignmentFilter(objectID, workerEmail)
+	filter["work_state"] = workStateFilter(from)
+
+	result, err := scheduledTasksCollection.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update time log", "details": err.Error()})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Your time log changed, please try again"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    message,
+		"work_state": to,
+		"at":         now,
+	})
+}
+
+// checkOutAssignment closes the worker's running segment and records their logged time. It
+// returns nil without error when the worker isn't checked in, and any database error as is.
+func checkOutAssignment(taskID primitive.ObjectID, workerEmail string, now time.Time) (*models.ScheduledTask, error) {
+	filter := activeAssignmentFilter(taskID, workerEmail)
+	filter["work_state"] = bson.M{"$in": []models.WorkState{models.WorkWorking, models.WorkPaused}}
+
+	var assignment models.ScheduledTask
+	err := scheduledTasksCollection.FindOne(context.TODO(), filter).Decode(&assignment)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	set := bson.M{"work_state": models.WorkCheckedOut, "time_approval": models.TimePending}
+	if assignment.WorkState == models.WorkWorking {
+		last := len(assignment.Segments) - 1
+		assignment.Segments[last].End = &now
+		set[fmt.Sprintf("segments.%d.end", last)] = now
+	}
+	assignment.LoggedMinutes = assignment.WorkedMinutes(now)
+	set["logged_minutes"] = assignment.LoggedMinutes
+
+	filter["work_state"] = assignment.WorkState
+	result, err := scheduledTasksCollection.UpdateOne(context.TODO(), filter, bson.M{"$set": set})
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, nil
+	}
+
+	assignment.WorkState = models.WorkCheckedOut
+	assignment.TimeApproval = models.TimePending
+	return &assignment, nil
+}
+
+// GetTimesheet shows the poster every worker's time on a task, and a worker their own
+func GetTimesheet(c *gin.Context) {
+	taskID := c.Param("task_id")
+	email := c.Param("email")
+
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	var task models.Task
+	err = tasksCollection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	filter := bson.M{"task_id": objectID}
+	if task.CreatorEmail != email {
+		if !contains(task.SelectedUsers, email) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the poster and selected workers can view time logs"})
+			return
+		}
+		filter["worker_email"] = email
+	}
+
+	cursor, err := scheduledTasksCollection.Find(context.TODO(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query scheduled_tasks"})
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	var assignments []models.ScheduledTask
+	if err := cursor.All(context.TODO(), &assignments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode scheduled_tasks"})
+		return
+	}
+
+	now := time.Now()
+	entries := []gin.H{}
+	for _, assignment := range assignments {
+		minutes, source, amount := assignmentPay(task, assignment)
+		entries = append(entries, gin.H{
+			"worker_email":     assignment.Worker,
+			"work_state":       assignment.WorkState,
+			"segments":         assignment.Segments,
+			"worked_minutes":   assignment.WorkedMinutes(now),
+			"logged_minutes":   assignment.LoggedMinutes,
+			"approved_minutes": assignment.ApprovedMinutes,
+			"time_approval":    assignment.TimeApproval,
+			"time_note":        assignment.TimeNote,
+			"payable_minutes":  minutes,
+			"hours_source":     source,
+			"amount_due":       amount,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"task_id":    taskID,
+		"pay_rate":   task.EstimatedPayRate,
+		"timesheets": entries,
+	})
+}
+
+// ApproveTime lets the poster accept a worker's logged time as is, or adjust it with a reason
+func ApproveTime(c *gin.Context) {
+	taskID := c.Param("task_id")
+	posterEmail := c.Param("email")
+
+	var input struct {
+		WorkerEmail     string `json:"worker_email" binding:"required,email"`
+		ApprovedMinutes *int   `json:"approved_minutes"` // Leave out to approve the logged time
+		Note            string `json:"note"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	var task models.Task
+	err = tasksCollection.FindOne(
+		context.TODO(),
+		bson.M{"_id": objectID, "creator_email": posterEmail},
+	).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found or you don't have permission to approve time on it"})
+		return
+	}
+
+	var assignment models.ScheduledTask
+	err = scheduledTasksCollection.FindOne(context.TODO(), activeAssignmentFilter(objectID, input.WorkerEmail)).Decode(&assignment)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Worker is not scheduled for this task"})
+		return
+	}
+
+	if assignment.WorkState != models.WorkCheckedOut {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Worker has not checked out yet"})
+		return
+	}
+
+	approved := assignment.LoggedMinutes
+	approval := models.TimeApproved
+	if input.ApprovedMinutes != nil && *input.ApprovedMinutes != assignment.LoggedMinutes {
+		if *input.ApprovedMinutes < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Approved minutes can't be negative"})
+			return
+		}
+		if input.Note == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Give a reason when adjusting the logged time"})
+			return
+		}
+		approved = *input.ApprovedMinutes
+		approval = models.TimeAdjusted
+	}
+
+	amount := utils.AmountDue(approved, task.EstimatedPayRate)
+	_, err = scheduledTasksCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": assignment.ID},
+		bson.M{"$set": bson.M{
+			"approved_minutes": approved,
+			"time_approval":    approval,
+			"time_note":        input.Note,
+			"amount_due":       amount,
+		}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve time", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Time " + string(approval),
+		"worker_email":     input.WorkerEmail,
+		"logged_minutes":   assignment.LoggedMinutes,
+		"approved_minutes": approved,
+		"amount_due":       amount,
+	})
+}
+
+// assignmentPay works out what a worker is owed: approved time if the poster reviewed it,
+// logged time otherwise, and the task's scheduled length if they never checked in
+func assignmentPay(task models.Task, assignment models.ScheduledTask) (int, string, float64) {
+	minutes, source := assignment.PayableMinutes(), "logged"
+	switch {
+	case assignment.ApprovedMinutes != nil:
+		source = "approved"
+	case len(assignment.Segments) == 0:
+		minutes, source = task.DurationMinutes, "scheduled"
+	}
+	return minutes, source, utils.AmountDue(minutes, task.EstimatedPayRate)
+}
+
+// activeAssignmentFilter matches a worker's assignment on a task that is neither completed nor cancelled
+func activeAssignmentFilter(taskID primitive.ObjectID, workerEmail string) bson.M {
+	return bson.M{
+		"task_id":      taskID,
+		"worker_email": workerEmail,
+		"status":       bson.M{"$nin": []models.TaskStatus{models.Completed, models.Cancelled}},
+	}
+}
+
+// workStateFilter matches a work state, treating not started as a missing field
+func workStateFilter(state models.WorkState) interface{} {
+	if state == "" {
+		return bson.M{"$in": []interface{}{nil, ""}}
+	}
+	return state
+}
+
+// describeWorkState names a work state for error messages
+func describeWorkState(state models.WorkState) string {
+	switch state {
+	case models.WorkWorking:
+		return "checked in"
+	case models.WorkPaused:
+		return "paused"
+	case models.WorkCheckedOut:
+		return "checked out"
+	}
+	return "not checked in"
+}
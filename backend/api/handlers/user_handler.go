@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 	"ufpeerassist/backend/api/utils"
 	"ufpeerassist/backend/models"
@@ -23,6 +24,9 @@ var authCollection *mongo.Collection
 var otpCollection *mongo.Collection
 var passwordResetReason = "passwordreset"
 
+// databaseName is the database every collection lives in; tests use a scratch one
+var databaseName = "ufpeerassist"
+
 // Initialize MongoDB connection
 func InitMongoDB() {
 	// generic uri
@@ -30,15 +34,30 @@ func InitMongoDB() {
 	// replication set uri
 	uri := "mongodb://localhost:27017/ufpeerassist?replicaSet=rs0"
 
-	// Connect to MongoDB
+	if err := connectMongoDB(uri); err != nil {
+		log.Fatal("❌ ", err)
+	}
+	if err := initUserCollections(); err != nil {
+		log.Fatal("❌ ", err)
+	}
+
+	fmt.Println("✅ MongoDB connected, TTL index for OTP set!")
+}
+
+// connectMongoDB connects the shared client
+func connectMongoDB(uri string) error {
 	var err error
 	client, err = mongo.Connect(context.TODO(), options.Client().ApplyURI(uri))
 	if err != nil {
-		log.Fatal("❌ Failed to connect to MongoDB:", err)
+		return fmt.Errorf("failed to connect to MongoDB: %w", err)
 	}
+	return nil
+}
 
+// initUserCollections sets up the user, auth and OTP collections
+func initUserCollections() error {
 	// Select database and collections
-	db := client.Database("ufpeerassist")
+	db := client.Database(databaseName)
 	usersCollection = db.Collection("users")
 	authCollection = db.Collection("auth")
 	otpCollection = db.Collection("otp") // Add OTP collection
@@ -49,12 +68,46 @@ func InitMongoDB() {
 		Options: options.Index().SetExpireAfterSeconds(0), // TTL index (expire immediately when time is reached)
 	}
 
-	_, err = otpCollection.Indexes().CreateOne(context.TODO(), indexModel)
+	_, err := otpCollection.Indexes().CreateOne(context.TODO(), indexModel)
 	if err != nil {
-		log.Fatal("❌ Failed to create TTL index:", err)
+		return fmt.Errorf("failed to create TTL index: %w", err)
 	}
+	return nil
+}
 
-	fmt.Println("✅ MongoDB connected, TTL index for OTP set!")
+// InitCollections initializes every collection after InitMongoDB has connected
+func InitCollections() {
+	InitTasksCollection()
+	InitScheduledTasksCollection()
+	InitReportsCollection()
+	InitBlocksCollection()
+	InitTaskEventsCollection()
+	InitRemindersCollection()
+	InitDisputesCollection()
+	InitLedgerCollection()
+	InitMessagesCollection()
+	InitCommentsCollection()
+	InitAttachmentsCollection()
+	InitInvitationsCollection()
+	InitSavedSearchesCollection()
+	InitBookmarksCollection()
+}
+
+// InitTestDatabase points the handlers at a scratch database and initializes every collection
+// in it, so tests can exercise the real handlers. Transactions need uri to be a replica set.
+func InitTestDatabase(uri, name string) (*mongo.Database, error) {
+	databaseName = name
+	if err := connectMongoDB(uri); err != nil {
+		return nil, err
+	}
+	if err := client.Database(name).Drop(context.TODO()); err != nil {
+		return nil, err
+	}
+	if err := initUserCollections(); err != nil {
+		return nil, err
+	}
+	InitCollections()
+	return client.Database(name), nil
 }
 
 // Signup Handler with Transaction Support
@@ -292,9 +345,15 @@ func UpdateUserProfile(c *gin.Context) {
 
 	// Define input structure for profile update
 	var input struct {
-		Name   string `json:"name" binding:"omitempty"`
-		Mobile string `json:"mobile" binding:"omitempty"`
-		// next sprint - to add other fields related to user
+		Name         string                       `json:"name" binding:"omitempty"`
+		Mobile       string                       `json:"mobile" binding:"omitempty"`
+		HideMobile   *bool                        `json:"hide_mobile"` // Never share the mobile number with posters or workers
+		Bio          *string                      `json:"bio"`
+		Major        *string                      `json:"major"`
+		Year         *string                      `json:"year"`
+		Skills       *[]models.TaskCategory       `json:"skills"`
+		Availability *[]models.AvailabilityWindow `json:"availability"`
+		InDirectory  *bool                        `json:"in_directory"` // List the user in the searchable worker directory
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -313,6 +372,57 @@ func UpdateUserProfile(c *gin.Context) {
 		updateDoc["mobile"] = input.Mobile
 	}
 
+	if input.HideMobile != nil {
+		updateDoc["hide_mobile"] = *input.HideMobile
+	}
+
+	if input.Bio != nil {
+		bio := strings.TrimSpace(*input.Bio)
+		if len(bio) > models.MaxBioLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Bio must be at most %d characters", models.MaxBioLength)})
+			return
+		}
+		updateDoc["bio"] = bio
+	}
+
+	if input.Major != nil {
+		major := strings.TrimSpace(*input.Major)
+		if len(major) > models.MaxMajorLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Major must be at most %d characters", models.MaxMajorLength)})
+			return
+		}
+		updateDoc["major"] = major
+	}
+
+	if input.Year != nil {
+		if *input.Year != "" && !isValidAcademicYear(*input.Year) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year", "valid_years": models.ValidAcademicYears()})
+			return
+		}
+		updateDoc["year"] = *input.Year
+	}
+
+	if input.Skills != nil {
+		skills, err := models.ValidateSkills(*input.Skills)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "valid_skills": models.ValidTaskCategories()})
+			return
+		}
+		updateDoc["skills"] = skills
+	}
+
+	if input.Availability != nil {
+		if err := models.ValidateAvailability(*input.Availability); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updateDoc["availability"] = *input.Availability
+	}
+
+	if input.InDirectory != nil {
+		updateDoc["in_directory"] = *input.InDirectory
+	}
+
 	// If no fields were provided to update
 	if len(updateDoc) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields provided for update"})
@@ -355,6 +465,16 @@ func GetUserProfile(c *gin.Context) {
 		Mobile         string `json:"mobile" bson:"mobile"`
 		Rating         string `json:"rating" bson:"rating"`
 		CompletedTasks int    `json:"completed_tasks" bson:"completed_tasks"`
+		DisputesWon    int    `json:"disputes_won" bson:"disputes_won"`
+		DisputesLost   int    `json:"disputes_lost" bson:"disputes_lost"`
+		HideMobile     bool   `json:"hide_mobile" bson:"hide_mobile"`
+
+		Bio          string                      `json:"bio" bson:"bio"`
+		Major        string                      `json:"major" bson:"major"`
+		Year         models.AcademicYear         `json:"year" bson:"year"`
+		Skills       []models.TaskCategory       `json:"skills" bson:"skills"`
+		Availability []models.AvailabilityWindow `json:"availability" bson:"availability"`
+		InDirectory  bool                        `json:"in_directory" bson:"in_directory"`
 		// Add any other fields you want to include
 	}
 
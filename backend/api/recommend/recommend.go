@@ -0,0 +1,146 @@
+package recommend
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+)
+
+// Signal weights. Each signal is capped so one strong habit can't drown out the others.
+const (
+	completedPoints    = 3.0 // Per completed task of the same type
+	completedCap       = 5
+	skillPoints        = 8.0 // Declared skill matches the task's type
+	appliedPoints      = 1.5 // Per past application to the same type
+	appliedCap         = 4
+	placePoints        = 4.0 // Same area as somewhere the worker has completed a task
+	availabilityPoints = 3.0 // Task starts inside one of the worker's availability windows
+)
+
+// History is what the feed knows about a worker when ranking tasks for them
+type History struct {
+	CompletedByType map[models.TaskCategory]int
+	AppliedByType   map[models.TaskCategory]int
+	Places          map[string]int // Approximate locations of completed tasks
+	Skills          []models.TaskCategory
+	Availability    []models.AvailabilityWindow
+}
+
+// NewHistory builds a worker's history from the tasks they completed and applied to
+func NewHistory(worker models.Users, completed, applied []models.Task) History {
+	history := History{
+		CompletedByType: map[models.TaskCategory]int{},
+		AppliedByType:   map[models.TaskCategory]int{},
+		Places:          map[string]int{},
+		Skills:          worker.Skills,
+		Availability:    worker.Availability,
+	}
+	for _, task := range completed {
+		history.CompletedByType[task.WorkType]++
+		if place := utils.ApproximateLocation(task.PlaceOfWork); place != utils.LocationHidden {
+			history.Places[place]++
+		}
+	}
+	for _, task := range applied {
+		history.AppliedByType[task.WorkType]++
+	}
+	return history
+}
+
+// reason is one signal's contribution to a score
+type reason struct {
+	points float64
+	text   string
+}
+
+// Score rates how well a task suits a worker and explains the rating
+func Score(history History, task models.Task) models.Recommendation {
+	reasons := []reason{}
+
+	if done := history.CompletedByType[task.WorkType]; done > 0 {
+		reasons = append(reasons, reason{
+			completedPoints * float64(min(done, completedCap)),
+			fmt.Sprintf("You've done %d %s %s", done, task.WorkType, plural(done, "task", "tasks")),
+		})
+	}
+
+	for _, skill := range history.Skills {
+		if skill == task.WorkType {
+			reasons = append(reasons, reason{skillPoints, fmt.Sprintf("Matches your %s skill", skill)})
+			break
+		}
+	}
+
+	if applied := history.AppliedByType[task.WorkType]; applied > 0 {
+		reasons = append(reasons, reason{
+			appliedPoints * float64(min(applied, appliedCap)),
+			fmt.Sprintf("You've applied to %d %s %s", applied, task.WorkType, plural(applied, "task", "tasks")),
+		})
+	}
+
+	place := utils.ApproximateLocation(task.PlaceOfWork)
+	if history.Places[place] > 0 {
+		reasons = append(reasons, reason{placePoints, fmt.Sprintf("Near where you've worked before (%s)", place)})
+	}
+
+	if !task.StartAt.IsZero() {
+		start := task.StartAt
+		if loc, err := utils.LoadTaskLocation(task.TimeZone); err == nil {
+			start = start.In(loc)
+		}
+		for _, window := range history.Availability {
+			if window.Covers(start) {
+				reasons = append(reasons, reason{availabilityPoints, fmt.Sprintf("Fits your %s availability", window.Day)})
+				break
+			}
+		}
+	}
+
+	// Strongest reason first
+	sort.SliceStable(reasons, func(i, j int) bool { return reasons[i].points > reasons[j].points })
+
+	recommendation := models.Recommendation{Reasons: []string{}}
+	for _, r := range reasons {
+		recommendation.Score += r.points
+		recommendation.Reasons = append(recommendation.Reasons, r.text)
+	}
+	return recommendation
+}
+
+// Rank scores the tasks and orders them best first. Ties go to the task starting soonest,
+// then the newest.
+func Rank(history History, tasks []models.Task) {
+	for i := range tasks {
+		recommendation := Score(history, tasks[i])
+		tasks[i].Recommendation = &recommendation
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if a.Recommendation.Score != b.Recommendation.Score {
+			return a.Recommendation.Score > b.Recommendation.Score
+		}
+		if !a.StartAt.Equal(b.StartAt) {
+			return startsBefore(a.StartAt, b.StartAt)
+		}
+		return a.CreatedAt.After(b.CreatedAt)
+	})
+}
+
+// startsBefore orders start times, putting unknown (zero) starts last
+func startsBefore(a, b time.Time) bool {
+	if a.IsZero() || b.IsZero() {
+		return !a.IsZero()
+	}
+	return a.Before(b)
+}
+
+// plural picks the singular or plural form for a count
+func plural(count int, singular, pluralForm string) string {
+	if count == 1 {
+		return singular
+	}
+	return pluralForm
+}
@@ -19,18 +19,39 @@ func SetupRoutes(router *gin.Engine) {
 	// user routes
 	router.GET("/users/:email/profileinfo", handlers.GetUserProfile)
 	router.PUT("/users/:email/profileupdate", handlers.UpdateUserProfile)
+	router.GET("/users/:email/public", handlers.GetPublicProfile) // Profile without contact details
+	router.POST("/users/:email/avatar", handlers.UploadAvatar)    // multipart field "photo"
+	router.GET("/users/:email/avatar", handlers.GetAvatar)
 	router.GET("/users/:email/created-tasks", handlers.GetUserCreatedTasks) // self tasks
 
-	// user post a task
-	// task routes with no conflicts
-	router.POST("/users/:email/post_task", handlers.PostATask)
+	// block list routes
+	router.POST("/users/:email/block/:blocked_email", handlers.BlockUser)
+	router.DELETE("/users/:email/block/:blocked_email", handlers.UnblockUser)
+	router.GET("/users/:email/blocked", handlers.GetBlockedUsers)
+
+	// user posts and edits a task
+	router.POST("/tasks", handlers.CreateTask)
+	router.GET("/tasks/:task_id", handlers.GetTask)
+	router.PATCH("/tasks/:task_id", handlers.UpdateTask) // partial update, version checked via If-Match
 
 	// Routes with authentication via viewer_email parameter
-	router.GET("/tasks/feed/:viewer_email", handlers.GetAllTasksForUser) // Get all available tasks
+	router.GET("/tasks/feed/:viewer_email", handlers.GetAllTasksForUser) // Get all available tasks; saved search filters or ?saved_search=<id>
 	router.GET("/appliedtasks/:viewer_email", handlers.GetAppliedTasks)  // get all the taks that user applied for
 	router.POST("/tasks/:task_id/apply/:email", handlers.ApplyForTask)   // Apply for a task
 	router.POST("/tasks/:task_id/accept/:email", handlers.AcceptTask)    // accept a task
+	router.POST("/tasks/:task_id/withdraw/:email", handlers.WithdrawApplication)
+	router.POST("/tasks/:task_id/reconfirm/:email", handlers.ReconfirmTask) // selected worker re-confirms after an edit
+	router.POST("/tasks/:task_id/cancel/:email", handlers.CancelTask)
+	router.POST("/tasks/:task_id/repost/:email", handlers.RepostTask)
+	router.GET("/tasks/:task_id/history/:email", handlers.GetTaskHistory) // audit log for poster and selected workers
 	router.GET("/scheduled-tasks/:email", handlers.GetScheduledTasks)
+	router.GET("/users/:email/schedule", handlers.GetSchedule)
+
+	// calendar routes
+	router.GET("/users/:email/calendar", handlers.GetCalendarSubscription)
+	router.POST("/users/:email/calendar/rotate", handlers.RotateCalendarSubscription)
+	router.GET("/calendar/:token/tasks.ics", handlers.GetCalendarFeed) // private iCal subscription feed
+	router.GET("/tasks/:task_id/ics/:email", handlers.DownloadTaskICS) // single task .ics download
 
 	// // poster accepts a task
 	// router.POST("/tasks/:task_id/accept/:email", )
@@ -38,4 +59,69 @@ func SetupRoutes(router *gin.Engine) {
 	router.POST("/tasks/:task_id/end/:email", handlers.EndTask)                  // Worker initiates task completion
 	router.POST("/validate-task-completion", handlers.ValidateTaskCompletionOTP) // Task owner validates completion
 
+	// time tracking routes
+	router.POST("/tasks/:task_id/check-in/:email", handlers.CheckIn)
+	router.POST("/tasks/:task_id/pause/:email", handlers.PauseWork)
+	router.POST("/tasks/:task_id/resume/:email", handlers.ResumeWork)
+	router.POST("/tasks/:task_id/check-out/:email", handlers.CheckOut)
+	router.GET("/tasks/:task_id/time/:email", handlers.GetTimesheet)         // Poster sees all workers, a worker their own
+	router.POST("/tasks/:task_id/time/:email/approve", handlers.ApproveTime) // Poster approves or adjusts a worker's time
+
+	// photo routes (multipart field "photo", optional "caption")
+	router.POST("/tasks/:task_id/photos/:email", handlers.UploadTaskPhoto)
+	router.POST("/tasks/:task_id/completion-photos/:email", handlers.UploadCompletionPhoto)
+	router.GET("/tasks/:task_id/photos/:email", handlers.GetTaskPhotos)
+	router.GET("/photos/:photo_id/:email", handlers.GetPhoto) // ?size=thumb for the thumbnail
+	router.DELETE("/photos/:photo_id/:email", handlers.DeletePhoto)
+
+	// directory routes
+	router.GET("/directory/:email", handlers.SearchDirectory)            // ?skill=&min_rating=&min_completed=&available_at=|task_id=
+	router.POST("/tasks/:task_id/invite/:email", handlers.InviteToApply) // Poster invites a directory worker, body {worker_email, message}
+	router.GET("/users/:email/invitations", handlers.GetInvitations)
+
+	// saved search routes
+	router.POST("/users/:email/saved-searches", handlers.CreateSavedSearch) // Body {name, category, min_pay, max_pay, from_date, to_date, latitude, longitude, radius_km, keyword, alert}
+	router.GET("/users/:email/saved-searches", handlers.GetSavedSearches)
+	router.DELETE("/users/:email/saved-searches/:search_id", handlers.DeleteSavedSearch)
+
+	// bookmark routes
+	router.POST("/users/:email/bookmarks/:task_id", handlers.AddBookmark)
+	router.DELETE("/users/:email/bookmarks/:task_id", handlers.RemoveBookmark)
+	router.GET("/users/:email/bookmarks", handlers.GetBookmarks)
+
+	// comment routes
+	router.GET("/tasks/:task_id/comments/:email", handlers.GetTaskComments)
+	router.POST("/tasks/:task_id/comments/:email", handlers.PostTaskComment)
+	router.POST("/comments/:comment_id/pin/:email", handlers.PinTaskComment) // Poster pins an answer into the description
+	router.DELETE("/comments/:comment_id/:email", handlers.DeleteTaskComment)
+	router.POST("/comments/:comment_id/report/:email", handlers.ReportComment)
+
+	// report routes
+	router.POST("/tasks/:task_id/report/:email", handlers.ReportTask)        // Report a task
+	router.POST("/users/:email/report/:reported_email", handlers.ReportUser) // Report another user
+
+	// message routes
+	router.POST("/tasks/:task_id/messages/:email", handlers.SendMessage)
+	router.GET("/tasks/:task_id/messages/:email", handlers.GetMessages) // Poster adds ?with=<participant>
+	router.POST("/tasks/:task_id/messages/:email/read", handlers.MarkMessagesRead)
+	router.GET("/tasks/:task_id/threads/:email", handlers.GetMessageThreads) // Poster's conversations on a task
+	router.GET("/ws/messages/:email", handlers.MessageSocket)                // Live delivery of new messages
+
+	// payment routes
+	router.GET("/users/:email/balance", handlers.GetBalance)
+	router.GET("/users/:email/transactions", handlers.GetTransactions)
+	router.POST("/users/:email/payout", handlers.RequestPayout)
+	router.GET("/users/:email/earnings", handlers.GetEarningsReport) // ?period=month|semester&format=json|csv|pdf
+
+	// dispute routes
+	router.POST("/tasks/:task_id/dispute/:email", handlers.OpenDispute)                  // Worker disputes an unconfirmed completion
+	router.POST("/disputes/:dispute_id/statements/:email", handlers.AddDisputeStatement) // Either side adds their account
+	router.GET("/disputes/:dispute_id/view/:email", handlers.GetDispute)
+
+	// moderation routes
+	router.GET("/moderation/reports/:email", handlers.GetModerationQueue)
+	router.POST("/moderation/reports/:report_id/resolve/:email", handlers.ResolveReport)
+	router.GET("/moderation/disputes/:email", handlers.GetDisputeQueue)
+	router.POST("/moderation/disputes/:dispute_id/resolve/:email", handlers.ResolveDispute)
+
 }
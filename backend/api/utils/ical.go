@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CalendarEvent is a single VEVENT in an iCalendar document
+type CalendarEvent struct {
+	UID            string
+	Summary        string
+	Description    string
+	Location       string
+	Start          time.Time
+	End            time.Time
+	Cancelled      bool
+	Sequence       int // Bumped whenever the event changes so calendar apps pick up edits
+	LastModified   time.Time
+	OrganizerName  string
+	OrganizerEmail string
+}
+
+// icalTimeFormat is the UTC date-time form used for DTSTART, DTEND and friends
+const icalTimeFormat = "20060102T150405Z"
+
+// BuildICalendar renders events as an iCalendar (RFC 5545) document
+func BuildICalendar(calendarName string, events []CalendarEvent) string {
+	var b strings.Builder
+
+	writeICalLine(&b, "BEGIN:VCALENDAR")
+	writeICalLine(&b, "VERSION:2.0")
+	writeICalLine(&b, "PRODID:-//UFPeerAssist//Tasks//EN")
+	writeICalLine(&b, "CALSCALE:GREGORIAN")
+	writeICalLine(&b, "METHOD:PUBLISH")
+	writeICalLine(&b, "X-WR-CALNAME:"+EscapeICalText(calendarName))
+
+	stamp := time.Now().UTC().Format(icalTimeFormat)
+	for _, event := range events {
+		writeICalLine(&b, "BEGIN:VEVENT")
+		writeICalLine(&b, "UID:"+event.UID)
+		writeICalLine(&b, "DTSTAMP:"+stamp)
+		writeICalLine(&b, "DTSTART:"+event.Start.UTC().Format(icalTimeFormat))
+		writeICalLine(&b, "DTEND:"+event.End.UTC().Format(icalTimeFormat))
+		writeICalLine(&b, "SUMMARY:"+EscapeICalText(event.Summary))
+		if event.Description != "" {
+			writeICalLine(&b, "DESCRIPTION:"+EscapeICalText(event.Description))
+		}
+		if event.Location != "" {
+			writeICalLine(&b, "LOCATION:"+EscapeICalText(event.Location))
+		}
+		if event.OrganizerEmail != "" {
+			organizer := "ORGANIZER"
+			if event.OrganizerName != "" {
+				organizer += ";CN=" + quoteICalParam(event.OrganizerName)
+			}
+			writeICalLine(&b, organizer+":mailto:"+event.OrganizerEmail)
+		}
+		if !event.LastModified.IsZero() {
+			writeICalLine(&b, "LAST-MODIFIED:"+event.LastModified.UTC().Format(icalTimeFormat))
+		}
+		writeICalLine(&b, fmt.Sprintf("SEQUENCE:%d", event.Sequence))
+		if event.Cancelled {
+			writeICalLine(&b, "STATUS:CANCELLED")
+		} else {
+			writeICalLine(&b, "STATUS:CONFIRMED")
+		}
+		writeICalLine(&b, "END:VEVENT")
+	}
+
+	writeICalLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+// EscapeICalText escapes backslashes, commas, semicolons and newlines in a TEXT value
+func EscapeICalText(value string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	)
+	return replacer.Replace(value)
+}
+
+// quoteICalParam wraps a parameter value in quotes, dropping characters it can't contain
+func quoteICalParam(value string) string {
+	return `"` + strings.NewReplacer(`"`, "", "\r", "", "\n", " ").Replace(value) + `"`
+}
+
+// writeICalLine writes a content line, folding it at 75 octets as RFC 5545 requires
+func writeICalLine(b *strings.Builder, line string) {
+	// The leading space of a continuation line counts towards its 75 octets
+	limit := 75
+
+	for len(line) > limit {
+		// Don't split a multi-byte UTF-8 character across lines
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// GenerateToken creates a random URL-safe token for private links
+func GenerateToken() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// unitWords start the part of an address that points at a specific room or apartment
+var unitWords = map[string]bool{
+	"apt": true, "apartment": true, "unit": true, "suite": true, "ste": true,
+	"room": true, "rm": true, "floor": true, "bldg": true, "#": true,
+}
+
+// LocationHidden is shown when nothing of an address is safe to reveal
+const LocationHidden = "Shared once you're selected"
+
+// ApproximateLocation reduces a place of work to the area it's in, dropping house numbers,
+// street addresses, unit and room numbers and zip codes. For example
+// "Apt 4B, 1500 SW 13th St, Gainesville, FL 32601" becomes "Gainesville, FL" and
+// "Library West Room 210" becomes "Library West".
+func ApproximateLocation(place string) string {
+	parts := []string{}
+	for _, part := range strings.Split(place, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	kept := []string{}
+	for _, part := range parts {
+		words := strings.Fields(part)
+
+		// With several parts, a part that starts with a number is the street address itself
+		if len(parts) > 1 && (startsWithDigit(words[0]) || isUnitWord(words[0])) {
+			continue
+		}
+
+		area := []string{}
+		for i, word := range words {
+			if isUnitWord(word) || strings.HasPrefix(word, "#") {
+				break
+			}
+			// Drop the house number at the front and zip codes anywhere
+			if (i == 0 && startsWithDigit(word)) || isZipCode(word) {
+				continue
+			}
+			area = append(area, word)
+		}
+		if len(area) > 0 {
+			kept = append(kept, strings.Join(area, " "))
+		}
+	}
+
+	if len(kept) == 0 {
+		return LocationHidden
+	}
+	return strings.Join(kept, ", ")
+}
+
+// isUnitWord reports whether a word introduces a unit or room number
+func isUnitWord(word string) bool {
+	return unitWords[strings.ToLower(strings.TrimSuffix(word, ":"))]
+}
+
+// startsWithDigit reports whether a word starts with a digit, like a house number
+func startsWithDigit(word string) bool {
+	return word != "" && unicode.IsDigit(rune(word[0]))
+}
+
+// isZipCode reports whether a word is a five or nine digit US zip code
+func isZipCode(word string) bool {
+	digits := strings.ReplaceAll(word, "-", "")
+	if len(digits) != 5 && len(digits) != 9 {
+		return false
+	}
+	for _, r := range digits {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
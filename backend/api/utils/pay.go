@@ -0,0 +1,11 @@
+package utils
+
+import "math"
+
+// AmountDue converts worked minutes at an hourly rate into an amount rounded to the cent
+func AmountDue(minutes int, hourlyRate float64) float64 {
+	if minutes <= 0 || hourlyRate <= 0 {
+		return 0
+	}
+	return math.Round(float64(minutes)/60*hourlyRate*100) / 100
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// pdfLinesPerPage fits 11pt lines between the top and bottom margins of a Letter page
+const pdfLinesPerPage = 52
+
+// BuildTextPDF renders a title and lines of plain text as a PDF document in Helvetica.
+// It is meant for simple reports and only supports Latin-1 text.
+func BuildTextPDF(title string, lines []string) []byte {
+	// Split the lines into pages, with the title on the first one
+	all := append([]string{title, ""}, lines...)
+	pages := [][]string{}
+	for len(all) > 0 {
+		n := pdfLinesPerPage
+		if n > len(all) {
+			n = len(all)
+		}
+		pages = append(pages, all[:n])
+		all = all[n:]
+	}
+
+	// Objects 1 and 2 are the catalog and page tree, 3 is the font, then a page and
+	// its content stream for each page
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // Page tree, filled in once the page object numbers are known
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+
+	kids := []string{}
+	for i, page := range pages {
+		pageObj := 4 + i*2
+		contentObj := pageObj + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObj))
+
+		var content strings.Builder
+		content.WriteString("BT\n/F1 11 Tf\n14 TL\n50 742 Td\n")
+		for j, line := range page {
+			if i == 0 && j == 0 {
+				content.WriteString("/F1 16 Tf\n")
+			}
+			fmt.Fprintf(&content, "(%s) Tj T*\n", escapePDFText(line))
+			if i == 0 && j == 0 {
+				content.WriteString("/F1 11 Tf\n")
+			}
+		}
+		content.WriteString("ET")
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", contentObj),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return b.Bytes()
+}
+
+// escapePDFText escapes a string for a PDF literal, replacing characters Helvetica can't show
+func escapePDFText(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteRune(' ')
+		case r > 255:
+			b.WriteRune('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
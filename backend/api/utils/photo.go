@@ -0,0 +1,258 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// Photo limits shared by every upload
+const (
+	MaxPhotoBytes        = 8 << 20 // 8 MB per upload
+	MaxPhotoPixels       = 40_000_000
+	ThumbnailMaxSide     = 320
+	photoJPEGQuality     = 85
+	thumbnailJPEGQuality = 80
+)
+
+// Photo processing errors
+var (
+	ErrPhotoTooLarge        = errors.New("photo is larger than the upload limit")
+	ErrUnsupportedPhotoType = errors.New("only JPEG, PNG and GIF photos are supported")
+	ErrPhotoDimensions      = errors.New("photo dimensions are too large")
+)
+
+// ProcessedPhoto is an upload that has been checked, re-encoded without metadata and thumbnailed
+type ProcessedPhoto struct {
+	ContentType      string
+	Data             []byte
+	Width            int
+	Height           int
+	Thumbnail        []byte
+	ThumbnailType    string
+	OriginalMimeType string
+}
+
+// ProcessPhoto sniffs an upload's real content type, rejects anything that isn't a supported
+// image within the size limits, and re-encodes it. Re-encoding drops EXIF and any other
+// metadata (GPS position included); a JPEG's EXIF orientation is applied first so the photo
+// still displays the right way up. GIFs keep their first frame only and become PNGs.
+func ProcessPhoto(data []byte) (*ProcessedPhoto, error) {
+	if len(data) > MaxPhotoBytes {
+		return nil, ErrPhotoTooLarge
+	}
+
+	// Trust the bytes, not the client's Content-Type header
+	sniffed := http.DetectContentType(data)
+	switch sniffed {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return nil, ErrUnsupportedPhotoType
+	}
+
+	// Check dimensions before decoding so a tiny file can't expand into a huge bitmap
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedPhotoType
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPhotoPixels {
+		return nil, ErrPhotoDimensions
+	}
+
+	var decoded image.Image
+	switch sniffed {
+	case "image/jpeg":
+		decoded, err = jpeg.Decode(bytes.NewReader(data))
+	case "image/png":
+		decoded, err = png.Decode(bytes.NewReader(data))
+	case "image/gif":
+		decoded, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, ErrUnsupportedPhotoType
+	}
+
+	img := toRGBA(decoded)
+	if sniffed == "image/jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	photo := &ProcessedPhoto{
+		Width:            img.Bounds().Dx(),
+		Height:           img.Bounds().Dy(),
+		OriginalMimeType: sniffed,
+	}
+
+	thumb := Thumbnail(img, ThumbnailMaxSide)
+
+	var full, small bytes.Buffer
+	if sniffed == "image/jpeg" {
+		photo.ContentType, photo.ThumbnailType = "image/jpeg", "image/jpeg"
+		err = jpeg.Encode(&full, img, &jpeg.Options{Quality: photoJPEGQuality})
+		if err == nil {
+			err = jpeg.Encode(&small, thumb, &jpeg.Options{Quality: thumbnailJPEGQuality})
+		}
+	} else {
+		photo.ContentType, photo.ThumbnailType = "image/png", "image/png"
+		err = png.Encode(&full, img)
+		if err == nil {
+			err = png.Encode(&small, thumb)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	photo.Data = full.Bytes()
+	photo.Thumbnail = small.Bytes()
+	return photo, nil
+}
+
+// Thumbnail scales an image down to fit within maxSide on its longer edge, averaging the
+// source pixels behind each thumbnail pixel. Images that already fit are returned as they are.
+func Thumbnail(src *image.RGBA, maxSide int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw <= maxSide && sh <= maxSide {
+		return src
+	}
+
+	dw, dh := maxSide, sh*maxSide/sw
+	if sh > sw {
+		dw, dh = sw*maxSide/sh, maxSide
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*sh/dh, max((y+1)*sh/dh, y*sh/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*sw/dw, max((x+1)*sw/dw, x*sw/dw+1)
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				offset := src.PixOffset(src.Bounds().Min.X+x0, src.Bounds().Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(src.Pix[offset])
+					g += int(src.Pix[offset+1])
+					b += int(src.Pix[offset+2])
+					a += int(src.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// toRGBA copies any decoded image into an RGBA bitmap starting at the origin
+func toRGBA(src image.Image) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+	return dst
+}
+
+// applyOrientation turns an image the way its EXIF orientation tag (1-8) says it should be shown
+func applyOrientation(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w // Orientations 5-8 swap width and height
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Mirrored horizontally
+				dx, dy = w-1-x, y
+			case 3: // Rotated 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // Mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // Transposed
+				dx, dy = y, x
+			case 6: // Rotated 90 clockwise
+				dx, dy = h-1-y, x
+			case 7: // Transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // Rotated 90 counter-clockwise
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], src.Pix[src.PixOffset(x, y):src.PixOffset(x, y)+4])
+		}
+	}
+	return dst
+}
+
+// jpegOrientation reads the EXIF orientation tag from a JPEG, returning 1 (as shot) when there
+// isn't one or it can't be read
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xFF {
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if marker == 0xDA || length < 2 || pos+2+length > len(data) {
+			break // Start of scan: no more metadata
+		}
+
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && len(segment) > 14 && string(segment[:6]) == "Exif\x00\x00" {
+			return exifOrientation(segment[6:])
+		}
+		pos += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation finds tag 0x0112 in the first IFD of a TIFF-structured EXIF block
+func exifOrientation(tiff []byte) int {
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+	return 1
+}
@@ -0,0 +1,21 @@
+package utils
+
+import "strings"
+
+// PinnedAnswersHeading introduces the Q&A section at the end of a task description
+const PinnedAnswersHeading = "Questions & answers:"
+
+// AppendPinnedAnswer adds a question and its answer to a task description, under a shared
+// heading so several pinned answers read as one list. The question may be empty.
+func AppendPinnedAnswer(description, question, answer string) string {
+	entry := "A: " + answer
+	if question != "" {
+		entry = "Q: " + question + "\n" + entry
+	}
+
+	description = strings.TrimRight(description, "\n ")
+	if !strings.Contains(description, PinnedAnswersHeading) {
+		description += "\n\n" + PinnedAnswersHeading
+	}
+	return description + "\n\n" + entry
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// Report periods for earnings and spending summaries
+const (
+	ReportPeriodMonth    = "month"
+	ReportPeriodSemester = "semester"
+)
+
+// ReportPeriodLabel names the month ("2025-04") or academic semester ("Spring 2025") a time falls in.
+// Semesters follow the UF calendar: Spring is January to April, Summer May to July, Fall August to December.
+func ReportPeriodLabel(t time.Time, period string) string {
+	if period != ReportPeriodSemester {
+		return t.Format("2006-01")
+	}
+
+	switch {
+	case t.Month() <= time.April:
+		return fmt.Sprintf("Spring %d", t.Year())
+	case t.Month() <= time.July:
+		return fmt.Sprintf("Summer %d", t.Year())
+	default:
+		return fmt.Sprintf("Fall %d", t.Year())
+	}
+}
+
+// ReportPeriodStart is the first day of the month or semester a time falls in, for ordering periods
+func ReportPeriodStart(t time.Time, period string) time.Time {
+	month := t.Month()
+	if period == ReportPeriodSemester {
+		switch {
+		case month <= time.April:
+			month = time.January
+		case month <= time.July:
+			month = time.May
+		default:
+			month = time.August
+		}
+	}
+	return time.Date(t.Year(), month, 1, 0, 0, 0, 0, t.Location())
+}
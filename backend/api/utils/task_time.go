@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	// Embed the time zone database so task time zones resolve on hosts without one
+	_ "time/tzdata"
+)
+
+// DefaultTaskTimeZone is used for tasks posted without a time zone
+const DefaultTaskTimeZone = "America/New_York"
+
+// DefaultTaskDurationMinutes is used for tasks posted without a duration or end time
+const DefaultTaskDurationMinutes = 60
+
+// timeOfDayPattern matches "9", "9:30", "09:30:00", "9.30", each with an optional am/pm suffix
+var timeOfDayPattern = regexp.MustCompile(`^(\d{1,2})(?:[:.](\d{2}))?(?::(\d{2}))?\s*(am|pm|a\.m\.|p\.m\.)?$`)
+
+// LoadTaskLocation resolves an IANA time zone name, falling back to the default zone when empty
+func LoadTaskLocation(name string) (*time.Location, error) {
+	if name == "" {
+		name = DefaultTaskTimeZone
+	}
+	return time.LoadLocation(name)
+}
+
+// ParseTaskStart parses a start datetime. Values with an offset (RFC 3339) are taken as
+// is; values without one, like "2025-04-20T15:30", are read in the task's time zone.
+func ParseTaskStart(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("invalid datetime format. Use YYYY-MM-DDTHH:MM or RFC 3339")
+}
+
+// ParseTimeOfDay reads a free-form time such as "14:00", "2 PM" or "9:30am" into
+// hours and minutes. It also accepts the start of a range like "10:00 - 12:00".
+func ParseTimeOfDay(value string) (int, int, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	// Only the start of a range matters
+	for _, sep := range []string{" - ", "-", " to ", "–"} {
+		if idx := strings.Index(value, sep); idx > 0 {
+			value = strings.TrimSpace(value[:idx])
+			break
+		}
+	}
+
+	switch value {
+	case "noon":
+		return 12, 0, true
+	case "midnight":
+		return 0, 0, true
+	}
+
+	match := timeOfDayPattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, 0, false
+	}
+
+	hour, _ := strconv.Atoi(match[1])
+	minute := 0
+	if match[2] != "" {
+		minute, _ = strconv.Atoi(match[2])
+	}
+
+	switch strings.ReplaceAll(match[4], ".", "") {
+	case "am":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		if hour != 12 {
+			hour += 12
+		}
+	}
+
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
+
+// CombineTaskDateTime places a time of day on a calendar date in the task's time zone.
+// The date's year, month and day are used as-is, whatever zone it was parsed in.
+func CombineTaskDateTime(date time.Time, timeOfDay string, loc *time.Location) (time.Time, bool) {
+	hour, minute, ok := ParseTimeOfDay(timeOfDay)
+	if !ok {
+		return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc), false
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, loc), true
+}
+
+// TaskDateOf returns the start's local calendar date as midnight UTC, matching how
+// task_date has always been stored
+func TaskDateOf(start time.Time, loc *time.Location) time.Time {
+	local := start.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/go-gomail/gomail"
 	"golang.org/x/crypto/bcrypt"
@@ -96,3 +97,198 @@ func SendTaskCompletionOTP(email, otp, taskTitle string) error {
 	}
 	return err
 }
+
+// SendTaskCancelledNotification lets a selected worker know the poster cancelled the task
+func SendTaskCancelledNotification(email string, taskTitle string) error {
+	mailer := gomail.NewMessage()
+	mailer.SetHeader("From", "jamusvenkatesh@gmail.com")
+	mailer.SetHeader("To", email)
+	mailer.SetHeader("Subject", "A task you were scheduled for was cancelled")
+	mailer.SetBody("text/plain", fmt.Sprintf(
+		"The poster has cancelled the task: %s. It has been removed from your scheduled tasks.", taskTitle,
+	))
+
+	dialer := gomail.NewDialer("smtp.sendgrid.net", 587, "apikey", "")
+
+	err := dialer.DialAndSend(mailer)
+	if err != nil {
+		log.Printf("Failed to send cancellation notification to %s: %v\n", email, err)
+	}
+	return err
+}
+
+// SendTaskExpiredNotification tells a poster their task passed its date without anyone selected
+func SendTaskExpiredNotification(email string, taskTitle string, taskID string) error {
+	mailer := gomail.NewMessage()
+	mailer.SetHeader("From", "jamusvenkatesh@gmail.com")
+	mailer.SetHeader("To", email)
+	mailer.SetHeader("Subject", "Your task has expired")
+	mailer.SetBody("text/plain", fmt.Sprintf(
+		"Your task \"%s\" reached its date without anyone being selected, so it has been marked as expired "+
+			"and removed from the task feed.\n\nIf you still need help, you can repost it with a new date from "+
+			"your posted tasks (task ID %s).", taskTitle, taskID,
+	))
+
+	dialer := gomail.NewDialer("smtp.sendgrid.net", 587, "apikey", "")
+
+	err := dialer.DialAndSend(mailer)
+	if err != nil {
+		log.Printf("Failed to send expiry notification to %s: %v\n", email, err)
+	}
+	return err
+}
+
+// SendDisputeNotification tells one side of a dispute about a change to it
+func SendDisputeNotification(email string, taskTitle string, message string) error {
+	mailer := gomail.NewMessage()
+	mailer.SetHeader("From", "jamusvenkatesh@gmail.com")
+	mailer.SetHeader("To", email)
+	mailer.SetHeader("Subject", "Update on a dispute for "+taskTitle)
+	mailer.SetBody("text/plain", fmt.Sprintf("Task: %s\n\n%s", taskTitle, message))
+
+	dialer := gomail.NewDialer("smtp.sendgrid.net", 587, "apikey", "")
+
+	err := dialer.DialAndSend(mailer)
+	if err != nil {
+		log.Printf("Failed to send dispute notification to %s: %v\n", email, err)
+	}
+	return err
+}
+
+// SendTaskChangeNotification tells an applicant what changed on a task they applied for
+func SendTaskChangeNotification(email string, taskTitle string, changes []string, needsReconfirm bool) error {
+	mailer := gomail.NewMessage()
+	mailer.SetHeader("From", "jamusvenkatesh@gmail.com")
+	mailer.SetHeader("To", email)
+	mailer.SetHeader("Subject", "A task you applied for has changed")
+
+	body := fmt.Sprintf("The poster has updated the task: %s.\n\nWhat changed:\n%s\n", taskTitle, strings.Join(changes, "\n"))
+	if needsReconfirm {
+		body += "\nSince you were selected for this task, please re-confirm that you can still do it or drop out from your scheduled tasks."
+	}
+
+	mailer.SetBody("text/plain", body)
+
+	dialer := gomail.NewDialer("smtp.sendgrid.net", 587, "apikey", "")
+
+	err := dialer.DialAndSend(mailer)
+	if err != nil {
+		log.Printf("Failed to send task change notification to %s: %v\n", email, err)
+	}
+	return err
+}
+
+// SendTaskReminder reminds the poster or a worker that a task is coming up
+func SendTaskReminder(email, taskTitle, startsAt, place, window string) error {
+	mailer := gomail.NewMessage()
+	mailer.SetHeader("From", "jamusvenkatesh@gmail.com")
+	mailer.SetHeader("To", email)
+
+	subject := "Reminder: your task starts tomorrow"
+	if window == "1h" {
+		subject = "Reminder: your task starts in an hour"
+	}
+	mailer.SetHeader("Subject", subject)
+	mailer.SetBody("text/plain", fmt.Sprintf(
+		"This is a reminder for the task: %s.\n\nStarts: %s\nPlace: %s\n\nPlease view scheduled tasks in your dashboard for more information.",
+		taskTitle, startsAt, place,
+	))
+
+	dialer := gomail.NewDialer("smtp.sendgrid.net", 587, "apikey", "")
+
+	err := dialer.DialAndSend(mailer)
+	if err != nil {
+		log.Printf("Failed to send task reminder to %s: %v\n", email, err)
+	}
+	return err
+}
+
+// SendCompletionNudge asks the poster to confirm completion of a task whose time has passed
+func SendCompletionNudge(email, taskTitle, startedAt string) error {
+	mailer := gomail.NewMessage()
+	mailer.SetHeader("From", "jamusvenkatesh@gmail.com")
+	mailer.SetHeader("To", email)
+	mailer.SetHeader("Subject", "Did your task get done?")
+	mailer.SetBody("text/plain", fmt.Sprintf(
+		"Your task: %s was scheduled for %s but hasn't been marked complete yet.\n\n"+
+			"Once the work is done, ask your worker to end the task and enter the OTP you receive, or cancel the task if it didn't happen.",
+		taskTitle, startedAt,
+	))
+
+	dialer := gomail.NewDialer("smtp.sendgrid.net", 587, "apikey", "")
+
+	err := dialer.DialAndSend(mailer)
+	if err != nil {
+		log.Printf("Failed to send completion nudge to %s: %v\n", email, err)
+	}
+	return err
+}
+
+// SendTaskInvitation tells a worker from the directory that a poster would like them to apply
+func SendTaskInvitation(email, posterName, taskTitle, message, taskID string) error {
+	mailer := gomail.NewMessage()
+	mailer.SetHeader("From", "jamusvenkatesh@gmail.com")
+	mailer.SetHeader("To", email)
+	mailer.SetHeader("Subject", "You're invited to apply: "+taskTitle)
+
+	body := fmt.Sprintf("%s found you in the worker directory and invited you to apply for their task: %s (task ID %s).", posterName, taskTitle, taskID)
+	if message != "" {
+		body += fmt.Sprintf("\n\nTheir message:\n%s", message)
+	}
+	body += "\n\nOpen the task in UFPeerAssist to apply. You can leave the directory at any time from your profile settings."
+	mailer.SetBody("text/plain", body)
+
+	dialer := gomail.NewDialer("smtp.sendgrid.net", 587, "apikey", "")
+
+	err := dialer.DialAndSend(mailer)
+	if err != nil {
+		log.Printf("Failed to send task invitation to %s: %v\n", email, err)
+	}
+	return err
+}
+
+// SendSavedSearchAlert lists new tasks matching one of the user's saved searches, either as
+// they're posted or as a daily digest
+func SendSavedSearchAlert(email, searchName string, taskLines []string, digest bool) error {
+	mailer := gomail.NewMessage()
+	mailer.SetHeader("From", "jamusvenkatesh@gmail.com")
+	mailer.SetHeader("To", email)
+
+	subject := "New task matching " + searchName
+	if digest {
+		subject = fmt.Sprintf("%d new tasks matching %s today", len(taskLines), searchName)
+	}
+	mailer.SetHeader("Subject", subject)
+	mailer.SetBody("text/plain", fmt.Sprintf(
+		"New tasks match your saved search \"%s\":\n\n%s\n\nOpen UFPeerAssist to apply, or change the alert settings of your saved search.",
+		searchName, strings.Join(taskLines, "\n"),
+	))
+
+	dialer := gomail.NewDialer("smtp.sendgrid.net", 587, "apikey", "")
+
+	err := dialer.DialAndSend(mailer)
+	if err != nil {
+		log.Printf("Failed to send saved search alert to %s: %v\n", email, err)
+	}
+	return err
+}
+
+// SendBookmarkNotification tells a user about a change to a task they bookmarked
+func SendBookmarkNotification(email, taskTitle, taskID, message string) error {
+	mailer := gomail.NewMessage()
+	mailer.SetHeader("From", "jamusvenkatesh@gmail.com")
+	mailer.SetHeader("To", email)
+	mailer.SetHeader("Subject", "Update on a task you bookmarked: "+taskTitle)
+	mailer.SetBody("text/plain", fmt.Sprintf(
+		"%s\n\nTask: %s (task ID %s)\n\nOpen your bookmarks in UFPeerAssist to apply or remove the bookmark.",
+		message, taskTitle, taskID,
+	))
+
+	dialer := gomail.NewDialer("smtp.sendgrid.net", 587, "apikey", "")
+
+	err := dialer.DialAndSend(mailer)
+	if err != nil {
+		log.Printf("Failed to send bookmark notification to %s: %v\n", email, err)
+	}
+	return err
+}
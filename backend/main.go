@@ -3,6 +3,7 @@ package main
 import (
 	"time"
 	"ufpeerassist/backend/api/handlers"
+	"ufpeerassist/backend/api/middleware"
 	"ufpeerassist/backend/api/routes"
 
 	"github.com/gin-contrib/cors"
@@ -12,16 +13,25 @@ import (
 func main() {
 
 	handlers.InitMongoDB()
-	handlers.InitTasksCollection()
-	handlers.InitScheduledTasksCollection()
+	handlers.InitCollections()
+
+	// Backfill structured start times for tasks posted before they existed
+	handlers.MigrateTaskTimes()
+
+	// Record which assignments were ended before assignments tracked it
+	handlers.MigrateAssignmentEnds()
 
 	router := gin.Default()
 
+	// Tag every request with an ID for the audit log
+	router.Use(middleware.RequestID())
+
 	//Enable CORS
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000"}, // where reactfrontend is running
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowOrigins:     handlers.AllowedOrigins, // shared with the WebSocket origin check
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match", middleware.RequestIDHeader},
+		ExposeHeaders:    []string{"ETag", middleware.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           6 * time.Hour, // cache response for 6 hours
 	}))
@@ -29,6 +39,12 @@ func main() {
 	// setup the other routes
 	routes.SetupRoutes(router)
 
+	// Send upcoming task reminders and expire stale open tasks in the background
+	handlers.StartReminderScheduler(5 * time.Minute)
+	handlers.StartTaskExpiryJob(15 * time.Minute)
+	handlers.StartSavedSearchDigestJob(time.Hour)
+	handlers.StartBookmarkAlertJob(30 * time.Minute)
+
 	// Run server on port 8080
 	router.Run(":8080")
 }
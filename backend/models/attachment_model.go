@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AttachmentKind says what a photo is attached to
+type AttachmentKind string
+
+// Define attachment kinds
+const (
+	AttachmentTask       AttachmentKind = "task"       // Added by the poster to show what needs doing
+	AttachmentCompletion AttachmentKind = "completion" // Added by a worker as proof of finished work
+)
+
+// Attachment is a photo stored in the blob store, with its thumbnail
+type Attachment struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	TaskID        primitive.ObjectID `bson:"task_id" json:"task_id"`
+	Kind          AttachmentKind     `bson:"kind" json:"kind"`
+	UploaderEmail string             `bson:"uploader_email" json:"uploader_email"`
+	ContentType   string             `bson:"content_type" json:"content_type"`
+	Size          int                `bson:"size" json:"size"`
+	Width         int                `bson:"width" json:"width"`
+	Height        int                `bson:"height" json:"height"`
+	BlobKey       string             `bson:"blob_key" json:"-"`
+	ThumbnailKey  string             `bson:"thumbnail_key" json:"-"`
+	ThumbnailType string             `bson:"thumbnail_type" json:"-"`
+	Caption       string             `bson:"caption,omitempty" json:"caption,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Bookmark is a task a user shortlisted without applying
+type Bookmark struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Email     string             `bson:"email" json:"email"`
+	TaskID    primitive.ObjectID `bson:"task_id" json:"task_id"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+
+	// One-off alerts already sent, so they aren't repeated
+	NearlyFullNotified bool `bson:"nearly_full_notified,omitempty" json:"-"`
+	ExpiringNotified   bool `bson:"expiring_notified,omitempty" json:"-"`
+}
+
+// BookmarkAlert identifies why a bookmarker was notified about a task
+type BookmarkAlert string
+
+// Define bookmark alerts
+const (
+	BookmarkEdited     BookmarkAlert = "edited"
+	BookmarkNearlyFull BookmarkAlert = "nearly_full"
+	BookmarkExpiring   BookmarkAlert = "expiring" // Still open shortly before it starts
+)
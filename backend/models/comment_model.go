@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaskComment is a public question or answer on an open task
+type TaskComment struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	TaskID      primitive.ObjectID  `bson:"task_id" json:"task_id"`
+	AuthorEmail string              `bson:"author_email" json:"author_email"`
+	AuthorName  string              `bson:"author_name" json:"author_name"`
+	Body        string              `bson:"body" json:"body"`
+	ReplyTo     *primitive.ObjectID `bson:"reply_to,omitempty" json:"reply_to,omitempty"` // The question this comment answers
+	FromPoster  bool                `bson:"from_poster" json:"from_poster"`
+	Pinned      bool                `bson:"pinned" json:"pinned"` // Copied into the task description by the poster
+	CreatedAt   time.Time           `bson:"created_at" json:"created_at"`
+
+	// Moderation fields
+	Hidden    bool       `bson:"hidden" json:"-"` // Hidden pending moderation review, or for good once actioned
+	RemovedBy string     `bson:"removed_by,omitempty" json:"-"`
+	RemovedAt *time.Time `bson:"removed_at,omitempty" json:"-"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DisputeStatus represents where a dispute is in the moderation queue
+type DisputeStatus string
+
+// Define dispute statuses
+const (
+	DisputeOpen     DisputeStatus = "Open"
+	DisputeResolved DisputeStatus = "Resolved"
+)
+
+// DisputeOutcome is a moderator's ruling on a disputed assignment
+type DisputeOutcome string
+
+// Define dispute outcomes
+const (
+	DisputeComplete   DisputeOutcome = "complete"   // The worker did the job; the assignment is completed
+	DisputeIncomplete DisputeOutcome = "incomplete" // The job wasn't done; the worker is released
+	DisputePartial    DisputeOutcome = "partial"    // Some of the job was done; the worker is paid the share the moderator sets
+)
+
+// DisputeStatement is one side's account of what happened
+type DisputeStatement struct {
+	AuthorEmail string    `bson:"author_email" json:"author_email"`
+	Text        string    `bson:"text" json:"text"`
+	Attachments []string  `bson:"attachments,omitempty" json:"attachments,omitempty"` // Links to photos or documents
+	SubmittedAt time.Time `bson:"submitted_at" json:"submitted_at"`
+}
+
+// Dispute is raised by a worker whose poster won't confirm completion of their assignment
+type Dispute struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	TaskID         primitive.ObjectID `bson:"task_id" json:"task_id"`
+	TaskTitle      string             `bson:"task_title" json:"task_title"`
+	WorkerEmail    string             `bson:"worker_email" json:"worker_email"`
+	PosterEmail    string             `bson:"poster_email" json:"poster_email"`
+	Status         DisputeStatus      `bson:"status" json:"status"`
+	PreviousStatus TaskStatus         `bson:"previous_status" json:"-"` // Task status to restore if the task isn't settled by the ruling
+	Statements     []DisputeStatement `bson:"statements" json:"statements"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+
+	// Moderation fields
+	Outcome        DisputeOutcome `bson:"outcome,omitempty" json:"outcome,omitempty"`
+	ResolvedBy     string         `bson:"resolved_by,omitempty" json:"resolved_by,omitempty"`
+	ResolvedAt     *time.Time     `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+	ResolutionNote string         `bson:"resolution_note,omitempty" json:"resolution_note,omitempty"`
+	PaidCents      *int64         `bson:"paid_cents,omitempty" json:"paid_cents,omitempty"` // Escrow released to the worker on a partial ruling
+}
+
+// ValidDisputeOutcomes lists the rulings a moderator can make
+func ValidDisputeOutcomes() []DisputeOutcome {
+	return []DisputeOutcome{DisputeComplete, DisputeIncomplete, DisputePartial}
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaskInvitation records a poster inviting a worker from the directory to apply for a task
+type TaskInvitation struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	TaskID      primitive.ObjectID `bson:"task_id" json:"task_id"`
+	TaskTitle   string             `bson:"task_title" json:"task_title"`
+	PosterEmail string             `bson:"poster_email" json:"poster_email"`
+	WorkerEmail string             `bson:"worker_email" json:"worker_email"`
+	Message     string             `bson:"message,omitempty" json:"message,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+}
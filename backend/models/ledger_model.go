@@ -0,0 +1,74 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LedgerTransactionKind says what a ledger transaction was for
+type LedgerTransactionKind string
+
+// Define ledger transaction kinds
+const (
+	LedgerEscrowHold    LedgerTransactionKind = "escrow_hold"    // Poster funds a worker's pay when selecting them
+	LedgerEscrowRelease LedgerTransactionKind = "escrow_release" // Held pay moves to the worker once the work is confirmed
+	LedgerEscrowRefund  LedgerTransactionKind = "escrow_refund"  // Held pay goes back to the poster
+	LedgerPayout        LedgerTransactionKind = "payout"         // Worker withdraws their balance
+	LedgerPayoutReturn  LedgerTransactionKind = "payout_return"  // A payout the provider rejected goes back to the balance
+)
+
+// ExternalAccount stands for money outside the platform: posters' cards and workers' banks
+const ExternalAccount = "external"
+
+// WalletAccount is the ledger account holding a user's withdrawable balance
+func WalletAccount(email string) string {
+	return "wallet:" + email
+}
+
+// EscrowAccount is the ledger account holding a poster's funds for one worker's assignment
+func EscrowAccount(taskID primitive.ObjectID, workerEmail string) string {
+	return fmt.Sprintf("escrow:%s:%s", taskID.Hex(), workerEmail)
+}
+
+// LedgerPosting moves an amount into (positive) or out of (negative) one account
+type LedgerPosting struct {
+	Account     string `bson:"account" json:"account"`
+	AmountCents int64  `bson:"amount_cents" json:"amount_cents"`
+}
+
+// LedgerTransaction is a balanced set of postings: its amounts always sum to zero
+type LedgerTransaction struct {
+	ID             primitive.ObjectID    `bson:"_id,omitempty" json:"id,omitempty"`
+	Kind           LedgerTransactionKind `bson:"kind" json:"kind"`
+	IdempotencyKey string                `bson:"idempotency_key" json:"-"` // Stops the same hold, release or refund being posted twice
+	TaskID         *primitive.ObjectID   `bson:"task_id,omitempty" json:"task_id,omitempty"`
+	PosterEmail    string                `bson:"poster_email,omitempty" json:"poster_email,omitempty"`
+	WorkerEmail    string                `bson:"worker_email,omitempty" json:"worker_email,omitempty"`
+	Postings       []LedgerPosting       `bson:"postings" json:"postings"`
+	Provider       string                `bson:"provider,omitempty" json:"provider,omitempty"`
+	ProviderRefs   []string              `bson:"provider_refs,omitempty" json:"provider_refs,omitempty"`
+	Description    string                `bson:"description" json:"description"`
+	CreatedAt      time.Time             `bson:"created_at" json:"created_at"`
+}
+
+// IsBalanced reports whether the postings sum to zero
+func (t LedgerTransaction) IsBalanced() bool {
+	var sum int64
+	for _, posting := range t.Postings {
+		sum += posting.AmountCents
+	}
+	return sum == 0
+}
+
+// AmountFor is the net amount the transaction moved into or out of an account
+func (t LedgerTransaction) AmountFor(account string) int64 {
+	var sum int64
+	for _, posting := range t.Postings {
+		if posting.Account == account {
+			sum += posting.AmountCents
+		}
+	}
+	return sum
+}
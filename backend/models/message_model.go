@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Message is one message in a task's conversation between the poster and one applicant or worker
+type Message struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	TaskID           primitive.ObjectID `bson:"task_id" json:"task_id"`
+	ParticipantEmail string             `bson:"participant_email" json:"participant_email"` // The applicant or worker side of the thread
+	SenderEmail      string             `bson:"sender_email" json:"sender_email"`
+	RecipientEmail   string             `bson:"recipient_email" json:"recipient_email"`
+	Body             string             `bson:"body" json:"body"`
+	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
+	ReadAt           *time.Time         `bson:"read_at,omitempty" json:"read_at,omitempty"`
+}
@@ -0,0 +1,213 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AcademicYear is where a student is in their studies
+type AcademicYear string
+
+// Define academic years
+const (
+	Freshman  AcademicYear = "Freshman"
+	Sophomore AcademicYear = "Sophomore"
+	Junior    AcademicYear = "Junior"
+	Senior    AcademicYear = "Senior"
+	Graduate  AcademicYear = "Graduate"
+)
+
+// ValidAcademicYears lists the years a profile can show
+func ValidAcademicYears() []AcademicYear {
+	return []AcademicYear{Freshman, Sophomore, Junior, Senior, Graduate}
+}
+
+// Profile limits
+const (
+	MaxBioLength          = 500
+	MaxMajorLength        = 100
+	MaxSkills             = 10
+	MaxAvailabilityWindow = 21 // Three windows a day
+)
+
+// AvailabilityWindow is a weekly stretch of time a student is usually free, in the task time zone.
+// Start and End are "HH:MM" on the 24-hour clock.
+type AvailabilityWindow struct {
+	Day   string `bson:"day" json:"day"` // Monday, Tuesday, ...
+	Start string `bson:"start" json:"start"`
+	End   string `bson:"end" json:"end"`
+}
+
+// Weekday parses the window's day name
+func (w AvailabilityWindow) Weekday() (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(w.Day, day.String()) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// Minutes returns the window's start and end as minutes after midnight
+func (w AvailabilityWindow) Minutes() (int, int, bool) {
+	start, ok := clockMinutes(w.Start)
+	if !ok {
+		return 0, 0, false
+	}
+	end, ok := clockMinutes(w.End)
+	if !ok {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// Covers reports whether a moment, already in the task time zone, falls inside the window
+func (w AvailabilityWindow) Covers(t time.Time) bool {
+	day, ok := w.Weekday()
+	if !ok || t.Weekday() != day {
+		return false
+	}
+	start, end, ok := w.Minutes()
+	minute := t.Hour()*60 + t.Minute()
+	return ok && minute >= start && minute < end
+}
+
+// ValidateAvailability checks every window is well formed and that windows on the same day
+// don't overlap. Day names are normalized to their canonical spelling.
+func ValidateAvailability(windows []AvailabilityWindow) error {
+	if len(windows) > MaxAvailabilityWindow {
+		return fmt.Errorf("at most %d availability windows are allowed", MaxAvailabilityWindow)
+	}
+
+	type span struct{ start, end int }
+	byDay := map[time.Weekday][]span{}
+
+	for i := range windows {
+		day, ok := windows[i].Weekday()
+		if !ok {
+			return fmt.Errorf("availability window %d has an invalid day %q", i+1, windows[i].Day)
+		}
+		windows[i].Day = day.String()
+
+		start, end, ok := windows[i].Minutes()
+		if !ok {
+			return fmt.Errorf("availability window %d must use HH:MM times", i+1)
+		}
+		if end <= start {
+			return fmt.Errorf("availability window %d must end after it starts", i+1)
+		}
+		byDay[day] = append(byDay[day], span{start, end})
+	}
+
+	for day, spans := range byDay {
+		sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+		for i := 1; i < len(spans); i++ {
+			if spans[i].start < spans[i-1].end {
+				return fmt.Errorf("availability windows on %s overlap", day)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateSkills checks skills against the task categories and removes duplicates
+func ValidateSkills(skills []TaskCategory) ([]TaskCategory, error) {
+	if len(skills) > MaxSkills {
+		return nil, fmt.Errorf("at most %d skills are allowed", MaxSkills)
+	}
+
+	seen := map[TaskCategory]bool{}
+	cleaned := []TaskCategory{}
+	for _, skill := range skills {
+		valid := false
+		for _, category := range ValidTaskCategories() {
+			if skill == category {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, errors.New("invalid skill " + string(skill))
+		}
+		if !seen[skill] {
+			seen[skill] = true
+			cleaned = append(cleaned, skill)
+		}
+	}
+	return cleaned, nil
+}
+
+// PublicProfile is the part of a user's profile anyone can see; it leaves out contact details
+// and account settings
+type PublicProfile struct {
+	Email          string               `json:"email"`
+	Name           string               `json:"name"`
+	Bio            string               `json:"bio"`
+	Major          string               `json:"major"`
+	Year           AcademicYear         `json:"year"`
+	Skills         []TaskCategory       `json:"skills"`
+	Availability   []AvailabilityWindow `json:"availability"`
+	Rating         string               `json:"rating"`
+	CompletedTasks int                  `json:"completed_tasks"`
+	HasAvatar      bool                 `json:"has_avatar"`
+}
+
+// PublicProfile returns the publicly visible part of a user's profile
+func (u Users) PublicProfile() PublicProfile {
+	profile := PublicProfile{
+		Email:          u.Email,
+		Name:           u.Name,
+		Bio:            u.Bio,
+		Major:          u.Major,
+		Year:           u.Year,
+		Skills:         u.Skills,
+		Availability:   u.Availability,
+		Rating:         u.Rating,
+		CompletedTasks: u.CompletedTasks,
+		HasAvatar:      u.AvatarKey != "",
+	}
+	if profile.Skills == nil {
+		profile.Skills = []TaskCategory{}
+	}
+	if profile.Availability == nil {
+		profile.Availability = []AvailabilityWindow{}
+	}
+	return profile
+}
+
+// AvailableAt reports whether one of the user's availability windows covers a moment, which
+// should already be in the task's time zone
+func (u Users) AvailableAt(t time.Time) bool {
+	for _, window := range u.Availability {
+		if window.Covers(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// RatingValue parses the stored rating, reporting false for users who haven't been rated
+func (u Users) RatingValue() (float64, bool) {
+	rating, err := strconv.ParseFloat(strings.TrimSpace(u.Rating), 64)
+	if err != nil {
+		return 0, false
+	}
+	return rating, true
+}
+
+// clockMinutes parses a strict "HH:MM" 24-hour time into minutes after midnight; "24:00" is
+// allowed so a window can run to the end of the day
+func clockMinutes(value string) (int, bool) {
+	parsed, err := time.Parse("15:04", value)
+	if err == nil {
+		return parsed.Hour()*60 + parsed.Minute(), true
+	}
+	if value == "24:00" {
+		return 24 * 60, true
+	}
+	return 0, false
+}
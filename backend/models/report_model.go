@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReportReason represents why a task or user was reported
+type ReportReason string
+
+// Define report reasons
+const (
+	ReportSpam          ReportReason = "Spam"
+	ReportUnsafe        ReportReason = "Unsafe"
+	ReportScam          ReportReason = "Scam"
+	ReportInappropriate ReportReason = "Inappropriate"
+)
+
+// ReportTargetType represents what kind of entity was reported
+type ReportTargetType string
+
+// Define report target types
+const (
+	ReportTargetTask    ReportTargetType = "task"
+	ReportTargetUser    ReportTargetType = "user"
+	ReportTargetComment ReportTargetType = "comment"
+)
+
+// ReportStatus represents where a report is in the moderation queue
+type ReportStatus string
+
+// Define report statuses
+const (
+	ReportOpen        ReportStatus = "Open"
+	ReportDismissed   ReportStatus = "Dismissed"
+	ReportActionTaken ReportStatus = "Action Taken"
+)
+
+// Report represents a student's report against a task or another user
+type Report struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	TargetType    ReportTargetType    `bson:"target_type" json:"target_type"`
+	TaskID        *primitive.ObjectID `bson:"task_id,omitempty" json:"task_id,omitempty"` // Reported task, or the task the user report was raised from
+	CommentID     *primitive.ObjectID `bson:"comment_id,omitempty" json:"comment_id,omitempty"`
+	ReportedEmail string              `bson:"reported_email" json:"reported_email"` // Task creator for task reports, the comment's author for comment reports, the reported user otherwise
+	ReporterEmail string              `bson:"reporter_email" json:"reporter_email"`
+	Reason        ReportReason        `bson:"reason" json:"reason"`
+	Details       string              `bson:"details" json:"details"`
+	Status        ReportStatus        `bson:"status" json:"status"`
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+
+	// Moderation fields
+	ReviewedBy     string     `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	ReviewedAt     *time.Time `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+	ResolutionNote string     `bson:"resolution_note,omitempty" json:"resolution_note,omitempty"`
+}
+
+// ValidReportReasons lists the reasons a report can be filed for
+func ValidReportReasons() []ReportReason {
+	return []ReportReason{ReportSpam, ReportUnsafe, ReportScam, ReportInappropriate}
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GeoPoint is a latitude/longitude pair in degrees
+type GeoPoint struct {
+	Latitude  float64 `bson:"latitude" json:"latitude"`
+	Longitude float64 `bson:"longitude" json:"longitude"`
+}
+
+// Valid reports whether the point is a real position on the globe
+func (p GeoPoint) Valid() bool {
+	return p.Latitude >= -90 && p.Latitude <= 90 && p.Longitude >= -180 && p.Longitude <= 180
+}
+
+// DistanceKm is the great-circle distance between two points
+func (p GeoPoint) DistanceKm(other GeoPoint) float64 {
+	const earthRadiusKm = 6371.0
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+
+	dLat := toRadians(other.Latitude - p.Latitude)
+	dLng := toRadians(other.Longitude - p.Longitude)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(p.Latitude))*math.Cos(toRadians(other.Latitude))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// Bounds on the distance filter of a saved search. The minimum keeps repeated searches with a
+// shrinking radius from pinning down a task's exact position before the viewer is selected.
+const (
+	MinSearchRadiusKm = 1
+	MaxSearchRadiusKm = 200
+)
+
+// TaskFilter is a feed filter a user can save. Empty fields don't filter.
+type TaskFilter struct {
+	Category TaskCategory `bson:"category,omitempty" json:"category,omitempty"`
+	MinPay   *float64     `bson:"min_pay,omitempty" json:"min_pay,omitempty"` // Per hour
+	MaxPay   *float64     `bson:"max_pay,omitempty" json:"max_pay,omitempty"`
+	FromDate *time.Time   `bson:"from_date,omitempty" json:"from_date,omitempty"` // Compared with task_date, inclusive
+	ToDate   *time.Time   `bson:"to_date,omitempty" json:"to_date,omitempty"`
+	Near     *GeoPoint    `bson:"near,omitempty" json:"near,omitempty"`
+	RadiusKm float64      `bson:"radius_km,omitempty" json:"radius_km,omitempty"`
+	Keyword  string       `bson:"keyword,omitempty" json:"keyword,omitempty"` // Every word must appear in the title or description
+}
+
+// Validate checks that the filter's ranges make sense
+func (f TaskFilter) Validate() error {
+	if (f.MinPay != nil && *f.MinPay < 0) || (f.MaxPay != nil && *f.MaxPay < 0) {
+		return errors.New("pay range can't be negative")
+	}
+	if f.MinPay != nil && f.MaxPay != nil && *f.MinPay > *f.MaxPay {
+		return errors.New("min_pay must not be more than max_pay")
+	}
+	if f.FromDate != nil && f.ToDate != nil && f.FromDate.After(*f.ToDate) {
+		return errors.New("from_date must not be after to_date")
+	}
+	if (f.Near == nil) != (f.RadiusKm == 0) {
+		return errors.New("a distance filter needs both a position and radius_km")
+	}
+	if f.Near != nil && (!f.Near.Valid() || f.RadiusKm < MinSearchRadiusKm || f.RadiusKm > MaxSearchRadiusKm) {
+		return fmt.Errorf("distance filter is out of range; radius_km must be between %d and %d", MinSearchRadiusKm, MaxSearchRadiusKm)
+	}
+	return nil
+}
+
+// Matches reports whether a task passes every part of the filter. Tasks without coordinates
+// never match a distance filter.
+func (f TaskFilter) Matches(task Task) bool {
+	if f.Category != "" && task.WorkType != f.Category {
+		return false
+	}
+	if f.MinPay != nil && task.EstimatedPayRate < *f.MinPay {
+		return false
+	}
+	if f.MaxPay != nil && task.EstimatedPayRate > *f.MaxPay {
+		return false
+	}
+	if f.FromDate != nil && task.TaskDate.Before(*f.FromDate) {
+		return false
+	}
+	if f.ToDate != nil && task.TaskDate.After(*f.ToDate) {
+		return false
+	}
+	if f.Near != nil {
+		if task.Coordinates == nil || f.Near.DistanceKm(*task.Coordinates) > f.RadiusKm {
+			return false
+		}
+	}
+	if f.Keyword != "" {
+		text := strings.ToLower(task.Title + " " + task.Description)
+		for _, word := range strings.Fields(strings.ToLower(f.Keyword)) {
+			if !strings.Contains(text, word) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// SavedSearchAlert says how a user hears about new tasks matching a saved search
+type SavedSearchAlert string
+
+// Define saved search alert modes
+const (
+	AlertImmediate SavedSearchAlert = "immediate"
+	AlertDigest    SavedSearchAlert = "digest" // One email a day listing the day's matches
+	AlertNone      SavedSearchAlert = "none"
+)
+
+// SavedSearch is a named feed filter with its alert settings
+type SavedSearch struct {
+	ID           primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
+	Email        string               `bson:"email" json:"email"`
+	Name         string               `bson:"name" json:"name"`
+	Filter       TaskFilter           `bson:"filter" json:"filter"`
+	Alert        SavedSearchAlert     `bson:"alert" json:"alert"`
+	PendingTasks []primitive.ObjectID `bson:"pending_tasks,omitempty" json:"pending_tasks,omitempty"` // Matches waiting for the next digest
+	LastDigestAt *time.Time           `bson:"last_digest_at,omitempty" json:"last_digest_at,omitempty"`
+	CreatedAt    time.Time            `bson:"created_at" json:"created_at"`
+}
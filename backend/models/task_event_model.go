@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaskEventType represents a mutation in a task's lifecycle
+type TaskEventType string
+
+// Define task event types
+const (
+	TaskCreated      TaskEventType = "created"
+	TaskEdited       TaskEventType = "edited"
+	TaskApplied      TaskEventType = "applied"
+	TaskWithdrawn    TaskEventType = "withdrawn"
+	TaskAccepted     TaskEventType = "accepted"
+	TaskEnded        TaskEventType = "ended"
+	TaskOTPIssued    TaskEventType = "otp_issued"
+	TaskOTPValidated TaskEventType = "otp_validated"
+	TaskCancelled    TaskEventType = "cancelled"
+	TaskExpired      TaskEventType = "expired"
+	TaskDisputed     TaskEventType = "disputed"
+	TaskDisputeRuled TaskEventType = "dispute_resolved"
+)
+
+// TaskEvent is an immutable audit record of a single task mutation
+type TaskEvent struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	TaskID    primitive.ObjectID `bson:"task_id" json:"task_id"`
+	Type      TaskEventType      `bson:"type" json:"type"`
+	Actor     string             `bson:"actor" json:"actor"` // Email of the user who caused the event
+	Timestamp time.Time          `bson:"timestamp" json:"timestamp"`
+	Before    bson.M             `bson:"before,omitempty" json:"before,omitempty"` // Changed fields before the mutation
+	After     bson.M             `bson:"after,omitempty" json:"after,omitempty"`   // Changed fields after the mutation
+	Details   bson.M             `bson:"details,omitempty" json:"details,omitempty"`
+	RequestID string             `bson:"request_id" json:"request_id"`
+}
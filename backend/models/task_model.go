@@ -32,8 +32,26 @@ const (
 	InProgress TaskStatus = "In Progress"
 	Completed  TaskStatus = "Completed"
 	Cancelled  TaskStatus = "Cancelled"
+	Expired    TaskStatus = "Expired"  // Start passed while still open with nobody selected
+	Disputed   TaskStatus = "Disputed" // A worker disputes the poster's refusal to confirm completion
 )
 
+// ValidTaskCategories lists the categories a task can be posted under
+func ValidTaskCategories() []TaskCategory {
+	return []TaskCategory{
+		Plumbing,
+		HouseShifting,
+		Carpentry,
+		Cleaning,
+		Electrical,
+		Painting,
+		Gardening,
+		Tutoring,
+		ComputerHelp,
+		Other,
+	}
+}
+
 // Task represents a task posted by a user
 type Task struct {
 	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
@@ -41,8 +59,13 @@ type Task struct {
 	Description      string             `bson:"description" json:"description"`
 	TaskTime         string             `bson:"task_time" json:"task_time"`
 	TaskDate         time.Time          `bson:"task_date" json:"task_date"`
+	StartAt          time.Time          `bson:"start_at" json:"start_at"`
+	EndAt            time.Time          `bson:"end_at" json:"end_at"`
+	DurationMinutes  int                `bson:"duration_minutes" json:"duration_minutes"`
+	TimeZone         string             `bson:"time_zone" json:"time_zone"`                   // IANA name, e.g. America/New_York
 	EstimatedPayRate float64            `bson:"estimated_pay_rate" json:"estimated_pay_rate"` // Per hour
 	PlaceOfWork      string             `bson:"place_of_work" json:"place_of_work"`
+	Coordinates      *GeoPoint          `bson:"coordinates,omitempty" json:"-"` // Exact position, only used for distance searches
 	WorkType         TaskCategory       `bson:"work_type" json:"work_type"`
 	PeopleNeeded     int                `bson:"people_needed" json:"people_needed"`
 
@@ -54,6 +77,78 @@ type Task struct {
 	Views         int        `bson:"views" json:"views"`
 	Applicants    []string   `bson:"applicants" json:"applicants"`         // List of emails of users who applied
 	SelectedUsers []string   `bson:"selected_users" json:"selected_users"` // List of emails of users selected for the task
+	Hidden        bool       `bson:"hidden" json:"hidden"`                 // Hidden from the feed pending moderation review
+
+	Version int `bson:"version" json:"version"` // Bumped on every edit for optimistic concurrency
+
+	// Bumped whenever an assignment is settled, so concurrent settlements of the task conflict
+	AssignmentRevision int `bson:"assignment_revision,omitempty" json:"-"`
+
+	// Selected workers who have not yet re-confirmed after a material edit
+	PendingConfirmations []string `bson:"pending_confirmations,omitempty" json:"pending_confirmations,omitempty"`
+
+	// Set on responses where place_of_work has been reduced to an approximate location
+	LocationApproximate bool `bson:"-" json:"location_approximate,omitempty"`
+
+	// Set on feed responses sorted by recommendation
+	Recommendation *Recommendation `bson:"-" json:"recommendation,omitempty"`
+
+	// Set on feed responses when the viewer has bookmarked the task
+	Bookmarked bool `bson:"-" json:"bookmarked,omitempty"`
+}
+
+// SpotsLeft is how many more workers the poster can still select
+func (t Task) SpotsLeft() int {
+	left := t.PeopleNeeded - len(t.SelectedUsers)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// NearlyFull reports whether a task that needs several workers is down to its last spot,
+// or its last fifth of spots for large tasks
+func (t Task) NearlyFull() bool {
+	left := t.SpotsLeft()
+	return t.PeopleNeeded > 1 && left > 0 && (left == 1 || left*5 <= t.PeopleNeeded)
+}
+
+// Recommendation explains why a task was ranked where it was in a viewer's feed
+type Recommendation struct {
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"` // Strongest first, e.g. "You've done 4 Tutoring tasks"
+}
+
+// ContactRevealGrace keeps contact details visible for a while after a task's scheduled end
+const ContactRevealGrace = 12 * time.Hour
+
+// ContactWindowOpen reports whether a worker and the poster can see each other's contact details
+// and the exact place of work: only while the worker is selected, the task is still active and it
+// hasn't long since ended
+func (t Task) ContactWindowOpen(worker string, now time.Time) bool {
+	selected := false
+	for _, email := range t.SelectedUsers {
+		if email == worker {
+			selected = true
+			break
+		}
+	}
+	if !selected {
+		return false
+	}
+
+	switch t.Status {
+	case Completed, Cancelled, Expired:
+		return false
+	}
+
+	// Legacy tasks without an end time stay open until they're completed or cancelled
+	return t.EndAt.IsZero() || now.Before(t.EndAt.Add(ContactRevealGrace))
+}
+
+// ShowsExactLocationTo reports whether a viewer may see the exact place of work
+func (t Task) ShowsExactLocationTo(viewer string, now time.Time) bool {
+	return viewer == t.CreatorEmail || t.ContactWindowOpen(viewer, now)
 }
 
 type ScheduledTask struct {
@@ -65,5 +160,93 @@ type ScheduledTask struct {
 	ScheduledAt time.Time          `bson:"scheduled_at" json:"scheduled_at"`
 	TaskDate    time.Time          `bson:"task_date" json:"task_date"`
 	TaskTime    string             `bson:"task_time" json:"task_time"`
+	StartAt     time.Time          `bson:"start_at" json:"start_at"`
+	EndAt       time.Time          `bson:"end_at" json:"end_at"`
+	TimeZone    string             `bson:"time_zone" json:"time_zone"`
 	Place       string             `bson:"place_of_work" json:"place_of_work"`
+	Status      TaskStatus         `bson:"status,omitempty" json:"status,omitempty"` // Set once this worker's part is completed or the task is cancelled
+	CompletedAt time.Time          `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	EndedAt     *time.Time         `bson:"ended_at,omitempty" json:"ended_at,omitempty"`               // When the worker last marked their part done
+	Outcome     DisputeOutcome     `bson:"dispute_outcome,omitempty" json:"dispute_outcome,omitempty"` // Set when a moderator settled this assignment
+
+	// Time tracking for hourly pay
+	WorkState       WorkState     `bson:"work_state,omitempty" json:"work_state,omitempty"`
+	Segments        []WorkSegment `bson:"segments,omitempty" json:"segments,omitempty"`
+	LoggedMinutes   int           `bson:"logged_minutes,omitempty" json:"logged_minutes,omitempty"`     // Worked time, set at check-out
+	ApprovedMinutes *int          `bson:"approved_minutes,omitempty" json:"approved_minutes,omitempty"` // Time the poster agreed to pay for
+	TimeApproval    TimeApproval  `bson:"time_approval,omitempty" json:"time_approval,omitempty"`
+	TimeNote        string        `bson:"time_note,omitempty" json:"time_note,omitempty"` // Poster's reason for an adjustment
+	AmountDue       float64       `bson:"amount_due,omitempty" json:"amount_due,omitempty"`
+}
+
+// WorkState is where a worker is in checking in and out of an assignment
+type WorkState string
+
+// Define work states
+const (
+	WorkWorking    WorkState = "working"
+	WorkPaused     WorkState = "paused"
+	WorkCheckedOut WorkState = "checked_out"
+)
+
+// WorkSegment is one uninterrupted stretch of work; End is unset while it is still running
+type WorkSegment struct {
+	Start time.Time  `bson:"start" json:"start"`
+	End   *time.Time `bson:"end,omitempty" json:"end,omitempty"`
+}
+
+// TimeApproval is the poster's decision on a worker's logged time
+type TimeApproval string
+
+// Define time approval states
+const (
+	TimePending  TimeApproval = "pending"
+	TimeApproved TimeApproval = "approved"
+	TimeAdjusted TimeApproval = "adjusted"
+)
+
+// WorkedMinutes totals the worked segments, counting a running one up to now
+func (s ScheduledTask) WorkedMinutes(now time.Time) int {
+	var worked time.Duration
+	for _, segment := range s.Segments {
+		end := now
+		if segment.End != nil {
+			end = *segment.End
+		}
+		if end.After(segment.Start) {
+			worked += end.Sub(segment.Start)
+		}
+	}
+	return int(worked.Round(time.Minute) / time.Minute)
+}
+
+// PayableMinutes is the approved time, or the logged time while the poster hasn't reviewed it
+func (s ScheduledTask) PayableMinutes() int {
+	if s.ApprovedMinutes != nil {
+		return *s.ApprovedMinutes
+	}
+	return s.LoggedMinutes
+}
+
+// ReminderKind identifies which reminder was sent for a task
+type ReminderKind string
+
+// Define reminder kinds
+const (
+	Reminder24Hours    ReminderKind = "24h"
+	Reminder1Hour      ReminderKind = "1h"
+	ReminderCompletion ReminderKind = "completion_nudge"
+)
+
+// TaskReminder tracks delivery of one reminder to one recipient so restarts don't resend it
+type TaskReminder struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	TaskID         primitive.ObjectID `bson:"task_id" json:"task_id"`
+	RecipientEmail string             `bson:"recipient_email" json:"recipient_email"`
+	Kind           ReminderKind       `bson:"kind" json:"kind"`
+	Delivered      bool               `bson:"delivered" json:"delivered"`
+	Attempts       int                `bson:"attempts" json:"attempts"`
+	LastError      string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	DeliveredAt    *time.Time         `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
 }
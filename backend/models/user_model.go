@@ -11,10 +11,38 @@ type Users struct {
 	Email          string `gorm:"primaryKey" json:"email"`
 	Name           string `json:"name"`
 	Mobile         string `json:"mobile"`
-	CompletedTasks int    `json:"completedTasks"`
+	CompletedTasks int    `bson:"completed_tasks" json:"completedTasks"`
 	Rating         string `json:"rating"`
+	Role           string `bson:"role,omitempty" json:"role,omitempty"` // "moderator" for users who can triage reports
+	CalendarToken  string `bson:"calendar_token,omitempty" json:"-"`    // Secret for the private iCal subscription URL
+	DisputesWon    int    `bson:"disputes_won,omitempty" json:"disputes_won,omitempty"`
+	DisputesLost   int    `bson:"disputes_lost,omitempty" json:"disputes_lost,omitempty"`
+	HideMobile     bool   `bson:"hide_mobile,omitempty" json:"hide_mobile"` // Never share the mobile number; use in-app messaging instead
+
+	// Profile details shown on the public profile
+	Bio          string               `bson:"bio,omitempty" json:"bio,omitempty"`
+	Major        string               `bson:"major,omitempty" json:"major,omitempty"`
+	Year         AcademicYear         `bson:"year,omitempty" json:"year,omitempty"`
+	Skills       []TaskCategory       `bson:"skills,omitempty" json:"skills,omitempty"`
+	Availability []AvailabilityWindow `bson:"availability,omitempty" json:"availability,omitempty"`
+	AvatarKey    string               `bson:"avatar_key,omitempty" json:"-"` // Blob store key of the avatar image
+	AvatarType   string               `bson:"avatar_type,omitempty" json:"-"`
+	InDirectory  bool                 `bson:"in_directory,omitempty" json:"in_directory"` // Opted in to the searchable worker directory
+}
+
+// UserSummary is the part of a user's profile shown to the other side of a task
+type UserSummary struct {
+	Email          string `bson:"email" json:"email"`
+	Name           string `bson:"name" json:"name"`
+	Mobile         string `bson:"mobile" json:"mobile"`
+	Rating         string `bson:"rating" json:"rating"`
+	CompletedTasks int    `bson:"completed_tasks" json:"completed_tasks"`
+	HideMobile     bool   `bson:"hide_mobile" json:"-"`
 }
 
+// UserRoleModerator marks a user who can work the moderation queue
+const UserRoleModerator = "moderator"
+
 // UserAuth model (UserAuth Table)
 type User_Auth struct {
 	Email    string `gorm:"primaryKey" json:"email"`
@@ -37,3 +65,10 @@ type TaskCompletionOTP struct {
 	TaskID      primitive.ObjectID `bson:"task_id"`      // Associated task ID
 	WorkerEmail string             `bson:"worker_email"` // Worker who completed the task
 }
+
+// UserBlock records that one user has blocked another
+type UserBlock struct {
+	BlockerEmail string    `bson:"blocker_email" json:"blocker_email"`
+	BlockedEmail string    `bson:"blocked_email" json:"blocked_email"`
+	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+	"ufpeerassist/backend/models"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Test that a worker can only be selected once, only after applying and only while a spot is left
+func TestAcceptTaskSelectsOnce(t *testing.T) {
+	st := setupScheduleConflictTest(t)
+	seedUsers(t, st.db, models.Users{Name: "Late", Email: "late@example.com"})
+
+	// A day after the worker's other booking, so the schedules don't clash
+	taskID := st.seedTask(t, st.booked.Add(24*time.Hour), time.Hour)
+
+	w, _ := performJSON(st.router, http.MethodPost, "/tasks/"+taskID+"/accept/worker@example.com", nil)
+	assert.Equal(t, http.StatusConflict, w.Code, "A worker who hasn't applied can't be selected")
+
+	st.apply(t, taskID)
+	w, _ = performJSON(st.router, http.MethodPost, "/tasks/"+taskID+"/apply/late@example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, _ = performJSON(st.router, http.MethodPost, "/tasks/"+taskID+"/accept/worker@example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code, "Accepting should succeed: %s", w.Body.String())
+
+	w, _ = performJSON(st.router, http.MethodPost, "/tasks/"+taskID+"/accept/worker@example.com", nil)
+	assert.Equal(t, http.StatusConflict, w.Code, "Accepting the same worker again should be refused")
+
+	w, _ = performJSON(st.router, http.MethodPost, "/tasks/"+taskID+"/accept/late@example.com", nil)
+	assert.Equal(t, http.StatusConflict, w.Code, "The only spot is taken")
+
+	objectID, _ := primitive.ObjectIDFromHex(taskID)
+	var task models.Task
+	assert.NoError(t, st.db.Collection("tasks").FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&task))
+	assert.Equal(t, []string{"worker@example.com"}, task.SelectedUsers)
+
+	rows, err := st.db.Collection("scheduled_tasks").CountDocuments(context.Background(), bson.M{"task_id": objectID})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), rows, "The worker should be booked once")
+}
@@ -53,6 +53,7 @@ func (at *ApplyForTaskTest) Initialize(t *testing.T) {
 	// Clear existing data from collections
 	at.db.Collection("users").DeleteMany(at.ctx, bson.M{})
 	at.db.Collection("tasks").DeleteMany(at.ctx, bson.M{})
+	at.db.Collection("blocks").DeleteMany(at.ctx, bson.M{})
 
 	// Seed test data
 	at.seedTestData(t)
@@ -127,6 +128,22 @@ func (at *ApplyForTaskTest) mockApplyForTask(c *gin.Context) {
 		return
 	}
 
+	// Check that neither user has blocked the other
+	blocked, err := at.db.Collection("blocks").CountDocuments(at.ctx, bson.M{
+		"$or": []bson.M{
+			{"blocker_email": task.CreatorEmail, "blocked_email": applicantEmail},
+			{"blocker_email": applicantEmail, "blocked_email": task.CreatorEmail},
+		},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply for task", "details": err.Error()})
+		return
+	}
+	if blocked > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot apply for this task"})
+		return
+	}
+
 	// Check if user has already applied
 	for _, email := range task.Applicants {
 		if email == applicantEmail {
@@ -502,3 +519,40 @@ func TestApplyToTaskAlreadyApplied(t *testing.T) {
 	assert.NoError(t, err, "Failed to parse response body")
 	assert.Equal(t, "You have already applied for this task", response.Error)
 }
+
+// Test applying to a task whose creator has blocked the applicant
+func TestApplyToTaskBlockedByCreator(t *testing.T) {
+	// Initialize test environment
+	at := &ApplyForTaskTest{}
+	at.Initialize(t)
+	defer at.Cleanup(t)
+
+	// The creator blocks the applicant
+	_, err := at.db.Collection("blocks").InsertOne(at.ctx, models.UserBlock{
+		BlockerEmail: "creator@example.com",
+		BlockedEmail: "applicant@example.com",
+		CreatedAt:    time.Now(),
+	})
+	assert.NoError(t, err, "Failed to seed block")
+
+	req, _ := http.NewRequest("POST", "/tasks/"+at.openTaskIDHex+"/apply/applicant@example.com", nil)
+
+	w := httptest.NewRecorder()
+	at.router.ServeHTTP(w, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusForbidden, w.Code, "Expected status 403 Forbidden when the creator blocked the applicant")
+
+	var response struct {
+		Error string `json:"error"`
+	}
+	err = json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err, "Failed to parse response body")
+	assert.Equal(t, "You cannot apply for this task", response.Error)
+
+	// Applicant must not have been added
+	var task models.Task
+	err = at.db.Collection("tasks").FindOne(at.ctx, bson.M{"_id": at.openTaskID}).Decode(&task)
+	assert.NoError(t, err, "Failed to find task")
+	assert.NotContains(t, task.Applicants, "applicant@example.com")
+}
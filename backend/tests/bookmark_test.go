@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/handlers"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Test when bookmarkers are told a task is filling up
+func TestTaskNearlyFull(t *testing.T) {
+	task := func(needed int, selected ...string) models.Task {
+		return models.Task{PeopleNeeded: needed, SelectedUsers: selected}
+	}
+
+	// Single-worker tasks go straight from open to full
+	assert.False(t, task(1).NearlyFull())
+	assert.False(t, task(1, "a").NearlyFull())
+
+	// Last spot
+	assert.False(t, task(3, "a").NearlyFull())
+	assert.True(t, task(3, "a", "b").NearlyFull())
+	assert.False(t, task(3, "a", "b", "c").NearlyFull(), "full, not nearly full")
+
+	// Large tasks warn with a fifth of the spots left
+	assert.False(t, task(10, "a", "b", "c", "d", "e", "f", "g").NearlyFull())
+	assert.True(t, task(10, "a", "b", "c", "d", "e", "f", "g", "h").NearlyFull())
+
+	assert.Equal(t, 2, task(3, "a").SpotsLeft())
+	assert.Equal(t, 0, task(1, "a", "b").SpotsLeft(), "never negative")
+}
+
+// bookmarkTest holds the database, router and seeded tasks of a bookmark test
+type bookmarkTest struct {
+	db       *mongo.Database
+	router   *gin.Engine
+	expiring string // Starts tomorrow with nobody selected
+	staffed  string // Starts tomorrow with a worker already selected
+	later    string // Starts next week
+}
+
+// setupBookmarkTest seeds a poster, a student, an applicant and three open tasks
+func setupBookmarkTest(t *testing.T) *bookmarkTest {
+	bt := &bookmarkTest{db: setupHandlerDatabase(t, "ufpeerassist_test_bookmarks")}
+
+	seedUsers(t, bt.db,
+		models.Users{Name: "Poster", Email: "poster@example.com"},
+		models.Users{Name: "Student", Email: "student@example.com"},
+		models.Users{Name: "Applicant", Email: "applicant@example.com"},
+	)
+
+	seed := func(title string, start time.Time, selected []string) string {
+		task := models.Task{
+			ID:            primitive.NewObjectID(),
+			Title:         title,
+			StartAt:       start,
+			EndAt:         start.Add(time.Hour),
+			PeopleNeeded:  2,
+			CreatorEmail:  "poster@example.com",
+			Status:        models.Open,
+			Applicants:    []string{"applicant@example.com"},
+			SelectedUsers: selected,
+			CreatedAt:     time.Now(),
+			Version:       1,
+		}
+		_, err := bt.db.Collection("tasks").InsertOne(context.Background(), task)
+		assert.NoError(t, err, "Seeding the task should succeed")
+		return task.ID.Hex()
+	}
+	now := time.Now()
+	bt.expiring = seed("Expiring", now.Add(12*time.Hour), []string{})
+	bt.staffed = seed("Staffed", now.Add(12*time.Hour), []string{"applicant@example.com"})
+	bt.later = seed("Next week", now.Add(7*24*time.Hour), []string{})
+
+	bt.router = gin.New()
+	bt.router.POST("/users/:email/bookmarks/:task_id", handlers.AddBookmark)
+	bt.router.DELETE("/users/:email/bookmarks/:task_id", handlers.RemoveBookmark)
+	bt.router.GET("/users/:email/bookmarks", handlers.GetBookmarks)
+	bt.router.GET("/tasks/feed/:viewer_email", handlers.GetAllTasksForUser)
+	return bt
+}
+
+// bookmark bookmarks a task for a user, expecting it to succeed
+func (bt *bookmarkTest) bookmark(t *testing.T, email, taskID string) {
+	w, _ := performJSON(bt.router, http.MethodPost, "/users/"+email+"/bookmarks/"+taskID, nil)
+	assert.Equal(t, http.StatusCreated, w.Code, "Bookmarking should succeed: %s", w.Body.String())
+}
+
+// feedBookmarks maps the titles in a user's feed to whether each is flagged as bookmarked
+func (bt *bookmarkTest) feedBookmarks(t *testing.T, email string) map[string]bool {
+	w, response := performJSON(bt.router, http.MethodGet, "/tasks/feed/"+email, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	flags := map[string]bool{}
+	tasks, _ := response["tasks"].([]interface{})
+	for _, item := range tasks {
+		task, _ := item.(map[string]interface{})
+		title, _ := task["title"].(string)
+		flags[title] = task["bookmarked"] == true
+	}
+	return flags
+}
+
+// Test adding, listing and removing bookmarks, and the flag they put on the feed
+func TestBookmarks(t *testing.T) {
+	bt := setupBookmarkTest(t)
+
+	for _, taskID := range []string{bt.expiring, bt.staffed, bt.later} {
+		bt.bookmark(t, "student@example.com", taskID)
+	}
+
+	w, _ := performJSON(bt.router, http.MethodPost, "/users/student@example.com/bookmarks/"+bt.later, nil)
+	assert.Equal(t, http.StatusConflict, w.Code, "A task can only be bookmarked once")
+
+	w, _ = performJSON(bt.router, http.MethodPost, "/users/poster@example.com/bookmarks/"+bt.later, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Posters can't bookmark their own tasks")
+
+	w, response := performJSON(bt.router, http.MethodGet, "/users/student@example.com/bookmarks", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(3), response["count"])
+
+	assert.Equal(t, map[string]bool{"Expiring": true, "Staffed": true, "Next week": true}, bt.feedBookmarks(t, "student@example.com"))
+
+	w, _ = performJSON(bt.router, http.MethodDelete, "/users/student@example.com/bookmarks/"+bt.later, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w, _ = performJSON(bt.router, http.MethodDelete, "/users/student@example.com/bookmarks/"+bt.later, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, "A removed bookmark is gone")
+
+	w, response = performJSON(bt.router, http.MethodGet, "/users/student@example.com/bookmarks", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	titles := []string{}
+	bookmarks, _ := response["bookmarks"].([]interface{})
+	for _, item := range bookmarks {
+		task, _ := item.(map[string]interface{})["task"].(map[string]interface{})
+		title, _ := task["title"].(string)
+		titles = append(titles, title)
+	}
+	assert.ElementsMatch(t, []string{"Expiring", "Staffed"}, titles)
+
+	assert.Equal(t, map[string]bool{"Expiring": true, "Staffed": true, "Next week": false}, bt.feedBookmarks(t, "student@example.com"))
+}
+
+// Test that bookmarkers hear once that an unstaffed task is about to expire, and applicants and
+// bookmarkers of staffed tasks not at all
+func TestBookmarkExpiryAlertsOnce(t *testing.T) {
+	bt := setupBookmarkTest(t)
+
+	bt.bookmark(t, "student@example.com", bt.expiring)
+	bt.bookmark(t, "student@example.com", bt.staffed)
+	bt.bookmark(t, "student@example.com", bt.later)
+	bt.bookmark(t, "applicant@example.com", bt.expiring)
+
+	sent := map[string]int{}
+	notify := func(email string, task models.Task, message string) error {
+		sent[email+"/"+task.Title]++
+		return nil
+	}
+	handlers.SendBookmarkExpiryAlertsWith(time.Now(), notify)
+	handlers.SendBookmarkExpiryAlertsWith(time.Now(), notify)
+
+	assert.Equal(t, map[string]int{"student@example.com/Expiring": 1}, sent, "Only the unstaffed task starting soon should be flagged, once")
+
+	expiring, _ := primitive.ObjectIDFromHex(bt.expiring)
+	var bookmark models.Bookmark
+	err := bt.db.Collection("bookmarks").FindOne(context.Background(), bson.M{"email": "student@example.com", "task_id": expiring}).Decode(&bookmark)
+	assert.NoError(t, err)
+	assert.True(t, bookmark.ExpiringNotified, "The alert should be claimed on the bookmark")
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test reducing places of work to an approximate location
+func TestApproximateLocation(t *testing.T) {
+	cases := map[string]string{
+		"Apt 4B, 1500 SW 13th St, Gainesville, FL 32601": "Gainesville, FL",
+		"Library West Room 210":                          "Library West",
+		"123 Main St":                                    "Main St",
+		"Reitz Union, Room 2300":                         "Reitz Union",
+		"Hume Hall #312":                                 "Hume Hall",
+		"Library":                                        "Library",
+		"4000 Central Florida Blvd":                      "Central Florida Blvd",
+		"42":                                             utils.LocationHidden,
+		"":                                               utils.LocationHidden,
+	}
+
+	for input, expected := range cases {
+		assert.Equal(t, expected, utils.ApproximateLocation(input), "Unexpected approximation of %q", input)
+	}
+}
+
+// Test when a worker and the poster can see each other's contact details
+func TestContactWindowOpen(t *testing.T) {
+	now := time.Date(2025, 4, 20, 12, 0, 0, 0, time.UTC)
+	task := models.Task{
+		CreatorEmail:  "poster@example.com",
+		Status:        models.InProgress,
+		StartAt:       now.Add(2 * time.Hour),
+		EndAt:         now.Add(4 * time.Hour),
+		Applicants:    []string{"worker@example.com", "applicant@example.com"},
+		SelectedUsers: []string{"worker@example.com"},
+	}
+
+	assert.True(t, task.ContactWindowOpen("worker@example.com", now), "Selected worker should see contact details")
+	assert.False(t, task.ContactWindowOpen("applicant@example.com", now), "Unselected applicant should not")
+	assert.True(t, task.ShowsExactLocationTo("poster@example.com", now), "Poster always sees their own address")
+	assert.False(t, task.ShowsExactLocationTo("applicant@example.com", now))
+
+	// Still open shortly after the end, closed once the grace period is over
+	assert.True(t, task.ContactWindowOpen("worker@example.com", task.EndAt.Add(time.Hour)))
+	assert.False(t, task.ContactWindowOpen("worker@example.com", task.EndAt.Add(models.ContactRevealGrace+time.Minute)))
+
+	// Finished tasks close the window straight away
+	for _, status := range []models.TaskStatus{models.Completed, models.Cancelled, models.Expired} {
+		task.Status = status
+		assert.False(t, task.ContactWindowOpen("worker@example.com", now), "Window should close when %s", status)
+	}
+
+	// Legacy tasks without an end time stay open while active
+	legacy := models.Task{Status: models.Open, SelectedUsers: []string{"worker@example.com"}}
+	assert.True(t, legacy.ContactWindowOpen("worker@example.com", now))
+}
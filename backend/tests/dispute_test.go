@@ -0,0 +1,243 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/handlers"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// setupDisputeTest seeds a poster, a worker, a moderator and an in-progress task with the worker selected
+func setupDisputeTest(t *testing.T) (*mongo.Database, *gin.Engine, primitive.ObjectID) {
+	db := setupHandlerDatabase(t, "ufpeerassist_test_disputes")
+
+	seedUsers(t, db,
+		models.Users{Name: "Poster", Email: "poster@example.com"},
+		models.Users{Name: "Worker", Email: "worker@example.com"},
+		models.Users{Name: "Moderator", Email: "moderator@example.com", Role: models.UserRoleModerator},
+	)
+
+	start := time.Now().Add(-3 * time.Hour)
+	task := models.Task{
+		ID:               primitive.NewObjectID(),
+		Title:            "Move a couch",
+		StartAt:          start,
+		EndAt:            start.Add(2 * time.Hour),
+		DurationMinutes:  120,
+		EstimatedPayRate: 20,
+		PeopleNeeded:     1,
+		CreatorEmail:     "poster@example.com",
+		Status:           models.InProgress,
+		Applicants:       []string{"worker@example.com"},
+		SelectedUsers:    []string{"worker@example.com"},
+		Version:          1,
+	}
+	_, err := db.Collection("tasks").InsertOne(context.Background(), task)
+	assert.NoError(t, err, "Seeding the task should succeed")
+
+	_, err = db.Collection("scheduled_tasks").InsertOne(context.Background(), models.ScheduledTask{
+		TaskID:  task.ID,
+		Title:   task.Title,
+		Poster:  task.CreatorEmail,
+		Worker:  "worker@example.com",
+		StartAt: task.StartAt,
+		EndAt:   task.EndAt,
+	})
+	assert.NoError(t, err, "Seeding the assignment should succeed")
+
+	// The poster paid the worker's $40 into escrow when selecting them
+	escrow := models.EscrowAccount(task.ID, "worker@example.com")
+	_, err = db.Collection("ledger").InsertOne(context.Background(), models.LedgerTransaction{
+		Kind:           models.LedgerEscrowHold,
+		IdempotencyKey: "hold:" + task.ID.Hex() + ":worker@example.com:0",
+		TaskID:         &task.ID,
+		PosterEmail:    task.CreatorEmail,
+		WorkerEmail:    "worker@example.com",
+		Postings: []models.LedgerPosting{
+			{Account: models.ExternalAccount, AmountCents: -4000},
+			{Account: escrow, AmountCents: 4000},
+		},
+		CreatedAt: time.Now(),
+	})
+	assert.NoError(t, err, "Seeding the escrow hold should succeed")
+
+	router := gin.New()
+	router.POST("/tasks/:task_id/end/:email", handlers.EndTask)
+	router.POST("/tasks/:task_id/dispute/:email", handlers.OpenDispute)
+	router.POST("/disputes/:dispute_id/statements/:email", handlers.AddDisputeStatement)
+	router.POST("/moderation/disputes/:dispute_id/resolve/:email", handlers.ResolveDispute)
+
+	return db, router, task.ID
+}
+
+// openDispute ends the worker's part of the task and disputes it, returning the dispute ID
+func openDispute(t *testing.T, router *gin.Engine, taskID primitive.ObjectID) string {
+	w, _ := performJSON(router, http.MethodPost, "/tasks/"+taskID.Hex()+"/end/worker@example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code, "Ending the task should succeed: %s", w.Body.String())
+
+	w, response := performJSON(router, http.MethodPost, "/tasks/"+taskID.Hex()+"/dispute/worker@example.com", gin.H{"statement": "I moved the couch"})
+	assert.Equal(t, http.StatusCreated, w.Code, "Opening the dispute should succeed: %s", w.Body.String())
+	id, _ := response["dispute_id"].(string)
+	return id
+}
+
+// findUser reads a user back from the test database
+func findUser(t *testing.T, db *mongo.Database, email string) models.Users {
+	var user models.Users
+	err := db.Collection("users").FindOne(context.Background(), bson.M{"email": email}).Decode(&user)
+	assert.NoError(t, err)
+	return user
+}
+
+// findAssignment reads the worker's assignment back from the test database
+func findAssignment(t *testing.T, db *mongo.Database, taskID primitive.ObjectID) models.ScheduledTask {
+	var assignment models.ScheduledTask
+	err := db.Collection("scheduled_tasks").FindOne(context.Background(), bson.M{"task_id": taskID}).Decode(&assignment)
+	assert.NoError(t, err)
+	return assignment
+}
+
+// findTaskStatus reads a task's status back from the test database
+func findTaskStatus(t *testing.T, db *mongo.Database, taskID primitive.ObjectID) models.TaskStatus {
+	var task models.Task
+	err := db.Collection("tasks").FindOne(context.Background(), bson.M{"_id": taskID}).Decode(&task)
+	assert.NoError(t, err)
+	return task.Status
+}
+
+// escrowSettlement reads how the worker's escrow was settled: what they were paid and what the poster got back
+func escrowSettlement(t *testing.T, db *mongo.Database, taskID primitive.ObjectID) (paid, refunded int64) {
+	var settlement models.LedgerTransaction
+	err := db.Collection("ledger").FindOne(context.Background(), bson.M{
+		"task_id": taskID,
+		"kind":    bson.M{"$in": []models.LedgerTransactionKind{models.LedgerEscrowRelease, models.LedgerEscrowRefund}},
+	}).Decode(&settlement)
+	if !assert.NoError(t, err, "The escrow should be settled") {
+		return 0, 0
+	}
+	assert.Equal(t, int64(-4000), settlement.AmountFor(models.EscrowAccount(taskID, "worker@example.com")), "The escrow should be emptied")
+	return settlement.AmountFor(models.WalletAccount("worker@example.com")), settlement.AmountFor(models.ExternalAccount)
+}
+
+// Test that only ended assignments can be disputed, going by the assignment rather than the audit log
+func TestOpenDisputeRequiresEndedAssignment(t *testing.T) {
+	db, router, taskID := setupDisputeTest(t)
+
+	w, _ := performJSON(router, http.MethodPost, "/tasks/"+taskID.Hex()+"/dispute/worker@example.com", gin.H{"statement": "Too early"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "A worker who hasn't ended the task can't dispute it")
+
+	w, _ = performJSON(router, http.MethodPost, "/tasks/"+taskID.Hex()+"/end/worker@example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotNil(t, findAssignment(t, db, taskID).EndedAt, "Ending the task should be recorded on the assignment")
+
+	// Losing the best-effort audit log mustn't stop the dispute
+	_, err := db.Collection("task_events").DeleteMany(context.Background(), bson.M{})
+	assert.NoError(t, err)
+
+	w, _ = performJSON(router, http.MethodPost, "/tasks/"+taskID.Hex()+"/dispute/worker@example.com", gin.H{"statement": "I moved the couch"})
+	assert.Equal(t, http.StatusCreated, w.Code, "Opening the dispute should succeed: %s", w.Body.String())
+	assert.Equal(t, models.Disputed, findTaskStatus(t, db, taskID), "The task should be on hold")
+
+	w, _ = performJSON(router, http.MethodPost, "/tasks/"+taskID.Hex()+"/dispute/worker@example.com", gin.H{"statement": "Again"})
+	assert.Equal(t, http.StatusConflict, w.Code, "An assignment can only be disputed once")
+}
+
+// Test a ruling for the worker: the assignment and task complete and the counters move
+func TestResolveDisputeComplete(t *testing.T) {
+	db, router, taskID := setupDisputeTest(t)
+	disputeID := openDispute(t, router, taskID)
+
+	w, _ := performJSON(router, http.MethodPost, "/disputes/"+disputeID+"/statements/poster@example.com", gin.H{"statement": "The couch is still here"})
+	assert.Equal(t, http.StatusOK, w.Code, "The poster should be able to add their side")
+
+	w, _ = performJSON(router, http.MethodPost, "/moderation/disputes/"+disputeID+"/resolve/worker@example.com", gin.H{"outcome": "complete"})
+	assert.Equal(t, http.StatusForbidden, w.Code, "Only moderators can rule")
+
+	w, response := performJSON(router, http.MethodPost, "/moderation/disputes/"+disputeID+"/resolve/moderator@example.com", gin.H{"outcome": "complete"})
+	assert.Equal(t, http.StatusOK, w.Code, "Resolving should succeed: %s", w.Body.String())
+	assert.Equal(t, string(models.Completed), response["task_status"])
+
+	assignment := findAssignment(t, db, taskID)
+	assert.Equal(t, models.Completed, assignment.Status)
+	assert.Equal(t, models.DisputeComplete, assignment.Outcome)
+	assert.Equal(t, models.Completed, findTaskStatus(t, db, taskID))
+
+	worker, poster := findUser(t, db, "worker@example.com"), findUser(t, db, "poster@example.com")
+	assert.Equal(t, 1, worker.CompletedTasks, "The worker should be credited with the task")
+	assert.Equal(t, 1, worker.DisputesWon)
+	assert.Equal(t, 1, poster.DisputesLost)
+
+	paid, refunded := escrowSettlement(t, db, taskID)
+	assert.Equal(t, int64(4000), paid, "The worker should be paid in full")
+	assert.Zero(t, refunded)
+
+	w, _ = performJSON(router, http.MethodPost, "/moderation/disputes/"+disputeID+"/resolve/moderator@example.com", gin.H{"outcome": "incomplete"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "A dispute can only be resolved once")
+}
+
+// Test a ruling for the poster: the worker is released and the task, with nobody left, is cancelled
+func TestResolveDisputeIncomplete(t *testing.T) {
+	db, router, taskID := setupDisputeTest(t)
+	disputeID := openDispute(t, router, taskID)
+
+	w, response := performJSON(router, http.MethodPost, "/moderation/disputes/"+disputeID+"/resolve/moderator@example.com", gin.H{"outcome": "incomplete"})
+	assert.Equal(t, http.StatusOK, w.Code, "Resolving should succeed: %s", w.Body.String())
+	assert.Equal(t, string(models.Cancelled), response["task_status"])
+
+	assert.Equal(t, models.Cancelled, findAssignment(t, db, taskID).Status)
+	assert.Equal(t, models.Cancelled, findTaskStatus(t, db, taskID))
+
+	worker, poster := findUser(t, db, "worker@example.com"), findUser(t, db, "poster@example.com")
+	assert.Equal(t, 0, worker.CompletedTasks, "The worker shouldn't be credited with the task")
+	assert.Equal(t, 1, worker.DisputesLost)
+	assert.Equal(t, 1, poster.DisputesWon)
+
+	paid, refunded := escrowSettlement(t, db, taskID)
+	assert.Zero(t, paid, "The worker shouldn't be paid")
+	assert.Equal(t, int64(4000), refunded, "The poster should get everything back")
+}
+
+// Test a partial ruling: the worker gets the moderator's share of the escrow, the poster the rest,
+// and neither side wins or loses
+func TestResolveDisputePartial(t *testing.T) {
+	db, router, taskID := setupDisputeTest(t)
+	disputeID := openDispute(t, router, taskID)
+	resolve := "/moderation/disputes/" + disputeID + "/resolve/moderator@example.com"
+
+	w, _ := performJSON(router, http.MethodPost, resolve, gin.H{"outcome": "partial"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "A partial ruling needs the worker's share")
+
+	w, _ = performJSON(router, http.MethodPost, resolve, gin.H{"outcome": "partial", "amount_cents": 1000, "fraction": 0.25})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "The share is either an amount or a fraction")
+
+	w, _ = performJSON(router, http.MethodPost, resolve, gin.H{"outcome": "partial", "amount_cents": 4001})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "The worker can't be paid more than is held")
+
+	w, _ = performJSON(router, http.MethodPost, resolve, gin.H{"outcome": "partial", "fraction": 1.5})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "A fraction can't be over one")
+
+	w, _ = performJSON(router, http.MethodPost, resolve, gin.H{"outcome": "complete", "amount_cents": 1000})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Only partial rulings take a share")
+
+	w, response := performJSON(router, http.MethodPost, resolve, gin.H{"outcome": "partial", "fraction": 0.25})
+	assert.Equal(t, http.StatusOK, w.Code, "Resolving should succeed: %s", w.Body.String())
+	assert.Equal(t, float64(1000), response["paid_cents"])
+
+	assert.Equal(t, models.Completed, findAssignment(t, db, taskID).Status)
+
+	paid, refunded := escrowSettlement(t, db, taskID)
+	assert.Equal(t, int64(1000), paid, "The worker should be paid a quarter of the escrow")
+	assert.Equal(t, int64(3000), refunded, "The poster should get the rest back")
+
+	worker, poster := findUser(t, db, "worker@example.com"), findUser(t, db, "poster@example.com")
+	assert.Equal(t, 1, worker.CompletedTasks)
+	assert.Zero(t, worker.DisputesWon+worker.DisputesLost+poster.DisputesWon+poster.DisputesLost, "Partial rulings don't count for either side")
+}
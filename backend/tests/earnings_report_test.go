@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test naming the month and UF semester a completion falls in
+func TestReportPeriodLabel(t *testing.T) {
+	cases := []struct {
+		date     string
+		month    string
+		semester string
+		start    string
+	}{
+		{"2025-01-15", "2025-01", "Spring 2025", "2025-01-01"},
+		{"2025-04-30", "2025-04", "Spring 2025", "2025-01-01"},
+		{"2025-05-01", "2025-05", "Summer 2025", "2025-05-01"},
+		{"2025-07-31", "2025-07", "Summer 2025", "2025-05-01"},
+		{"2025-08-20", "2025-08", "Fall 2025", "2025-08-01"},
+		{"2025-12-12", "2025-12", "Fall 2025", "2025-08-01"},
+	}
+
+	for _, tc := range cases {
+		date, _ := time.Parse("2006-01-02", tc.date)
+		assert.Equal(t, tc.month, utils.ReportPeriodLabel(date, utils.ReportPeriodMonth), "Unexpected month for %s", tc.date)
+		assert.Equal(t, tc.semester, utils.ReportPeriodLabel(date, utils.ReportPeriodSemester), "Unexpected semester for %s", tc.date)
+		assert.Equal(t, tc.start, utils.ReportPeriodStart(date, utils.ReportPeriodSemester).Format("2006-01-02"), "Unexpected semester start for %s", tc.date)
+	}
+}
+
+// Test that the PDF report is a well-formed document that pages long reports
+func TestBuildTextPDF(t *testing.T) {
+	lines := []string{}
+	for i := 0; i < 120; i++ {
+		lines = append(lines, "Cleaning: $20.00 (paid)")
+	}
+
+	pdf := utils.BuildTextPDF("Earnings", lines)
+
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")), "PDF should start with a header")
+	assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")), "PDF should end with an EOF marker")
+	assert.Contains(t, string(pdf), "/Count 3", "122 lines should need three pages")
+	assert.Contains(t, string(pdf), `(Cleaning: $20.00 \(paid\)) Tj`, "Parentheses should be escaped")
+
+	// The xref table must point at the objects it lists
+	body := string(pdf)
+	offset := strings.Index(body, "1 0 obj")
+	assert.Contains(t, body, "0000000009 00000 n", "First object should follow the 9 byte header")
+	assert.Equal(t, 9, offset, "First object should start right after the header")
+}
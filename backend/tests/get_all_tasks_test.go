@@ -3,6 +3,7 @@ package tests
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
@@ -100,6 +101,12 @@ func (gt *GetAllTasksTest) mockGetAllTasksForUser(c *gin.Context) {
 	// Don't show tasks that the user has already applied for
 	filter["applicants"] = bson.M{"$nin": []string{viewerEmail}}
 
+	// Don't show tasks hidden pending moderation review
+	filter["hidden"] = bson.M{"$ne": true}
+
+	// Don't show tasks that have already started
+	filter["start_at"] = bson.M{"$gt": time.Now()}
+
 	// Filter by category if provided
 	if category := c.Query("category"); category != "" {
 		filter["work_type"] = category
@@ -207,14 +214,14 @@ func (gt *GetAllTasksTest) seedTestData(t *testing.T) {
 	now := time.Now()
 	gt.taskIDs = make([]primitive.ObjectID, 7)
 
-	// Task dates
-	date1, _ := time.Parse("2006-01-02", "2025-04-10")
-	date2, _ := time.Parse("2006-01-02", "2025-04-15")
-	date3, _ := time.Parse("2006-01-02", "2025-04-20")
-	date4, _ := time.Parse("2006-01-02", "2025-04-25")
-	date5, _ := time.Parse("2006-01-02", "2025-04-30")
-	date6, _ := time.Parse("2006-01-02", "2025-05-05")
-	date7, _ := time.Parse("2006-01-02", "2025-05-10")
+	// Task dates, all in the future so none of them have started yet
+	date1 := testTaskDate(10)
+	date2 := testTaskDate(15)
+	date3 := testTaskDate(20)
+	date4 := testTaskDate(25)
+	date5 := testTaskDate(30)
+	date6 := testTaskDate(35)
+	date7 := testTaskDate(40)
 
 	// Create tasks with different attributes
 	tasks := []interface{}{
@@ -224,6 +231,7 @@ func (gt *GetAllTasksTest) seedTestData(t *testing.T) {
 			Description:      "Fix a leaky faucet",
 			TaskTime:         "10:00",
 			TaskDate:         date1,
+			StartAt:          date1.Add(12 * time.Hour),
 			EstimatedPayRate: 25.50,
 			PlaceOfWork:      "Apartment",
 			WorkType:         models.Plumbing,
@@ -242,6 +250,7 @@ func (gt *GetAllTasksTest) seedTestData(t *testing.T) {
 			Description:      "Clean the house",
 			TaskTime:         "14:00",
 			TaskDate:         date2,
+			StartAt:          date2.Add(12 * time.Hour),
 			EstimatedPayRate: 20.00,
 			PlaceOfWork:      "House",
 			WorkType:         models.Cleaning,
@@ -260,6 +269,7 @@ func (gt *GetAllTasksTest) seedTestData(t *testing.T) {
 			Description:      "Help with math homework",
 			TaskTime:         "16:00",
 			TaskDate:         date3,
+			StartAt:          date3.Add(12 * time.Hour),
 			EstimatedPayRate: 30.00,
 			PlaceOfWork:      "Library",
 			WorkType:         models.Tutoring,
@@ -278,6 +288,7 @@ func (gt *GetAllTasksTest) seedTestData(t *testing.T) {
 			Description:      "Paint a room",
 			TaskTime:         "09:00",
 			TaskDate:         date4,
+			StartAt:          date4.Add(12 * time.Hour),
 			EstimatedPayRate: 35.00,
 			PlaceOfWork:      "House",
 			WorkType:         models.Painting,
@@ -296,6 +307,7 @@ func (gt *GetAllTasksTest) seedTestData(t *testing.T) {
 			Description:      "Mow the lawn and trim hedges",
 			TaskTime:         "08:00",
 			TaskDate:         date5,
+			StartAt:          date5.Add(12 * time.Hour),
 			EstimatedPayRate: 25.00,
 			PlaceOfWork:      "House",
 			WorkType:         models.Gardening,
@@ -314,6 +326,7 @@ func (gt *GetAllTasksTest) seedTestData(t *testing.T) {
 			Description:      "This task is already closed",
 			TaskTime:         "13:00",
 			TaskDate:         date6,
+			StartAt:          date6.Add(12 * time.Hour),
 			EstimatedPayRate: 40.00,
 			PlaceOfWork:      "Office",
 			WorkType:         models.ComputerHelp,
@@ -332,6 +345,7 @@ func (gt *GetAllTasksTest) seedTestData(t *testing.T) {
 			Description:      "Fix wiring in the kitchen",
 			TaskTime:         "11:00",
 			TaskDate:         date7,
+			StartAt:          date7.Add(12 * time.Hour),
 			EstimatedPayRate: 45.00,
 			PlaceOfWork:      "House",
 			WorkType:         models.Electrical,
@@ -358,6 +372,12 @@ func (gt *GetAllTasksTest) seedTestData(t *testing.T) {
 	}
 }
 
+// testTaskDate returns the calendar date the given number of days from today, as midnight UTC
+func testTaskDate(days int) time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, days)
+}
+
 // Test retrieving all available tasks for a user
 func TestGetAllTasksForUser(t *testing.T) {
 	// Initialize test environment
@@ -482,7 +502,10 @@ func TestGetAllTasksFilteredByDateRange(t *testing.T) {
 	defer gt.Cleanup(t)
 
 	// Create a request to get tasks filtered by date range
-	req, _ := http.NewRequest("GET", "/tasks/available/viewer@example.com?from_date=2025-04-15&to_date=2025-04-25", nil)
+	fromDate := testTaskDate(15)
+	toDate := testTaskDate(25)
+	url := fmt.Sprintf("/tasks/available/viewer@example.com?from_date=%s&to_date=%s", fromDate.Format("2006-01-02"), toDate.Format("2006-01-02"))
+	req, _ := http.NewRequest("GET", url, nil)
 
 	w := httptest.NewRecorder()
 	gt.router.ServeHTTP(w, req)
@@ -504,10 +527,6 @@ func TestGetAllTasksFilteredByDateRange(t *testing.T) {
 		taskDate := task.TaskDate
 
 		// Check that all tasks fall within the requested date range
-		// Date should be >= 2025-04-15 and <= 2025-04-25
-		fromDate, _ := time.Parse("2006-01-02", "2025-04-15")
-		toDate, _ := time.Parse("2006-01-02", "2025-04-25")
-
 		assert.True(t, !taskDate.Before(fromDate), "Task date should be on or after from_date")
 		assert.True(t, !taskDate.After(toDate), "Task date should be on or before to_date")
 	}
@@ -562,3 +581,82 @@ func TestViewCountIncremented(t *testing.T) {
 		}
 	}
 }
+
+// Test that tasks hidden by moderation are excluded from the feed
+func TestGetAllTasksExcludesHiddenTasks(t *testing.T) {
+	// Initialize test environment
+	gt := &GetAllTasksTest{}
+	gt.Initialize(t)
+	defer gt.Cleanup(t)
+
+	// Hide the plumbing task as if it had crossed the report threshold
+	_, err := gt.db.Collection("tasks").UpdateOne(
+		gt.ctx,
+		bson.M{"_id": gt.taskIDs[0]},
+		bson.M{"$set": bson.M{"hidden": true}},
+	)
+	assert.NoError(t, err, "Failed to hide task")
+
+	req, _ := http.NewRequest("GET", "/tasks/available/viewer@example.com", nil)
+
+	w := httptest.NewRecorder()
+	gt.router.ServeHTTP(w, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status 200 OK for task retrieval")
+
+	var response struct {
+		Tasks []models.Task `json:"tasks"`
+		Count int           `json:"count"`
+	}
+
+	err = json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err, "Failed to parse response body")
+
+	// One fewer than the 4 visible tasks
+	assert.Equal(t, 3, response.Count, "Expected hidden task to be excluded")
+
+	for _, task := range response.Tasks {
+		assert.NotEqual(t, gt.taskIDs[0], task.ID, "Hidden task should not be in the feed")
+	}
+}
+
+// Test that open tasks whose start has passed are excluded before the expiry job runs
+func TestGetAllTasksExcludesStartedTasks(t *testing.T) {
+	// Initialize test environment
+	gt := &GetAllTasksTest{}
+	gt.Initialize(t)
+	defer gt.Cleanup(t)
+
+	// Move the plumbing task into the past while leaving it Open
+	yesterday := testTaskDate(-1)
+	_, err := gt.db.Collection("tasks").UpdateOne(
+		gt.ctx,
+		bson.M{"_id": gt.taskIDs[0]},
+		bson.M{"$set": bson.M{"task_date": yesterday, "start_at": yesterday.Add(12 * time.Hour)}},
+	)
+	assert.NoError(t, err, "Failed to move task into the past")
+
+	req, _ := http.NewRequest("GET", "/tasks/available/viewer@example.com", nil)
+
+	w := httptest.NewRecorder()
+	gt.router.ServeHTTP(w, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status 200 OK for task retrieval")
+
+	var response struct {
+		Tasks []models.Task `json:"tasks"`
+		Count int           `json:"count"`
+	}
+
+	err = json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err, "Failed to parse response body")
+
+	// One fewer than the 4 visible tasks
+	assert.Equal(t, 3, response.Count, "Expected started task to be excluded")
+
+	for _, task := range response.Tasks {
+		assert.NotEqual(t, gt.taskIDs[0], task.ID, "Started task should not be in the feed")
+	}
+}
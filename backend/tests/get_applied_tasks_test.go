@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"testing"
 	"time"
+	"ufpeerassist/backend/api/utils"
 	"ufpeerassist/backend/models"
 
 	"github.com/gin-gonic/gin"
@@ -114,6 +115,7 @@ func (gat *GetAppliedTasksTest) mockGetAppliedTasks(c *gin.Context) {
 
 	// Get task creators info to include with each task
 	var tasksWithCreatorInfo []gin.H
+	now := time.Now()
 	for _, task := range appliedTasks {
 		var creator models.Users
 		err := gat.db.Collection("users").FindOne(
@@ -128,9 +130,17 @@ func (gat *GetAppliedTasksTest) mockGetAppliedTasks(c *gin.Context) {
 			"mobile": "",
 		}
 
+		// Contact details are only shared with selected workers while the task is active
+		contactVisible := task.ContactWindowOpen(viewerEmail, now)
 		if err == nil {
 			creatorInfo["name"] = creator.Name
-			creatorInfo["mobile"] = creator.Mobile
+			if contactVisible && !creator.HideMobile {
+				creatorInfo["mobile"] = creator.Mobile
+			}
+		}
+		if !task.ShowsExactLocationTo(viewerEmail, now) {
+			task.PlaceOfWork = utils.ApproximateLocation(task.PlaceOfWork)
+			task.LocationApproximate = true
 		}
 
 		// Create a sanitized task object without other applicants' information
@@ -143,6 +153,7 @@ func (gat *GetAppliedTasksTest) mockGetAppliedTasks(c *gin.Context) {
 			"task_date":          task.TaskDate,
 			"estimated_pay_rate": task.EstimatedPayRate,
 			"place_of_work":      task.PlaceOfWork,
+			"location_exact":     !task.LocationApproximate,
 			"work_type":          task.WorkType,
 			"people_needed":      task.PeopleNeeded,
 			"creator_email":      task.CreatorEmail,
@@ -159,7 +170,8 @@ func (gat *GetAppliedTasksTest) mockGetAppliedTasks(c *gin.Context) {
 			"task":    sanitizedTask,
 			"creator": creatorInfo,
 			// Include status information for the application
-			"selected": contains(task.SelectedUsers, viewerEmail),
+			"selected":        contains(task.SelectedUsers, viewerEmail),
+			"contact_visible": contactVisible,
 		})
 	}
 
@@ -493,3 +505,41 @@ func TestGetAppliedTasksSelectedStatus(t *testing.T) {
 	assert.True(t, selectedFound, "At least one task should have user selected")
 	assert.True(t, notSelectedFound, "At least one task should have user not selected")
 }
+
+// Test that the poster's mobile number only reaches workers selected for an active task
+func TestGetAppliedTasksContactPrivacy(t *testing.T) {
+	// Initialize test environment
+	gat := &GetAppliedTasksTest{}
+	gat.Initialize(t)
+	defer gat.Cleanup(t)
+
+	req, _ := http.NewRequest("GET", "/tasks/applied/applicant@example.com", nil)
+
+	w := httptest.NewRecorder()
+	gat.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status 200 OK for getting applied tasks")
+
+	var response struct {
+		AppliedTasks []map[string]interface{} `json:"applied_tasks"`
+	}
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err, "Failed to parse response body")
+
+	for _, taskInfo := range response.AppliedTasks {
+		task := taskInfo["task"].(map[string]interface{})
+		creator := taskInfo["creator"].(map[string]interface{})
+
+		if task["title"] == "Applied and Selected Task" {
+			// Selected on a task in progress: full contact details
+			assert.Equal(t, "5551234567", creator["mobile"], "Selected worker should see the poster's mobile")
+			assert.Equal(t, true, taskInfo["contact_visible"])
+			assert.Equal(t, true, task["location_exact"])
+		} else {
+			// Not selected, or the task is over: no mobile number
+			assert.Empty(t, creator["mobile"], "Mobile should be hidden for %v", task["title"])
+			assert.Equal(t, false, taskInfo["contact_visible"])
+			assert.Equal(t, false, task["location_exact"])
+		}
+	}
+}
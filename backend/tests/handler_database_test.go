@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"ufpeerassist/backend/api/handlers"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// handlerTestMongoURI is a replica set, since several handlers use transactions
+const handlerTestMongoURI = "mongodb://localhost:27017/?replicaSet=rs0"
+
+// setupHandlerDatabase points the real handlers at a scratch database that is dropped when the test ends
+func setupHandlerDatabase(t *testing.T, name string) *mongo.Database {
+	gin.SetMode(gin.TestMode)
+
+	db, err := handlers.InitTestDatabase(handlerTestMongoURI, name)
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := db.Drop(context.Background()); err != nil {
+			t.Logf("Warning: Failed to drop test database: %v", err)
+		}
+		if err := db.Client().Disconnect(context.Background()); err != nil {
+			t.Logf("Warning: Failed to disconnect from MongoDB: %v", err)
+		}
+	})
+	return db
+}
+
+// performJSON sends a request with an optional JSON body and decodes the JSON response
+func performJSON(router *gin.Engine, method, path string, body interface{}) (*httptest.ResponseRecorder, gin.H) {
+	var reader *bytes.Reader
+	if body != nil {
+		payload, _ := json.Marshal(body)
+		reader = bytes.NewReader(payload)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, _ := http.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	response := gin.H{}
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
+// seedUsers inserts users into the test database
+func seedUsers(t *testing.T, db *mongo.Database, users ...models.Users) {
+	for _, user := range users {
+		if _, err := db.Collection("users").InsertOne(context.Background(), user); err != nil {
+			t.Fatalf("Failed to seed user %s: %v", user.Email, err)
+		}
+	}
+}
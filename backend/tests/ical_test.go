@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test rendering scheduled and cancelled tasks as an iCalendar document
+func TestBuildICalendar(t *testing.T) {
+	start := time.Date(2025, 4, 20, 18, 0, 0, 0, time.UTC)
+
+	ics := utils.BuildICalendar("UFPeerAssist tasks", []utils.CalendarEvent{
+		{
+			UID:            "task1@ufpeerassist",
+			Summary:        "Work: Fix a leaky faucet",
+			Description:    "Bring a wrench, please.\nPay: $25.00/hour",
+			Location:       "Apartment 4B, Gainesville",
+			Start:          start,
+			End:            start.Add(time.Hour),
+			Sequence:       2,
+			OrganizerName:  "Creator User",
+			OrganizerEmail: "creator@example.com",
+		},
+		{
+			UID:       "task2@ufpeerassist",
+			Summary:   "Cancelled Task",
+			Start:     start,
+			End:       start.Add(30 * time.Minute),
+			Cancelled: true,
+		},
+	})
+
+	// Every line must end in CRLF
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 2, strings.Count(ics, "BEGIN:VEVENT"))
+
+	assert.Contains(t, ics, "DTSTART:20250420T180000Z\r\n")
+	assert.Contains(t, ics, "DTEND:20250420T190000Z\r\n")
+	assert.Contains(t, ics, "LOCATION:Apartment 4B\\, Gainesville\r\n")
+	assert.Contains(t, ics, "DESCRIPTION:Bring a wrench\\, please.\\nPay: $25.00/hour\r\n")
+	assert.Contains(t, ics, "ORGANIZER;CN=\"Creator User\":mailto:creator@example.com\r\n")
+	assert.Contains(t, ics, "SEQUENCE:2\r\n")
+	assert.Contains(t, ics, "STATUS:CONFIRMED\r\n")
+	assert.Contains(t, ics, "STATUS:CANCELLED\r\n")
+}
+
+// Test that long lines are folded to at most 75 octets
+func TestBuildICalendarFoldsLongLines(t *testing.T) {
+	start := time.Date(2025, 4, 20, 18, 0, 0, 0, time.UTC)
+
+	ics := utils.BuildICalendar("Tasks", []utils.CalendarEvent{
+		{
+			UID:         "task1@ufpeerassist",
+			Summary:     "Long",
+			Description: strings.Repeat("Help me move my couch and boxes é ", 10),
+			Start:       start,
+			End:         start.Add(time.Hour),
+		},
+	})
+
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, "Line too long: %q", line)
+	}
+
+	// Unfolding gives back the original value
+	unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+	assert.Contains(t, unfolded, "DESCRIPTION:"+strings.Repeat("Help me move my couch and boxes é ", 10))
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/handlers"
+	"ufpeerassist/backend/api/payments"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Test that a release which pays the worker less than was held refunds the rest and stays balanced
+func TestLedgerReleaseBalances(t *testing.T) {
+	taskID := primitive.NewObjectID()
+	escrow := models.EscrowAccount(taskID, "worker@example.com")
+	wallet := models.WalletAccount("worker@example.com")
+
+	release := models.LedgerTransaction{
+		Kind:        models.LedgerEscrowRelease,
+		PosterEmail: "poster@example.com",
+		WorkerEmail: "worker@example.com",
+		Postings: []models.LedgerPosting{
+			{Account: escrow, AmountCents: -3000},
+			{Account: wallet, AmountCents: 2500},
+			{Account: models.ExternalAccount, AmountCents: 500},
+		},
+	}
+
+	assert.True(t, release.IsBalanced(), "Release postings should sum to zero")
+	assert.Equal(t, int64(-3000), release.AmountFor(escrow), "Escrow should be emptied")
+	assert.Equal(t, int64(2500), release.AmountFor(wallet), "Worker should be paid what is due")
+	assert.Equal(t, int64(500), release.AmountFor(models.ExternalAccount), "Poster should get the rest back")
+}
+
+// Test that postings creating money out of nothing are detected
+func TestLedgerUnbalancedTransaction(t *testing.T) {
+	transaction := models.LedgerTransaction{
+		Postings: []models.LedgerPosting{
+			{Account: models.ExternalAccount, AmountCents: -1000},
+			{Account: models.WalletAccount("worker@example.com"), AmountCents: 1200},
+		},
+	}
+
+	assert.False(t, transaction.IsBalanced(), "Postings that don't sum to zero should be unbalanced")
+}
+
+// Test that the fake provider tracks charges, refunds and payouts per user
+func TestFakePaymentProvider(t *testing.T) {
+	provider := payments.NewFakeProvider()
+
+	ref, err := provider.Charge("poster@example.com", 3000, "hold:1")
+	assert.NoError(t, err, "Charge should succeed")
+	assert.NotEmpty(t, ref, "Charge should return a reference")
+
+	_, err = provider.Refund("poster@example.com", 500, "settle:1")
+	assert.NoError(t, err, "Refund should succeed")
+	assert.Equal(t, int64(2500), provider.Charged("poster@example.com"), "Net charge should account for the refund")
+
+	_, err = provider.Payout("worker@example.com", 2500, "payout:1")
+	assert.NoError(t, err, "Payout should succeed")
+	assert.Equal(t, int64(2500), provider.PaidOut("worker@example.com"), "Payout should be recorded")
+
+	_, err = provider.Charge("poster@example.com", 0, "hold:2")
+	assert.ErrorIs(t, err, payments.ErrInvalidAmount, "Zero charges should be rejected")
+}
+
+// Test that two payouts of the whole balance at once can't take the wallet negative
+func TestConcurrentPayouts(t *testing.T) {
+	db := setupHandlerDatabase(t, "ufpeerassist_test_payouts")
+	worker := "worker@example.com"
+
+	seedUsers(t, db, models.Users{Name: "Worker", Email: worker})
+
+	// The worker has earned $50
+	taskID := primitive.NewObjectID()
+	_, err := db.Collection("ledger").InsertOne(context.Background(), models.LedgerTransaction{
+		Kind:           models.LedgerEscrowRelease,
+		IdempotencyKey: "settle:test",
+		TaskID:         &taskID,
+		PosterEmail:    "poster@example.com",
+		WorkerEmail:    worker,
+		Postings: []models.LedgerPosting{
+			{Account: models.EscrowAccount(taskID, worker), AmountCents: -5000},
+			{Account: models.WalletAccount(worker), AmountCents: 5000},
+		},
+		CreatedAt: time.Now(),
+	})
+	assert.NoError(t, err, "Seeding the worker's earnings should succeed")
+
+	router := gin.New()
+	router.POST("/users/:email/payout", handlers.RequestPayout)
+	router.GET("/users/:email/balance", handlers.GetBalance)
+
+	// Both requests withdraw everything
+	start := make(chan struct{})
+	codes := make([]int, 2)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			w, _ := performJSON(router, http.MethodPost, "/users/"+worker+"/payout", nil)
+			codes[i] = w.Code
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded := 0
+	for _, code := range codes {
+		if code == http.StatusOK {
+			succeeded++
+		} else {
+			assert.Contains(t, []int{http.StatusBadRequest, http.StatusConflict}, code, "The losing payout should be refused")
+		}
+	}
+	assert.Equal(t, 1, succeeded, "Exactly one payout should go through")
+
+	payouts, err := db.Collection("ledger").CountDocuments(context.Background(), bson.M{"kind": models.LedgerPayout})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), payouts, "Only one payout should be recorded")
+
+	_, balance := performJSON(router, http.MethodGet, "/users/"+worker+"/balance", nil)
+	assert.Equal(t, float64(0), balance["available_cents"], "The wallet should be empty, not negative")
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/handlers"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/net/websocket"
+)
+
+// setupMessagingTest seeds a poster, an applicant and a stranger on an open task, served over a real listener
+func setupMessagingTest(t *testing.T) (*httptest.Server, *gin.Engine, primitive.ObjectID) {
+	db := setupHandlerDatabase(t, "ufpeerassist_test_messaging")
+
+	seedUsers(t, db,
+		models.Users{Name: "Poster", Email: "poster@example.com"},
+		models.Users{Name: "Applicant", Email: "applicant@example.com"},
+		models.Users{Name: "Stranger", Email: "stranger@example.com"},
+	)
+
+	task := models.Task{
+		ID:           primitive.NewObjectID(),
+		Title:        "Assemble a desk",
+		PeopleNeeded: 1,
+		CreatorEmail: "poster@example.com",
+		Status:       models.Open,
+		Applicants:   []string{"applicant@example.com"},
+		Version:      1,
+	}
+	_, err := db.Collection("tasks").InsertOne(context.Background(), task)
+	assert.NoError(t, err, "Seeding the task should succeed")
+
+	router := gin.New()
+	router.POST("/tasks/:task_id/messages/:email", handlers.SendMessage)
+	router.GET("/tasks/:task_id/messages/:email", handlers.GetMessages)
+	router.GET("/ws/messages/:email", handlers.MessageSocket)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server, router, task.ID
+}
+
+// dialMessages opens the live message socket for a user from the given page origin
+func dialMessages(server *httptest.Server, email, origin string) (*websocket.Conn, error) {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/messages/" + email
+	return websocket.Dial(url, "", origin)
+}
+
+// Test that only the frontend's origin may open the live message socket
+func TestMessageSocketChecksOrigin(t *testing.T) {
+	server, _, _ := setupMessagingTest(t)
+
+	_, err := dialMessages(server, "applicant@example.com", "http://evil.example.com")
+	assert.Error(t, err, "A page from another site shouldn't get the user's messages")
+
+	conn, err := dialMessages(server, "applicant@example.com", handlers.AllowedOrigins[0])
+	if assert.NoError(t, err, "The frontend should be able to connect") {
+		conn.Close()
+	}
+}
+
+// Test that a sent message is stored, pushed live to the recipient and kept from outsiders
+func TestSendMessageDeliversLive(t *testing.T) {
+	server, router, taskID := setupMessagingTest(t)
+
+	conn, err := dialMessages(server, "poster@example.com", handlers.AllowedOrigins[0])
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	// The socket is registered just after the handshake, so retry until a message gets through
+	var event struct {
+		Type    string         `json:"type"`
+		Message models.Message `json:"message"`
+	}
+	received := false
+	for attempt := 0; attempt < 10 && !received; attempt++ {
+		w, _ := performJSON(router, http.MethodPost, "/tasks/"+taskID.Hex()+"/messages/applicant@example.com", gin.H{"body": "Is Saturday fine?"})
+		assert.Equal(t, http.StatusCreated, w.Code, "Sending should succeed: %s", w.Body.String())
+
+		conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		received = websocket.JSON.Receive(conn, &event) == nil
+	}
+	if assert.True(t, received, "The poster should get the message live") {
+		assert.Equal(t, "message", event.Type)
+		assert.Equal(t, "Is Saturday fine?", event.Message.Body)
+		assert.Equal(t, "applicant@example.com", event.Message.SenderEmail)
+	}
+
+	w, response := performJSON(router, http.MethodGet, "/tasks/"+taskID.Hex()+"/messages/poster@example.com?with=applicant@example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotZero(t, response["count"], "The message should be stored for the thread")
+
+	w, _ = performJSON(router, http.MethodPost, "/tasks/"+taskID.Hex()+"/messages/stranger@example.com", gin.H{"body": "Hello"})
+	assert.Equal(t, http.StatusForbidden, w.Code, "Only applicants and workers can write to the poster")
+
+	w, _ = performJSON(router, http.MethodPost, "/tasks/"+taskID.Hex()+"/messages/poster@example.com", gin.H{"body": "Yes"})
+	assert.Equal(t, http.StatusBadRequest, w.Code, "The poster has to say who they're writing to")
+}
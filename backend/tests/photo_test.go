@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+	"ufpeerassist/backend/api/storage"
+	"ufpeerassist/backend/api/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testJPEGWithExif encodes a w x h JPEG and inserts an EXIF block with the given orientation
+func testJPEGWithExif(t *testing.T, w, h int, orientation uint16) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+
+	var encoded bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&encoded, img, nil))
+
+	// Little-endian TIFF header with one IFD entry: orientation
+	tiff := []byte("II*\x00\x08\x00\x00\x00\x01\x00")
+	entry := make([]byte, 12)
+	binary.LittleEndian.PutUint16(entry[0:], 0x0112)
+	binary.LittleEndian.PutUint16(entry[2:], 3) // SHORT
+	binary.LittleEndian.PutUint32(entry[4:], 1)
+	binary.LittleEndian.PutUint16(entry[8:], orientation)
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	data := encoded.Bytes()
+	return append(append([]byte{0xFF, 0xD8}, app1...), data[2:]...)
+}
+
+// Test that photos are re-encoded without EXIF, turned upright and thumbnailed
+func TestProcessPhotoStripsExif(t *testing.T) {
+	data := testJPEGWithExif(t, 800, 400, 6) // Rotated 90 degrees clockwise
+	assert.True(t, bytes.Contains(data, []byte("Exif")))
+
+	photo, err := utils.ProcessPhoto(data)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "image/jpeg", photo.ContentType)
+	assert.False(t, bytes.Contains(photo.Data, []byte("Exif")), "EXIF should be stripped")
+	assert.Equal(t, 400, photo.Width, "Orientation 6 should swap width and height")
+	assert.Equal(t, 800, photo.Height)
+
+	thumb, _, err := image.DecodeConfig(bytes.NewReader(photo.Thumbnail))
+	assert.NoError(t, err)
+	assert.Equal(t, 160, thumb.Width)
+	assert.Equal(t, utils.ThumbnailMaxSide, thumb.Height)
+}
+
+// Test that the content type is sniffed rather than trusted
+func TestProcessPhotoRejectsNonImages(t *testing.T) {
+	_, err := utils.ProcessPhoto([]byte("<html><body>not a photo</body></html>"))
+	assert.ErrorIs(t, err, utils.ErrUnsupportedPhotoType)
+
+	_, err = utils.ProcessPhoto(make([]byte, utils.MaxPhotoBytes+1))
+	assert.ErrorIs(t, err, utils.ErrPhotoTooLarge)
+
+	// A PNG header claiming enormous dimensions is refused before decoding
+	var small bytes.Buffer
+	assert.NoError(t, png.Encode(&small, image.NewRGBA(image.Rect(0, 0, 1, 1))))
+	bomb := bytes.Clone(small.Bytes())
+	binary.BigEndian.PutUint32(bomb[16:], 100000)
+	binary.BigEndian.PutUint32(bomb[20:], 100000)
+	binary.BigEndian.PutUint32(bomb[29:], crc32.ChecksumIEEE(bomb[12:29])) // Fix up the IHDR checksum
+	_, err = utils.ProcessPhoto(bomb)
+	assert.ErrorIs(t, err, utils.ErrPhotoDimensions)
+
+	// Small PNGs are kept as PNGs and not scaled up for the thumbnail
+	photo, err := utils.ProcessPhoto(small.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", photo.ContentType)
+	assert.Equal(t, 1, photo.Width)
+}
+
+// Test storing, reading and deleting blobs on the local filesystem
+func TestLocalStore(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.Put("tasks/abc/photo.jpg", bytes.NewReader([]byte("jpeg bytes"))))
+
+	blob, err := store.Get("tasks/abc/photo.jpg")
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(blob)
+	blob.Close()
+	assert.Equal(t, "jpeg bytes", string(content))
+
+	assert.NoError(t, store.Delete("tasks/abc/photo.jpg"))
+	assert.NoError(t, store.Delete("tasks/abc/photo.jpg"), "Deleting twice should not fail")
+
+	_, err = store.Get("tasks/abc/photo.jpg")
+	assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+
+	// Keys can't escape the root
+	for _, key := range []string{"../secret", "/etc/passwd", "tasks//x", ""} {
+		assert.ErrorIs(t, store.Put(key, bytes.NewReader(nil)), storage.ErrInvalidKey, "Key %q should be refused", key)
+	}
+}
@@ -0,0 +1,20 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"ufpeerassist/backend/api/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test pinning answers into a task description
+func TestAppendPinnedAnswer(t *testing.T) {
+	description := utils.AppendPinnedAnswer("Help me move a couch.\n", "Is there parking?", "Yes, in the garage.")
+	assert.Equal(t, "Help me move a couch.\n\nQuestions & answers:\n\nQ: Is there parking?\nA: Yes, in the garage.", description)
+
+	// A second answer joins the same section, and answers don't need a question
+	description = utils.AppendPinnedAnswer(description, "", "Bring gloves.")
+	assert.Equal(t, 1, strings.Count(description, utils.PinnedAnswersHeading), "Heading should only appear once")
+	assert.Contains(t, description, "A: Yes, in the garage.\n\nA: Bring gloves.")
+}
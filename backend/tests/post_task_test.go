@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 	"ufpeerassist/backend/models"
@@ -57,8 +59,8 @@ func (pt *PostTaskTest) Initialize(t *testing.T) {
 
 	// Initializing router with mock handler
 	pt.router = gin.Default()
-	pt.router.POST("/tasks/:email", pt.mockPostATask)
-	pt.router.PUT("/tasks/:email", pt.mockPostATask)
+	pt.router.POST("/tasks", pt.mockCreateTask)
+	pt.router.PATCH("/tasks/:task_id", pt.mockUpdateTask)
 }
 
 // Cleanup tears down the test environment. this runs after eah fucntion
@@ -79,22 +81,11 @@ func (pt *PostTaskTest) Cleanup(t *testing.T) {
 	pt.cancelCtx()
 }
 
-// Mock PostATask handler
-func (pt *PostTaskTest) mockPostATask(c *gin.Context) {
-	// Get email from URL parameter
-	email := c.Param("email")
-
-	// Check if user exists
-	var user models.Users
-	err := pt.db.Collection("users").FindOne(pt.ctx, bson.M{"email": email}).Decode(&user)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		return
-	}
-
+// Mock CreateTask handler
+func (pt *PostTaskTest) mockCreateTask(c *gin.Context) {
 	// Define the input structure
 	var input struct {
-		ID               string  `json:"id"`
+		CreatorEmail     string  `json:"creator_email" binding:"required,email"`
 		Title            string  `json:"title" binding:"required"`
 		Description      string  `json:"description" binding:"required"`
 		TaskTime         string  `json:"task_time" binding:"required"`
@@ -110,6 +101,14 @@ func (pt *PostTaskTest) mockPostATask(c *gin.Context) {
 		return
 	}
 
+	// Check if user exists
+	var user models.Users
+	err := pt.db.Collection("users").FindOne(pt.ctx, bson.M{"email": input.CreatorEmail}).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
 	// Parse the task date
 	taskDate, err := time.Parse("2006-01-02", input.TaskDate)
 	if err != nil {
@@ -118,140 +117,205 @@ func (pt *PostTaskTest) mockPostATask(c *gin.Context) {
 	}
 
 	// Check if work type is valid
-	validWorkType := false
-	for _, category := range []models.TaskCategory{
-		models.Plumbing,
-		models.HouseShifting,
-		models.Carpentry,
-		models.Cleaning,
-		models.Electrical,
-		models.Painting,
-		models.Gardening,
-		models.Tutoring,
-		models.ComputerHelp,
-		models.Other,
-	} {
-		if string(category) == input.WorkType {
-			validWorkType = true
-			break
-		}
-	}
-
-	if !validWorkType {
+	if !isValidTestWorkType(input.WorkType) {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid work type",
-			"valid_types": []string{
-				string(models.Plumbing),
-				string(models.HouseShifting),
-				string(models.Carpentry),
-				string(models.Cleaning),
-				string(models.Electrical),
-				string(models.Painting),
-				string(models.Gardening),
-				string(models.Tutoring),
-				string(models.ComputerHelp),
-				string(models.Other),
-			},
+			"error":       "Invalid work type",
+			"valid_types": models.ValidTaskCategories(),
 		})
 		return
 	}
 
 	now := time.Now()
 
-	// Check if we're updating an existing task or creating a new one
-	if input.ID != "" {
-		// Convert string ID to ObjectID
-		objectID, err := primitive.ObjectIDFromHex(input.ID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
-			return
-		}
+	// Create a new task
+	newTask := models.Task{
+		ID:               primitive.NewObjectID(),
+		Title:            input.Title,
+		Description:      input.Description,
+		TaskTime:         input.TaskTime,
+		TaskDate:         taskDate,
+		EstimatedPayRate: input.EstimatedPayRate,
+		PlaceOfWork:      input.PlaceOfWork,
+		WorkType:         models.TaskCategory(input.WorkType),
+		PeopleNeeded:     input.PeopleNeeded,
+
+		// Meta data
+		CreatorEmail:  input.CreatorEmail,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Status:        models.Open,
+		Views:         0,
+		Applicants:    []string{},
+		SelectedUsers: []string{},
+		Version:       1,
+	}
 
-		// Find the existing task
-		var existingTask models.Task
-		err = pt.db.Collection("tasks").FindOne(
-			pt.ctx,
-			bson.M{
-				"_id":           objectID,
-				"creator_email": email,
-			},
-		).Decode(&existingTask)
+	// Insert the task
+	result, err := pt.db.Collection("tasks").InsertOne(pt.ctx, newTask)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task", "details": err.Error()})
+		return
+	}
 
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found or you don't have permission to update it"})
-			return
-		}
+	// Convert the InsertedID to a string
+	insertedID := result.InsertedID.(primitive.ObjectID).Hex()
 
-		// Update the task
-		update := bson.M{
-			"$set": bson.M{
-				"title":              input.Title,
-				"description":        input.Description,
-				"task_time":          input.TaskTime,
-				"task_date":          taskDate,
-				"estimated_pay_rate": input.EstimatedPayRate,
-				"place_of_work":      input.PlaceOfWork,
-				"work_type":          models.TaskCategory(input.WorkType),
-				"people_needed":      input.PeopleNeeded,
-				"updated_at":         now,
-			},
-		}
+	c.Header("ETag", fmt.Sprintf("\"%d\"", newTask.Version))
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Task created successfully",
+		"task_id": insertedID,
+		"version": newTask.Version,
+	})
+}
 
-		result, err := pt.db.Collection("tasks").UpdateOne(
-			pt.ctx,
-			bson.M{"_id": objectID},
-			update,
-		)
+// Mock UpdateTask handler
+func (pt *PostTaskTest) mockUpdateTask(c *gin.Context) {
+	taskID := c.Param("task_id")
 
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task", "details": err.Error()})
-			return
-		}
+	// Only the fields that are present are updated
+	var input struct {
+		Email            string   `json:"email" binding:"required,email"`
+		Title            *string  `json:"title"`
+		Description      *string  `json:"description"`
+		TaskTime         *string  `json:"task_time"`
+		TaskDate         *string  `json:"task_date"`
+		EstimatedPayRate *float64 `json:"estimated_pay_rate"`
+		PlaceOfWork      *string  `json:"place_of_work"`
+		WorkType         *string  `json:"work_type"`
+		PeopleNeeded     *int     `json:"people_needed"`
+	}
 
-		c.JSON(http.StatusOK, gin.H{
-			"message": "Task updated successfully",
-			"updated": result.ModifiedCount > 0,
-			"task_id": input.ID,
-		})
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	} else {
-		// Create a new task
-		newTask := models.Task{
-			ID:               primitive.NewObjectID(),
-			Title:            input.Title,
-			Description:      input.Description,
-			TaskTime:         input.TaskTime,
-			TaskDate:         taskDate,
-			EstimatedPayRate: input.EstimatedPayRate,
-			PlaceOfWork:      input.PlaceOfWork,
-			WorkType:         models.TaskCategory(input.WorkType),
-			PeopleNeeded:     input.PeopleNeeded,
-
-			// Meta data
-			CreatorEmail:  email,
-			CreatedAt:     now,
-			UpdatedAt:     now,
-			Status:        models.Open,
-			Views:         0,
-			Applicants:    []string{},
-			SelectedUsers: []string{},
-		}
+	// Work out which version the caller is editing
+	expectedVersion, err := strconv.Atoi(strings.Trim(c.GetHeader("If-Match"), "\""))
+	if err != nil {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header with the task's ETag is required"})
+		return
+	}
+
+	// Convert string ID to ObjectID
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		return
+	}
+
+	// Find the existing task
+	var existingTask models.Task
+	err = pt.db.Collection("tasks").FindOne(
+		pt.ctx,
+		bson.M{
+			"_id":           objectID,
+			"creator_email": input.Email,
+		},
+	).Decode(&existingTask)
 
-		// Insert the task
-		result, err := pt.db.Collection("tasks").InsertOne(pt.ctx, newTask)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found or you don't have permission to update it"})
+		return
+	}
+
+	if existingTask.Version != expectedVersion {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"error":           "Task was modified by someone else. Reload it and try again",
+			"current_version": existingTask.Version,
+		})
+		return
+	}
+
+	setFields := bson.M{}
+	if input.Title != nil {
+		setFields["title"] = *input.Title
+	}
+	if input.Description != nil {
+		setFields["description"] = *input.Description
+	}
+	if input.TaskTime != nil {
+		setFields["task_time"] = *input.TaskTime
+	}
+	if input.TaskDate != nil {
+		taskDate, err := time.Parse("2006-01-02", *input.TaskDate)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task", "details": err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
 			return
 		}
+		setFields["task_date"] = taskDate
+	}
+	if input.EstimatedPayRate != nil {
+		setFields["estimated_pay_rate"] = *input.EstimatedPayRate
+	}
+	if input.PlaceOfWork != nil {
+		setFields["place_of_work"] = *input.PlaceOfWork
+	}
+	if input.WorkType != nil {
+		if !isValidTestWorkType(*input.WorkType) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":       "Invalid work type",
+				"valid_types": models.ValidTaskCategories(),
+			})
+			return
+		}
+		setFields["work_type"] = models.TaskCategory(*input.WorkType)
+	}
+	if input.PeopleNeeded != nil {
+		// Can't ask for fewer people than have already been selected
+		if *input.PeopleNeeded < len(existingTask.SelectedUsers) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":          "People needed cannot be less than the number of selected workers",
+				"selected_count": len(existingTask.SelectedUsers),
+			})
+			return
+		}
+		setFields["people_needed"] = *input.PeopleNeeded
+	}
 
-		// Convert the InsertedID to a string
-		insertedID := result.InsertedID.(primitive.ObjectID).Hex()
+	if len(setFields) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields provided for update"})
+		return
+	}
+	setFields["updated_at"] = time.Now()
+
+	// Only apply the update if nobody else has bumped the version in the meantime
+	result, err := pt.db.Collection("tasks").UpdateOne(
+		pt.ctx,
+		bson.M{"_id": objectID, "version": existingTask.Version},
+		bson.M{
+			"$set": setFields,
+			"$inc": bson.M{"version": 1},
+		},
+	)
 
-		c.JSON(http.StatusCreated, gin.H{
-			"message": "Task created successfully",
-			"task_id": insertedID,
-		})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task", "details": err.Error()})
+		return
+	}
+
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Task was modified by someone else. Reload it and try again"})
+		return
 	}
+
+	c.Header("ETag", fmt.Sprintf("\"%d\"", existingTask.Version+1))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task updated successfully",
+		"updated": result.ModifiedCount > 0,
+		"task_id": taskID,
+		"version": existingTask.Version + 1,
+	})
+}
+
+// Helper function to check a work type against the task categories
+func isValidTestWorkType(workType string) bool {
+	for _, category := range models.ValidTaskCategories() {
+		if string(category) == workType {
+			return true
+		}
+	}
+	return false
 }
 
 // Seed the test database with initial data
@@ -290,6 +354,7 @@ func (pt *PostTaskTest) seedTestData(t *testing.T) {
 		Views:            0,
 		Applicants:       []string{},
 		SelectedUsers:    []string{},
+		Version:          1,
 	})
 	if err != nil {
 		t.Fatalf("Failed to seed test task: %v", err)
@@ -305,6 +370,7 @@ func TestCreateTaskSuccess(t *testing.T) { // t is curr test context
 
 	// Create test request with new task data
 	taskData := map[string]interface{}{
+		"creator_email":      "taskcreator@example.com",
 		"title":              "New Test Task",
 		"description":        "This is a newly created test task",
 		"task_time":          "15:30",
@@ -316,7 +382,7 @@ func TestCreateTaskSuccess(t *testing.T) { // t is curr test context
 	}
 
 	jsonData, _ := json.Marshal(taskData)
-	req, _ := http.NewRequest("POST", "/tasks/taskcreator@example.com", bytes.NewBuffer(jsonData))
+	req, _ := http.NewRequest("POST", "/tasks", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
@@ -361,7 +427,7 @@ func TestUpdateTaskSuccess(t *testing.T) {
 
 	// Create update task data with the existing task ID
 	taskData := map[string]interface{}{
-		"id":                 pt.taskID,
+		"email":              "taskcreator@example.com",
 		"title":              "Updated Test Task",
 		"description":        "This task has been updated",
 		"task_time":          "16:45",
@@ -373,8 +439,9 @@ func TestUpdateTaskSuccess(t *testing.T) {
 	}
 
 	jsonData, _ := json.Marshal(taskData)
-	req, _ := http.NewRequest("PUT", "/tasks/taskcreator@example.com", bytes.NewBuffer(jsonData))
+	req, _ := http.NewRequest("PATCH", "/tasks/"+pt.taskID, bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", "\"1\"")
 
 	w := httptest.NewRecorder()
 
@@ -418,6 +485,7 @@ func TestCreateTaskInvalidWorkType(t *testing.T) {
 	defer pt.Cleanup(t)
 
 	taskData := map[string]interface{}{
+		"creator_email":      "taskcreator@example.com",
 		"title":              "Invalid Work Type Task",
 		"description":        "This task has an invalid work type",
 		"task_time":          "10:00",
@@ -429,7 +497,7 @@ func TestCreateTaskInvalidWorkType(t *testing.T) {
 	}
 
 	jsonData, _ := json.Marshal(taskData)
-	req, _ := http.NewRequest("POST", "/tasks/taskcreator@example.com", bytes.NewBuffer(jsonData))
+	req, _ := http.NewRequest("POST", "/tasks", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
@@ -453,7 +521,7 @@ func TestUpdateTaskInvalidID(t *testing.T) {
 	defer pt.Cleanup(t)
 
 	taskData := map[string]interface{}{
-		"id":                 "invalid-id-format",
+		"email":              "taskcreator@example.com",
 		"title":              "Task with Invalid ID",
 		"description":        "This task has an invalid ID format",
 		"task_time":          "11:00",
@@ -465,8 +533,9 @@ func TestUpdateTaskInvalidID(t *testing.T) {
 	}
 
 	jsonData, _ := json.Marshal(taskData)
-	req, _ := http.NewRequest("PUT", "/tasks/taskcreator@example.com", bytes.NewBuffer(jsonData))
+	req, _ := http.NewRequest("PATCH", "/tasks/invalid-id-format", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", "\"1\"")
 
 	w := httptest.NewRecorder()
 	pt.router.ServeHTTP(w, req)
@@ -498,7 +567,7 @@ func TestUpdateTaskUnauthorized(t *testing.T) {
 	assert.NoError(t, err)
 
 	taskData := map[string]interface{}{
-		"id":                 pt.taskID, // Trying to update a task created by taskcreator@example.com
+		"email":              "another@example.com", // Trying to update a task created by taskcreator@example.com
 		"title":              "Unauthorized Update Attempt",
 		"description":        "This is an attempt to update someone else's task",
 		"task_time":          "12:00",
@@ -510,8 +579,9 @@ func TestUpdateTaskUnauthorized(t *testing.T) {
 	}
 
 	jsonData, _ := json.Marshal(taskData)
-	req, _ := http.NewRequest("PUT", "/tasks/another@example.com", bytes.NewBuffer(jsonData))
+	req, _ := http.NewRequest("PATCH", "/tasks/"+pt.taskID, bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", "\"1\"")
 
 	w := httptest.NewRecorder()
 	pt.router.ServeHTTP(w, req)
@@ -536,6 +606,7 @@ func TestCreateTaskUserDoesNotExist(t *testing.T) {
 	defer pt.Cleanup(t)
 
 	taskData := map[string]interface{}{
+		"creator_email":      "notarealuser@example.com",
 		"title":              "Ghost User Task",
 		"description":        "Trying to post with non-existent user",
 		"task_time":          "09:00",
@@ -547,7 +618,7 @@ func TestCreateTaskUserDoesNotExist(t *testing.T) {
 	}
 
 	jsonData, _ := json.Marshal(taskData)
-	req, _ := http.NewRequest("POST", "/tasks/notarealuser@example.com", bytes.NewBuffer(jsonData))
+	req, _ := http.NewRequest("POST", "/tasks", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
@@ -569,6 +640,7 @@ func TestCreateTaskInvalidInput(t *testing.T) {
 
 	// Missing required field "description"
 	taskData := map[string]interface{}{
+		"creator_email":      "taskcreator@example.com",
 		"title":              "Incomplete Task",
 		"task_time":          "13:00",
 		"task_date":          "2025-05-01",
@@ -579,7 +651,7 @@ func TestCreateTaskInvalidInput(t *testing.T) {
 	}
 
 	jsonData, _ := json.Marshal(taskData)
-	req, _ := http.NewRequest("POST", "/tasks/taskcreator@example.com", bytes.NewBuffer(jsonData))
+	req, _ := http.NewRequest("POST", "/tasks", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
@@ -592,3 +664,164 @@ func TestCreateTaskInvalidInput(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, response["error"], "Description")
 }
+
+// Test updating a task to need fewer people than are already selected
+func TestUpdateTaskPeopleNeededBelowSelected(t *testing.T) {
+	// Initialize test environment
+	pt := &PostTaskTest{}
+	pt.Initialize(t)
+	defer pt.Cleanup(t)
+
+	// Two workers have already been selected for the task
+	_, err := pt.db.Collection("tasks").UpdateOne(
+		pt.ctx,
+		bson.M{"_id": pt.validID},
+		bson.M{"$set": bson.M{
+			"applicants":     []string{"worker1@example.com", "worker2@example.com"},
+			"selected_users": []string{"worker1@example.com", "worker2@example.com"},
+		}},
+	)
+	assert.NoError(t, err, "Failed to seed selected workers")
+
+	taskData := map[string]interface{}{
+		"email":              "taskcreator@example.com",
+		"title":              "Existing Test Task",
+		"description":        "This is a test task for updating",
+		"task_time":          "14:00",
+		"task_date":          "2025-04-15",
+		"estimated_pay_rate": 20.0,
+		"place_of_work":      "Campus",
+		"work_type":          "Cleaning",
+		"people_needed":      1,
+	}
+
+	jsonData, _ := json.Marshal(taskData)
+	req, _ := http.NewRequest("PATCH", "/tasks/"+pt.taskID, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", "\"1\"")
+
+	w := httptest.NewRecorder()
+	pt.router.ServeHTTP(w, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Expected status 400 Bad Request when dropping below selected workers")
+
+	var response struct {
+		Error         string `json:"error"`
+		SelectedCount int    `json:"selected_count"`
+	}
+	err = json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err, "Failed to parse response body")
+	assert.Equal(t, "People needed cannot be less than the number of selected workers", response.Error)
+	assert.Equal(t, 2, response.SelectedCount)
+
+	// The task must be unchanged
+	var task models.Task
+	err = pt.db.Collection("tasks").FindOne(pt.ctx, bson.M{"_id": pt.validID}).Decode(&task)
+	assert.NoError(t, err, "Task should exist in the database")
+	assert.Equal(t, 2, task.PeopleNeeded)
+}
+
+// Test a partial update that only changes the title
+func TestUpdateTaskPartial(t *testing.T) {
+	// Initialize test environment
+	pt := &PostTaskTest{}
+	pt.Initialize(t)
+	defer pt.Cleanup(t)
+
+	taskData := map[string]interface{}{
+		"email": "taskcreator@example.com",
+		"title": "Only The Title Changed",
+	}
+
+	jsonData, _ := json.Marshal(taskData)
+	req, _ := http.NewRequest("PATCH", "/tasks/"+pt.taskID, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", "\"1\"")
+
+	w := httptest.NewRecorder()
+	pt.router.ServeHTTP(w, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status 200 OK, got %d with body: %s", w.Code, w.Body.String())
+	assert.Equal(t, "\"2\"", w.Header().Get("ETag"), "ETag should carry the new version")
+
+	// Other fields must be untouched
+	var task models.Task
+	err := pt.db.Collection("tasks").FindOne(pt.ctx, bson.M{"_id": pt.validID}).Decode(&task)
+	assert.NoError(t, err, "Task should exist in the database")
+	assert.Equal(t, "Only The Title Changed", task.Title)
+	assert.Equal(t, "This is a test task for updating", task.Description)
+	assert.Equal(t, "Campus", task.PlaceOfWork)
+	assert.Equal(t, 2, task.PeopleNeeded)
+	assert.Equal(t, 2, task.Version)
+}
+
+// Test updating a task with a stale version from another tab
+func TestUpdateTaskStaleVersion(t *testing.T) {
+	// Initialize test environment
+	pt := &PostTaskTest{}
+	pt.Initialize(t)
+	defer pt.Cleanup(t)
+
+	// Someone else already saved an edit
+	_, err := pt.db.Collection("tasks").UpdateOne(
+		pt.ctx,
+		bson.M{"_id": pt.validID},
+		bson.M{"$set": bson.M{"title": "Edited In Another Tab", "version": 2}},
+	)
+	assert.NoError(t, err, "Failed to bump task version")
+
+	taskData := map[string]interface{}{
+		"email": "taskcreator@example.com",
+		"title": "Stale Edit",
+	}
+
+	jsonData, _ := json.Marshal(taskData)
+	req, _ := http.NewRequest("PATCH", "/tasks/"+pt.taskID, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", "\"1\"")
+
+	w := httptest.NewRecorder()
+	pt.router.ServeHTTP(w, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code, "Expected status 412 Precondition Failed for a stale version")
+
+	var response struct {
+		Error          string `json:"error"`
+		CurrentVersion int    `json:"current_version"`
+	}
+	err = json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err, "Failed to parse response body")
+	assert.Equal(t, 2, response.CurrentVersion)
+
+	// The other tab's edit must survive
+	var task models.Task
+	err = pt.db.Collection("tasks").FindOne(pt.ctx, bson.M{"_id": pt.validID}).Decode(&task)
+	assert.NoError(t, err, "Task should exist in the database")
+	assert.Equal(t, "Edited In Another Tab", task.Title)
+}
+
+// Test updating a task without an If-Match header
+func TestUpdateTaskMissingIfMatch(t *testing.T) {
+	// Initialize test environment
+	pt := &PostTaskTest{}
+	pt.Initialize(t)
+	defer pt.Cleanup(t)
+
+	taskData := map[string]interface{}{
+		"email": "taskcreator@example.com",
+		"title": "No Precondition",
+	}
+
+	jsonData, _ := json.Marshal(taskData)
+	req, _ := http.NewRequest("PATCH", "/tasks/"+pt.taskID, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	pt.router.ServeHTTP(w, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code, "Expected status 428 Precondition Required without If-Match")
+}
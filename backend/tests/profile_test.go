@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"ufpeerassist/backend/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test validating weekly availability windows
+func TestValidateAvailability(t *testing.T) {
+	windows := []models.AvailabilityWindow{
+		{Day: "monday", Start: "09:00", End: "12:00"},
+		{Day: "Monday", Start: "13:00", End: "17:30"},
+		{Day: "Saturday", Start: "18:00", End: "24:00"},
+	}
+	assert.NoError(t, models.ValidateAvailability(windows))
+	assert.Equal(t, "Monday", windows[0].Day, "Day names should be normalized")
+
+	invalid := map[string][]models.AvailabilityWindow{
+		"unknown day":   {{Day: "Someday", Start: "09:00", End: "10:00"}},
+		"bad time":      {{Day: "Friday", Start: "9am", End: "10:00"}},
+		"ends too soon": {{Day: "Friday", Start: "10:00", End: "10:00"}},
+		"overlapping": {
+			{Day: "Friday", Start: "09:00", End: "11:00"},
+			{Day: "Friday", Start: "10:30", End: "12:00"},
+		},
+	}
+	for name, windows := range invalid {
+		assert.Error(t, models.ValidateAvailability(windows), "Expected %s to be rejected", name)
+	}
+
+	tooMany := make([]models.AvailabilityWindow, models.MaxAvailabilityWindow+1)
+	assert.Error(t, models.ValidateAvailability(tooMany))
+}
+
+// Test whether a moment falls inside an availability window
+func TestAvailabilityWindowCovers(t *testing.T) {
+	window := models.AvailabilityWindow{Day: "Tuesday", Start: "14:00", End: "16:00"}
+
+	tuesday := time.Date(2025, 4, 22, 0, 0, 0, 0, time.UTC)
+	assert.True(t, window.Covers(tuesday.Add(14*time.Hour)))
+	assert.True(t, window.Covers(tuesday.Add(15*time.Hour+59*time.Minute)))
+	assert.False(t, window.Covers(tuesday.Add(16*time.Hour)), "End is exclusive")
+	assert.False(t, window.Covers(tuesday.Add(24*time.Hour+15*time.Hour)), "Wrong day")
+}
+
+// Test skill validation and the public profile view
+func TestSkillsAndPublicProfile(t *testing.T) {
+	skills, err := models.ValidateSkills([]models.TaskCategory{models.Tutoring, models.Cleaning, models.Tutoring})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TaskCategory{models.Tutoring, models.Cleaning}, skills, "Duplicates should be dropped")
+
+	_, err = models.ValidateSkills([]models.TaskCategory{"Juggling"})
+	assert.Error(t, err)
+
+	user := models.Users{
+		Email:      "student@example.com",
+		Name:       "Student",
+		Mobile:     "1234567890",
+		HideMobile: true,
+		Role:       models.UserRoleModerator,
+		Bio:        "Happy to help",
+		Skills:     skills,
+		AvatarKey:  "avatars/abc.jpg",
+	}
+	profile := user.PublicProfile()
+	assert.Equal(t, "Happy to help", profile.Bio)
+	assert.True(t, profile.HasAvatar)
+	assert.NotNil(t, profile.Availability, "Empty lists should not be null")
+}
+
+// Test the directory's availability and rating checks
+func TestDirectoryFilters(t *testing.T) {
+	user := models.Users{
+		Rating: " 4.5 ",
+		Availability: []models.AvailabilityWindow{
+			{Day: "Monday", Start: "09:00", End: "12:00"},
+			{Day: "Wednesday", Start: "13:00", End: "17:00"},
+		},
+	}
+
+	wednesday := time.Date(2025, 4, 23, 0, 0, 0, 0, time.UTC)
+	assert.True(t, user.AvailableAt(wednesday.Add(14*time.Hour)))
+	assert.False(t, user.AvailableAt(wednesday.Add(10*time.Hour)))
+
+	rating, rated := user.RatingValue()
+	assert.True(t, rated)
+	assert.Equal(t, 4.5, rating)
+
+	_, rated = models.Users{}.RatingValue()
+	assert.False(t, rated, "Unrated users have no rating value")
+}
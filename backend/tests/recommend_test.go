@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/recommend"
+	"ufpeerassist/backend/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that recommendations rank by history and explain themselves
+func TestRecommendedRanking(t *testing.T) {
+	worker := models.Users{
+		Email:  "worker@example.com",
+		Skills: []models.TaskCategory{models.ComputerHelp},
+		Availability: []models.AvailabilityWindow{
+			{Day: "Saturday", Start: "08:00", End: "18:00"},
+		},
+	}
+
+	completed := []models.Task{}
+	for i := 0; i < 4; i++ {
+		completed = append(completed, models.Task{WorkType: models.Tutoring, PlaceOfWork: "Library West Room 210"})
+	}
+	applied := []models.Task{{WorkType: models.Cleaning}, {WorkType: models.Cleaning}}
+
+	history := recommend.NewHistory(worker, completed, applied)
+
+	// Saturday April 26th 2025, 10:00 in Gainesville
+	saturday := time.Date(2025, 4, 26, 14, 0, 0, 0, time.UTC)
+	tasks := []models.Task{
+		{Title: "Unrelated", WorkType: models.Plumbing, StartAt: saturday.Add(-72 * time.Hour)},
+		{Title: "Cleaning", WorkType: models.Cleaning, StartAt: saturday.Add(-48 * time.Hour)},
+		{Title: "Tutoring", WorkType: models.Tutoring, PlaceOfWork: "Library West Room 101", StartAt: saturday},
+		{Title: "Computer Help", WorkType: models.ComputerHelp, StartAt: saturday.Add(-24 * time.Hour)},
+	}
+
+	recommend.Rank(history, tasks)
+
+	titles := []string{}
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+	}
+	assert.Equal(t, []string{"Tutoring", "Computer Help", "Cleaning", "Unrelated"}, titles)
+
+	tutoring := tasks[0].Recommendation
+	assert.Equal(t, 4*3.0+4.0+3.0, tutoring.Score)
+	assert.Equal(t, []string{
+		"You've done 4 Tutoring tasks",
+		"Near where you've worked before (Library West)",
+		"Fits your Saturday availability",
+	}, tutoring.Reasons)
+
+	assert.Equal(t, []string{"Matches your Computer Help skill"}, tasks[1].Recommendation.Reasons)
+	assert.Equal(t, []string{"You've applied to 2 Cleaning tasks"}, tasks[2].Recommendation.Reasons)
+	assert.Empty(t, tasks[3].Recommendation.Reasons)
+}
+
+// Test that a single habit is capped
+func TestRecommendedScoreCaps(t *testing.T) {
+	completed := make([]models.Task, 20)
+	for i := range completed {
+		completed[i].WorkType = models.Cleaning
+	}
+	history := recommend.NewHistory(models.Users{}, completed, nil)
+
+	recommendation := recommend.Score(history, models.Task{WorkType: models.Cleaning})
+	assert.Equal(t, 15.0, recommendation.Score, "Completed tasks should count up to five")
+	assert.Equal(t, []string{"You've done 20 Cleaning tasks"}, recommendation.Reasons)
+}
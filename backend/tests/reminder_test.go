@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/handlers"
+	"ufpeerassist/backend/models"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// seedReminderTask inserts an in-progress task with one selected worker, starting at start
+func seedReminderTask(t *testing.T, db *mongo.Database, title string, start time.Time) primitive.ObjectID {
+	task := models.Task{
+		ID:            primitive.NewObjectID(),
+		Title:         title,
+		StartAt:       start,
+		EndAt:         start.Add(time.Hour),
+		PeopleNeeded:  1,
+		CreatorEmail:  "poster@example.com",
+		Status:        models.InProgress,
+		Applicants:    []string{"worker@example.com"},
+		SelectedUsers: []string{"worker@example.com"},
+		Version:       1,
+	}
+	_, err := db.Collection("tasks").InsertOne(context.Background(), task)
+	assert.NoError(t, err, "Seeding the task should succeed")
+	return task.ID
+}
+
+// findReminders reads back the reminders claimed for a task
+func findReminders(t *testing.T, db *mongo.Database, taskID primitive.ObjectID) []models.TaskReminder {
+	cursor, err := db.Collection("reminders").Find(context.Background(), bson.M{"task_id": taskID})
+	assert.NoError(t, err)
+	var reminders []models.TaskReminder
+	assert.NoError(t, cursor.All(context.Background(), &reminders))
+	return reminders
+}
+
+// reminderKinds counts a task's reminders by kind
+func reminderKinds(reminders []models.TaskReminder) map[models.ReminderKind]int {
+	kinds := map[models.ReminderKind]int{}
+	for _, reminder := range reminders {
+		kinds[reminder.Kind]++
+	}
+	return kinds
+}
+
+// reminderOutbox stands in for email, recording each reminder a run tries to send
+type reminderOutbox struct {
+	attempts map[string]int // By task title, recipient and kind
+	failFor  string         // Sends to this recipient fail
+}
+
+func newReminderOutbox(failFor string) *reminderOutbox {
+	return &reminderOutbox{attempts: map[string]int{}, failFor: failFor}
+}
+
+func (o *reminderOutbox) send(recipient string, task models.Task, kind models.ReminderKind) error {
+	o.attempts[task.Title+"/"+recipient+"/"+string(kind)]++
+	if recipient == o.failFor {
+		return errors.New("mail server unavailable")
+	}
+	return nil
+}
+
+// Test that a task inside the hour gets only the 1h reminder, and one a few hours out only the 24h one
+func TestReminderWindowsDontOverlap(t *testing.T) {
+	db := setupHandlerDatabase(t, "ufpeerassist_test_reminders")
+
+	now := time.Now()
+	soon := seedReminderTask(t, db, "Starts soon", now.Add(30*time.Minute))
+	later := seedReminderTask(t, db, "Starts later", now.Add(5*time.Hour))
+	nextWeek := seedReminderTask(t, db, "Starts next week", now.Add(7*24*time.Hour))
+
+	outbox := newReminderOutbox("")
+	handlers.SendDueRemindersWith(now, outbox.send)
+
+	assert.Equal(t, map[string]int{
+		"Starts soon/poster@example.com/1h":   1,
+		"Starts soon/worker@example.com/1h":   1,
+		"Starts later/poster@example.com/24h": 1,
+		"Starts later/worker@example.com/24h": 1,
+	}, outbox.attempts, "Each task should get the reminder of its own window only")
+
+	assert.Equal(t, map[models.ReminderKind]int{models.Reminder1Hour: 2}, reminderKinds(findReminders(t, db, soon)))
+	assert.Equal(t, map[models.ReminderKind]int{models.Reminder24Hours: 2}, reminderKinds(findReminders(t, db, later)))
+	assert.Empty(t, findReminders(t, db, nextWeek))
+}
+
+// Test that a delivered reminder is never resent, and failing ones are retried up to the limit
+func TestReminderClaimsAndRetries(t *testing.T) {
+	db := setupHandlerDatabase(t, "ufpeerassist_test_reminders")
+
+	taskID := seedReminderTask(t, db, "Starts soon", time.Now().Add(30*time.Minute))
+	overdue := seedReminderTask(t, db, "Ended yesterday", time.Now().Add(-24*time.Hour))
+
+	// Every send to the poster fails; the worker's go through
+	outbox := newReminderOutbox("poster@example.com")
+	for run := 0; run < 5; run++ {
+		handlers.SendDueRemindersWith(time.Now(), outbox.send)
+	}
+
+	assert.Equal(t, 1, outbox.attempts["Starts soon/worker@example.com/1h"], "A delivered reminder isn't sent again")
+	assert.Equal(t, 3, outbox.attempts["Starts soon/poster@example.com/1h"], "A failing reminder is tried at most three times")
+	assert.Equal(t, 3, outbox.attempts["Ended yesterday/poster@example.com/completion_nudge"], "Failing nudges are capped too")
+
+	reminders := findReminders(t, db, taskID)
+	assert.Len(t, reminders, 2, "There's one reminder per recipient and kind")
+	for _, reminder := range reminders {
+		switch reminder.RecipientEmail {
+		case "worker@example.com":
+			assert.True(t, reminder.Delivered)
+			assert.Equal(t, 1, reminder.Attempts)
+		case "poster@example.com":
+			assert.False(t, reminder.Delivered)
+			assert.Equal(t, 3, reminder.Attempts)
+			assert.Equal(t, "mail server unavailable", reminder.LastError, "The failure should be recorded")
+		}
+	}
+	assert.Equal(t, map[models.ReminderKind]int{models.ReminderCompletion: 1}, reminderKinds(findReminders(t, db, overdue)))
+}
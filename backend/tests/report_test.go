@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"ufpeerassist/backend/api/handlers"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Test that a user can only have one open report against another user
+func TestReportUserOnce(t *testing.T) {
+	db := setupHandlerDatabase(t, "ufpeerassist_test_reports")
+
+	seedUsers(t, db,
+		models.Users{Name: "Reporter", Email: "reporter@example.com"},
+		models.Users{Name: "Reported", Email: "reported@example.com"},
+		models.Users{Name: "Other", Email: "other@example.com"},
+	)
+
+	router := gin.New()
+	router.POST("/users/:email/report/:reported_email", handlers.ReportUser)
+
+	w, _ := performJSON(router, http.MethodPost, "/users/reporter@example.com/report/reported@example.com", gin.H{"reason": models.ReportScam})
+	assert.Equal(t, http.StatusCreated, w.Code, "Reporting should succeed: %s", w.Body.String())
+
+	w, _ = performJSON(router, http.MethodPost, "/users/reporter@example.com/report/reported@example.com", gin.H{"reason": models.ReportSpam})
+	assert.Equal(t, http.StatusConflict, w.Code, "A second open report on the same user should be refused")
+
+	w, _ = performJSON(router, http.MethodPost, "/users/other@example.com/report/reported@example.com", gin.H{"reason": models.ReportSpam})
+	assert.Equal(t, http.StatusCreated, w.Code, "Other users can still report them")
+
+	// Once the first report is dealt with, a new one can be filed
+	_, err := db.Collection("reports").UpdateMany(context.Background(),
+		bson.M{"reporter_email": "reporter@example.com"}, bson.M{"$set": bson.M{"status": models.ReportDismissed}})
+	assert.NoError(t, err)
+
+	w, _ = performJSON(router, http.MethodPost, "/users/reporter@example.com/report/reported@example.com", gin.H{"reason": models.ReportUnsafe})
+	assert.Equal(t, http.StatusCreated, w.Code, "A closed report doesn't block a new one")
+
+	open, err := db.Collection("reports").CountDocuments(context.Background(), bson.M{"reported_email": "reported@example.com", "status": models.ReportOpen})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), open)
+}
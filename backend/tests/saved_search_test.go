@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"ufpeerassist/backend/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that saved search filters are checked before they're stored
+func TestSavedSearchFilterValidate(t *testing.T) {
+	low, high := 10.0, 25.0
+	negative := -1.0
+	from := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC)
+	campus := &models.GeoPoint{Latitude: 29.6436, Longitude: -82.3549}
+
+	assert.NoError(t, models.TaskFilter{}.Validate())
+	assert.NoError(t, models.TaskFilter{MinPay: &low, MaxPay: &high, FromDate: &from, ToDate: &to, Near: campus, RadiusKm: 5}.Validate())
+
+	assert.Error(t, models.TaskFilter{MinPay: &high, MaxPay: &low}.Validate(), "min above max")
+	assert.Error(t, models.TaskFilter{MinPay: &negative}.Validate(), "negative pay")
+	assert.Error(t, models.TaskFilter{FromDate: &to, ToDate: &from}.Validate(), "dates reversed")
+	assert.Error(t, models.TaskFilter{Near: campus}.Validate(), "position without radius")
+	assert.Error(t, models.TaskFilter{RadiusKm: 5}.Validate(), "radius without position")
+	assert.Error(t, models.TaskFilter{Near: campus, RadiusKm: models.MaxSearchRadiusKm + 1}.Validate(), "radius too large")
+	assert.Error(t, models.TaskFilter{Near: campus, RadiusKm: 0.2}.Validate(), "radius too small")
+	assert.NoError(t, models.TaskFilter{Near: campus, RadiusKm: models.MinSearchRadiusKm}.Validate())
+	assert.Error(t, models.TaskFilter{Near: &models.GeoPoint{Latitude: 91}, RadiusKm: 5}.Validate(), "invalid position")
+}
+
+// Test that new tasks are matched against every part of a saved search
+func TestSavedSearchFilterMatches(t *testing.T) {
+	low, high := 15.0, 30.0
+	from := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC)
+	campus := models.GeoPoint{Latitude: 29.6436, Longitude: -82.3549}
+
+	filter := models.TaskFilter{
+		Category: models.Tutoring,
+		MinPay:   &low,
+		MaxPay:   &high,
+		FromDate: &from,
+		ToDate:   &to,
+		Near:     &campus,
+		RadiusKm: 5,
+		Keyword:  "calculus exam",
+	}
+
+	task := models.Task{
+		Title:            "Calculus tutoring",
+		Description:      "Help me prepare for my final EXAM",
+		WorkType:         models.Tutoring,
+		EstimatedPayRate: 20,
+		TaskDate:         time.Date(2025, 5, 10, 0, 0, 0, 0, time.UTC),
+		Coordinates:      &models.GeoPoint{Latitude: 29.6516, Longitude: -82.3248}, // Downtown, about 3km away
+	}
+	assert.True(t, filter.Matches(task))
+
+	cases := map[string]func(task *models.Task){
+		"other category":    func(task *models.Task) { task.WorkType = models.Cleaning },
+		"pay too low":       func(task *models.Task) { task.EstimatedPayRate = 10 },
+		"pay too high":      func(task *models.Task) { task.EstimatedPayRate = 40 },
+		"before range":      func(task *models.Task) { task.TaskDate = from.AddDate(0, 0, -1) },
+		"after range":       func(task *models.Task) { task.TaskDate = to.AddDate(0, 0, 1) },
+		"too far":           func(task *models.Task) { task.Coordinates = &models.GeoPoint{Latitude: 28.5383, Longitude: -81.3792} },
+		"no coordinates":    func(task *models.Task) { task.Coordinates = nil },
+		"missing a keyword": func(task *models.Task) { task.Description = "Help me with homework" },
+	}
+	for name, change := range cases {
+		changed := task
+		change(&changed)
+		assert.False(t, filter.Matches(changed), name)
+	}
+
+	// Range ends are inclusive
+	task.TaskDate = to
+	task.EstimatedPayRate = high
+	assert.True(t, filter.Matches(task))
+
+	// An empty filter matches everything
+	assert.True(t, models.TaskFilter{}.Matches(models.Task{}))
+}
+
+// Test the great-circle distance used by the distance filter
+func TestGeoPointDistance(t *testing.T) {
+	gainesville := models.GeoPoint{Latitude: 29.6516, Longitude: -82.3248}
+	orlando := models.GeoPoint{Latitude: 28.5383, Longitude: -81.3792}
+
+	assert.InDelta(t, 0, gainesville.DistanceKm(gainesville), 0.001)
+	assert.InDelta(t, 157, gainesville.DistanceKm(orlando), 3)
+	assert.InDelta(t, gainesville.DistanceKm(orlando), orlando.DistanceKm(gainesville), 0.001)
+}
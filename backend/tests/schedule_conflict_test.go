@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/handlers"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// scheduleConflictTest holds a worker already booked 10:00-12:00 two days from now
+type scheduleConflictTest struct {
+	db       *mongo.Database
+	router   *gin.Engine
+	booked   time.Time // Start of the worker's existing booking
+	bookedID primitive.ObjectID
+}
+
+// setupScheduleConflictTest seeds the users and the worker's existing booking
+func setupScheduleConflictTest(t *testing.T) *scheduleConflictTest {
+	st := &scheduleConflictTest{db: setupHandlerDatabase(t, "ufpeerassist_test_schedule_conflict")}
+
+	seedUsers(t, st.db,
+		models.Users{Name: "Poster", Email: "poster@example.com"},
+		models.Users{Name: "Worker", Email: "worker@example.com"},
+	)
+
+	day := time.Now().UTC().AddDate(0, 0, 2)
+	st.booked = time.Date(day.Year(), day.Month(), day.Day(), 10, 0, 0, 0, time.UTC)
+	st.bookedID = primitive.NewObjectID()
+	_, err := st.db.Collection("scheduled_tasks").InsertOne(context.Background(), models.ScheduledTask{
+		TaskID:  st.bookedID,
+		Title:   "Existing booking",
+		Poster:  "someone@example.com",
+		Worker:  "worker@example.com",
+		StartAt: st.booked,
+		EndAt:   st.booked.Add(2 * time.Hour),
+	})
+	assert.NoError(t, err, "Seeding the booking should succeed")
+
+	st.router = gin.New()
+	st.router.POST("/tasks/:task_id/apply/:email", handlers.ApplyForTask)
+	st.router.POST("/tasks/:task_id/accept/:email", handlers.AcceptTask)
+	return st
+}
+
+// seedTask inserts an open, unpaid task running from start for the given length; a zero start
+// leaves it without structured times
+func (st *scheduleConflictTest) seedTask(t *testing.T, start time.Time, length time.Duration) string {
+	task := models.Task{
+		ID:           primitive.NewObjectID(),
+		Title:        "New task",
+		PeopleNeeded: 1,
+		CreatorEmail: "poster@example.com",
+		Status:       models.Open,
+		Applicants:   []string{},
+		Version:      1,
+	}
+	if !start.IsZero() {
+		task.StartAt = start
+		task.EndAt = start.Add(length)
+	}
+	_, err := st.db.Collection("tasks").InsertOne(context.Background(), task)
+	assert.NoError(t, err, "Seeding the task should succeed")
+	return task.ID.Hex()
+}
+
+// apply applies the worker to a task, as they must before they can be accepted
+func (st *scheduleConflictTest) apply(t *testing.T, taskID string) {
+	w, _ := performJSON(st.router, http.MethodPost, "/tasks/"+taskID+"/apply/worker@example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code, "Applying should succeed: %s", w.Body.String())
+}
+
+// Test that applying to an overlapping task succeeds but warns, and touching or untimed tasks don't
+func TestApplyWarnsOnScheduleConflict(t *testing.T) {
+	st := setupScheduleConflictTest(t)
+
+	overlapping := st.seedTask(t, st.booked.Add(time.Hour), 2*time.Hour)
+	w, response := performJSON(st.router, http.MethodPost, "/tasks/"+overlapping+"/apply/worker@example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code, "Applying should still succeed: %s", w.Body.String())
+	assert.NotEmpty(t, response["warning"])
+	conflict, _ := response["conflicting_task"].(map[string]interface{})
+	assert.Equal(t, st.bookedID.Hex(), conflict["task_id"], "The warning should name the booked task")
+
+	cases := map[string]string{
+		"starts as the booking ends": st.seedTask(t, st.booked.Add(2*time.Hour), time.Hour),
+		"ends as the booking starts": st.seedTask(t, st.booked.Add(-time.Hour), time.Hour),
+		"no structured times":        st.seedTask(t, time.Time{}, 0),
+	}
+	for name, taskID := range cases {
+		w, response := performJSON(st.router, http.MethodPost, "/tasks/"+taskID+"/apply/worker@example.com", nil)
+		assert.Equal(t, http.StatusOK, w.Code, name)
+		assert.Nil(t, response["warning"], name)
+	}
+}
+
+// Test that accepting a double-booked worker needs an explicit override
+func TestAcceptRequiresConflictOverride(t *testing.T) {
+	st := setupScheduleConflictTest(t)
+
+	// Entirely inside the booking
+	taskID := st.seedTask(t, st.booked.Add(30*time.Minute), time.Hour)
+	st.apply(t, taskID)
+
+	w, response := performJSON(st.router, http.MethodPost, "/tasks/"+taskID+"/accept/worker@example.com", nil)
+	assert.Equal(t, http.StatusConflict, w.Code, "Accepting a double-booked worker should need an override")
+	assert.NotNil(t, response["conflicting_task"])
+
+	count, err := st.db.Collection("scheduled_tasks").CountDocuments(context.Background(), bson.M{"worker_email": "worker@example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count, "A refused acceptance shouldn't book the worker")
+
+	w, _ = performJSON(st.router, http.MethodPost, "/tasks/"+taskID+"/accept/worker@example.com?override_conflict=true", nil)
+	assert.Equal(t, http.StatusOK, w.Code, "The override should accept anyway: %s", w.Body.String())
+
+	count, err = st.db.Collection("scheduled_tasks").CountDocuments(context.Background(), bson.M{"worker_email": "worker@example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count, "The worker should now be booked for both")
+}
+
+// Test that cancelled and completed bookings don't block an acceptance
+func TestAcceptIgnoresSettledBookings(t *testing.T) {
+	st := setupScheduleConflictTest(t)
+
+	for _, status := range []models.TaskStatus{models.Cancelled, models.Completed} {
+		_, err := st.db.Collection("scheduled_tasks").UpdateOne(context.Background(),
+			bson.M{"task_id": st.bookedID}, bson.M{"$set": bson.M{"status": status}})
+		assert.NoError(t, err)
+
+		taskID := st.seedTask(t, st.booked.Add(time.Hour), 2*time.Hour)
+		st.apply(t, taskID)
+		w, _ := performJSON(st.router, http.MethodPost, "/tasks/"+taskID+"/accept/worker@example.com", nil)
+		assert.Equal(t, http.StatusOK, w.Code, "A %s booking shouldn't conflict: %s", status, w.Body.String())
+
+		// Clear the new booking so the next case only sees the settled one
+		_, err = st.db.Collection("scheduled_tasks").DeleteMany(context.Background(),
+			bson.M{"worker_email": "worker@example.com", "task_id": bson.M{"$ne": st.bookedID}})
+		assert.NoError(t, err)
+	}
+}
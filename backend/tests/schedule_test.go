@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/handlers"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// seedScheduledTask inserts a task and the worker's schedule row for it
+func seedScheduledTask(t *testing.T, db *mongo.Database, title, poster string, start time.Time, status, rowStatus models.TaskStatus, selected bool) {
+	task := models.Task{
+		ID:           primitive.NewObjectID(),
+		Title:        title,
+		StartAt:      start,
+		EndAt:        start.Add(2 * time.Hour),
+		PlaceOfWork:  "1 Main St, Gainesville, FL",
+		PeopleNeeded: 1,
+		CreatorEmail: poster,
+		Status:       status,
+		Applicants:   []string{},
+		Version:      1,
+	}
+	if selected {
+		task.Applicants = []string{"worker@example.com"}
+		task.SelectedUsers = []string{"worker@example.com"}
+	}
+	_, err := db.Collection("tasks").InsertOne(context.Background(), task)
+	assert.NoError(t, err, "Seeding the task should succeed")
+
+	_, err = db.Collection("scheduled_tasks").InsertOne(context.Background(), models.ScheduledTask{
+		TaskID:  task.ID,
+		Title:   title,
+		Poster:  poster,
+		Worker:  "worker@example.com",
+		StartAt: task.StartAt,
+		EndAt:   task.EndAt,
+		Place:   task.PlaceOfWork,
+		Status:  rowStatus,
+	})
+	assert.NoError(t, err, "Seeding the schedule row should succeed")
+}
+
+// setupScheduleTest seeds a worker's upcoming, past and cancelled bookings with two posters
+func setupScheduleTest(t *testing.T) *gin.Engine {
+	db := setupHandlerDatabase(t, "ufpeerassist_test_schedule")
+
+	seedUsers(t, db,
+		models.Users{Name: "Poster", Email: "poster@example.com", Mobile: "352-555-0100"},
+		models.Users{Name: "Private Poster", Email: "private@example.com", Mobile: "352-555-0101", HideMobile: true},
+		models.Users{Name: "Worker", Email: "worker@example.com", Mobile: "352-555-0102"},
+	)
+
+	now := time.Now()
+	seedScheduledTask(t, db, "Next week", "poster@example.com", now.Add(7*24*time.Hour), models.InProgress, "", true)
+	seedScheduledTask(t, db, "Tomorrow", "private@example.com", now.Add(24*time.Hour), models.InProgress, "", true)
+	seedScheduledTask(t, db, "Last week", "poster@example.com", now.Add(-7*24*time.Hour), models.Completed, models.Completed, true)
+	seedScheduledTask(t, db, "Called off", "poster@example.com", now.Add(48*time.Hour), models.Cancelled, models.Cancelled, true)
+	seedScheduledTask(t, db, "Withdrew", "poster@example.com", now.Add(72*time.Hour), models.Open, models.Cancelled, false)
+
+	router := gin.New()
+	router.GET("/users/:email/schedule", handlers.GetSchedule)
+	return router
+}
+
+// scheduleBucket returns the entries of one bucket of a schedule response
+func scheduleBucket(response gin.H, bucket string) []map[string]interface{} {
+	entries := []map[string]interface{}{}
+	list, _ := response[bucket].([]interface{})
+	for _, item := range list {
+		entry, _ := item.(map[string]interface{})
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// entryTitle reads the title of an entry's task
+func entryTitle(entry map[string]interface{}) string {
+	task, _ := entry["task"].(map[string]interface{})
+	title, _ := task["title"].(string)
+	return title
+}
+
+// entryCounterpartMobile reads the mobile number shown for the other side of an entry
+func entryCounterpartMobile(entry map[string]interface{}) interface{} {
+	counterpart, _ := entry["counterpart"].(map[string]interface{})
+	return counterpart["mobile"]
+}
+
+// Test that a worker's schedule is split into buckets, in order, with contact details only while they apply
+func TestGetScheduleForWorker(t *testing.T) {
+	router := setupScheduleTest(t)
+
+	w, response := performJSON(router, http.MethodGet, "/users/worker@example.com/schedule", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "worker", response["role"], "Worker is the default role")
+	assert.Equal(t, float64(5), response["count"])
+
+	upcoming := scheduleBucket(response, "upcoming")
+	if assert.Len(t, upcoming, 2) {
+		assert.Equal(t, "Tomorrow", entryTitle(upcoming[0]), "Upcoming entries are soonest first")
+		assert.Equal(t, "Next week", entryTitle(upcoming[1]))
+
+		assert.Equal(t, "", entryCounterpartMobile(upcoming[0]), "A poster who hides their mobile never shares it")
+		assert.Equal(t, "352-555-0100", entryCounterpartMobile(upcoming[1]), "The poster's mobile shows while the task is on")
+	}
+
+	past := scheduleBucket(response, "past")
+	if assert.Len(t, past, 1) {
+		assert.Equal(t, "Last week", entryTitle(past[0]))
+		assert.Equal(t, "", entryCounterpartMobile(past[0]), "Contact details close with the task")
+		task, _ := past[0]["task"].(map[string]interface{})
+		assert.Equal(t, true, task["location_approximate"], "The exact place is gone once the task is over")
+	}
+
+	cancelled := scheduleBucket(response, "cancelled")
+	if assert.Len(t, cancelled, 2) {
+		// Most recent first
+		assert.Equal(t, "Withdrew", entryTitle(cancelled[0]), "A cancelled booking shows even though the task goes on")
+		assert.Equal(t, "Called off", entryTitle(cancelled[1]))
+		assert.Equal(t, "", entryCounterpartMobile(cancelled[0]))
+	}
+}
+
+// Test the poster's side of the schedule and the request checks
+func TestGetScheduleForPoster(t *testing.T) {
+	router := setupScheduleTest(t)
+
+	w, response := performJSON(router, http.MethodGet, "/users/poster@example.com/schedule?role=poster", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(4), response["count"], "Only this poster's bookings are listed")
+
+	upcoming := scheduleBucket(response, "upcoming")
+	if assert.Len(t, upcoming, 1) {
+		assert.Equal(t, "Next week", entryTitle(upcoming[0]))
+		assert.Equal(t, "352-555-0102", entryCounterpartMobile(upcoming[0]), "The poster sees their worker's mobile")
+		task, _ := upcoming[0]["task"].(map[string]interface{})
+		assert.Equal(t, "1 Main St, Gainesville, FL", task["place_of_work"])
+	}
+	assert.Len(t, scheduleBucket(response, "past"), 1)
+	assert.Len(t, scheduleBucket(response, "cancelled"), 2)
+
+	w, _ = performJSON(router, http.MethodGet, "/users/poster@example.com/schedule?role=owner", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Only worker and poster are valid roles")
+
+	w, _ = performJSON(router, http.MethodGet, "/users/nobody@example.com/schedule", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/handlers"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// setupCancelTest seeds an open task with two selected workers and their schedule entries
+func setupCancelTest(t *testing.T) (*mongo.Database, *gin.Engine, primitive.ObjectID) {
+	db := setupHandlerDatabase(t, "ufpeerassist_test_cancel")
+
+	seedUsers(t, db,
+		models.Users{Name: "Poster", Email: "poster@example.com"},
+		models.Users{Name: "Alice", Email: "alice@example.com"},
+		models.Users{Name: "Bob", Email: "bob@example.com"},
+	)
+
+	start := time.Now().Add(48 * time.Hour)
+	task := models.Task{
+		ID:                   primitive.NewObjectID(),
+		Title:                "Paint a fence",
+		StartAt:              start,
+		EndAt:                start.Add(2 * time.Hour),
+		DurationMinutes:      120,
+		PeopleNeeded:         2,
+		CreatorEmail:         "poster@example.com",
+		Status:               models.Open,
+		Applicants:           []string{"alice@example.com", "bob@example.com"},
+		SelectedUsers:        []string{"alice@example.com", "bob@example.com"},
+		PendingConfirmations: []string{"bob@example.com"},
+		Version:              3,
+	}
+	_, err := db.Collection("tasks").InsertOne(context.Background(), task)
+	assert.NoError(t, err, "Seeding the task should succeed")
+
+	for _, worker := range task.SelectedUsers {
+		_, err = db.Collection("scheduled_tasks").InsertOne(context.Background(), models.ScheduledTask{
+			TaskID:  task.ID,
+			Title:   task.Title,
+			Poster:  task.CreatorEmail,
+			Worker:  worker,
+			StartAt: task.StartAt,
+			EndAt:   task.EndAt,
+		})
+		assert.NoError(t, err, "Seeding the assignment should succeed")
+	}
+
+	router := gin.New()
+	router.GET("/tasks/:task_id", handlers.GetTask)
+	router.PATCH("/tasks/:task_id", handlers.UpdateTask)
+	router.POST("/tasks/:task_id/withdraw/:email", handlers.WithdrawApplication)
+	router.POST("/tasks/:task_id/reconfirm/:email", handlers.ReconfirmTask)
+	router.POST("/tasks/:task_id/cancel/:email", handlers.CancelTask)
+
+	return db, router, task.ID
+}
+
+// findWorkerAssignments reads back every schedule entry a worker has on a task
+func findWorkerAssignments(t *testing.T, db *mongo.Database, taskID primitive.ObjectID, worker string) []models.ScheduledTask {
+	cursor, err := db.Collection("scheduled_tasks").Find(context.Background(), bson.M{"task_id": taskID, "worker_email": worker})
+	assert.NoError(t, err)
+	var assignments []models.ScheduledTask
+	assert.NoError(t, cursor.All(context.Background(), &assignments))
+	return assignments
+}
+
+// Test that cancelling a task changes its version, so its ETag and calendar sequence move on
+func TestCancelTaskBumpsVersion(t *testing.T) {
+	db, router, taskID := setupCancelTest(t)
+
+	w, _ := performJSON(router, http.MethodGet, "/tasks/"+taskID.Hex(), nil)
+	etagBefore := w.Header().Get("ETag")
+
+	w, _ = performJSON(router, http.MethodPost, "/tasks/"+taskID.Hex()+"/cancel/poster@example.com", gin.H{"reason": "Rain"})
+	assert.Equal(t, http.StatusOK, w.Code, "Cancelling should succeed: %s", w.Body.String())
+
+	w, response := performJSON(router, http.MethodGet, "/tasks/"+taskID.Hex(), nil)
+	assert.NotEqual(t, etagBefore, w.Header().Get("ETag"), "The ETag should change")
+	task, _ := response["task"].(map[string]interface{})
+	assert.Equal(t, float64(4), task["version"])
+	assert.Equal(t, string(models.Cancelled), task["status"])
+
+	for _, worker := range []string{"alice@example.com", "bob@example.com"} {
+		assignments := findWorkerAssignments(t, db, taskID, worker)
+		if assert.Len(t, assignments, 1) {
+			assert.Equal(t, models.Cancelled, assignments[0].Status, "%s's entry should be cancelled", worker)
+		}
+	}
+}
+
+// Test that withdrawing and dropping out keep the worker's schedule entry as cancelled
+func TestLeavingTaskCancelsScheduleEntry(t *testing.T) {
+	db, router, taskID := setupCancelTest(t)
+
+	w, _ := performJSON(router, http.MethodPost, "/tasks/"+taskID.Hex()+"/withdraw/alice@example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code, "Withdrawing should succeed: %s", w.Body.String())
+
+	w, _ = performJSON(router, http.MethodPost, "/tasks/"+taskID.Hex()+"/reconfirm/bob@example.com", gin.H{"confirm": false})
+	assert.Equal(t, http.StatusOK, w.Code, "Dropping out should succeed: %s", w.Body.String())
+
+	for _, worker := range []string{"alice@example.com", "bob@example.com"} {
+		assignments := findWorkerAssignments(t, db, taskID, worker)
+		if assert.Len(t, assignments, 1, "%s's entry should be kept", worker) {
+			assert.Equal(t, models.Cancelled, assignments[0].Status)
+		}
+	}
+
+	// With nobody left, the task stays open rather than counting as completed
+	assert.Equal(t, models.Open, findTaskStatus(t, db, taskID))
+}
+
+// Test that a cancelled task can't be edited, even with the current version
+func TestUpdateCancelledTask(t *testing.T) {
+	db, router, taskID := setupCancelTest(t)
+
+	w, _ := performJSON(router, http.MethodPost, "/tasks/"+taskID.Hex()+"/cancel/poster@example.com", gin.H{"reason": "Rain"})
+	assert.Equal(t, http.StatusOK, w.Code, "Cancelling should succeed: %s", w.Body.String())
+
+	w, _ = performJSON(router, http.MethodPatch, "/tasks/"+taskID.Hex(), gin.H{"email": "poster@example.com", "version": 4, "title": "Paint two fences"})
+	assert.Equal(t, http.StatusConflict, w.Code, "Editing a cancelled task should be refused")
+
+	var task models.Task
+	assert.NoError(t, db.Collection("tasks").FindOne(context.Background(), bson.M{"_id": taskID}).Decode(&task))
+	assert.Equal(t, "Paint a fence", task.Title)
+	assert.Equal(t, 4, task.Version, "A refused edit doesn't bump the version")
+}
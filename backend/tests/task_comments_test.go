@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/handlers"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// setupCommentsTest seeds a poster, two students and an open task created before tasks were versioned
+func setupCommentsTest(t *testing.T) (*mongo.Database, *gin.Engine, primitive.ObjectID) {
+	db := setupHandlerDatabase(t, "ufpeerassist_test_comments")
+
+	seedUsers(t, db,
+		models.Users{Name: "Poster", Email: "poster@example.com"},
+		models.Users{Name: "Student", Email: "student@example.com"},
+		models.Users{Name: "Blocked", Email: "blocked@example.com"},
+	)
+
+	// Legacy tasks have no version field at all
+	taskID := primitive.NewObjectID()
+	_, err := db.Collection("tasks").InsertOne(context.Background(), bson.M{
+		"_id":           taskID,
+		"title":         "Move a couch",
+		"description":   "Help me move a couch.",
+		"creator_email": "poster@example.com",
+		"status":        models.Open,
+		"start_at":      time.Now().Add(48 * time.Hour),
+		"applicants":    []string{},
+	})
+	assert.NoError(t, err, "Seeding the task should succeed")
+
+	router := gin.New()
+	router.GET("/tasks/:task_id/comments/:email", handlers.GetTaskComments)
+	router.POST("/tasks/:task_id/comments/:email", handlers.PostTaskComment)
+	router.POST("/comments/:comment_id/pin/:email", handlers.PinTaskComment)
+	router.DELETE("/comments/:comment_id/:email", handlers.DeleteTaskComment)
+
+	return db, router, taskID
+}
+
+// postComment posts a comment and returns its ID
+func postComment(t *testing.T, router *gin.Engine, taskID primitive.ObjectID, email, body, replyTo string) string {
+	w, response := performJSON(router, http.MethodPost, "/tasks/"+taskID.Hex()+"/comments/"+email, gin.H{"body": body, "reply_to": replyTo})
+	assert.Equal(t, http.StatusCreated, w.Code, "Posting a comment should succeed: %s", w.Body.String())
+	comment, _ := response["comment"].(map[string]interface{})
+	id, _ := comment["id"].(string)
+	return id
+}
+
+// Test that the poster can pin an answer on a task created before versioning
+func TestPinAnswerOnLegacyTask(t *testing.T) {
+	db, router, taskID := setupCommentsTest(t)
+
+	question := postComment(t, router, taskID, "student@example.com", "Is there parking?", "")
+	answer := postComment(t, router, taskID, "poster@example.com", "Yes, in the garage.", question)
+
+	// Only the poster can pin, and only their own answers
+	w, _ := performJSON(router, http.MethodPost, "/comments/"+answer+"/pin/student@example.com", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code, "Students shouldn't be able to pin")
+	w, _ = performJSON(router, http.MethodPost, "/comments/"+question+"/pin/poster@example.com", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Questions shouldn't be pinnable")
+
+	w, response := performJSON(router, http.MethodPost, "/comments/"+answer+"/pin/poster@example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code, "Pinning on a legacy task should succeed: %s", w.Body.String())
+	assert.Equal(t, float64(1), response["version"], "Pinning should bump the version")
+
+	var task models.Task
+	err := db.Collection("tasks").FindOne(context.Background(), bson.M{"_id": taskID}).Decode(&task)
+	assert.NoError(t, err)
+	assert.Contains(t, task.Description, "Q: Is there parking?\nA: Yes, in the garage.", "The answer should be in the description")
+	assert.Equal(t, 1, task.Version)
+
+	w, _ = performJSON(router, http.MethodPost, "/comments/"+answer+"/pin/poster@example.com", nil)
+	assert.Equal(t, http.StatusConflict, w.Code, "An answer should only be pinned once")
+}
+
+// Test that moderated questions and answers can't reach the description by being pinned
+func TestPinRejectsModeratedComments(t *testing.T) {
+	db, router, taskID := setupCommentsTest(t)
+
+	removedQuestion := postComment(t, router, taskID, "student@example.com", "Can I bring a friend?", "")
+	removedAnswer := postComment(t, router, taskID, "poster@example.com", "Sure.", removedQuestion)
+	w, _ := performJSON(router, http.MethodDelete, "/comments/"+removedQuestion+"/poster@example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, _ = performJSON(router, http.MethodPost, "/comments/"+removedAnswer+"/pin/poster@example.com", nil)
+	assert.Equal(t, http.StatusConflict, w.Code, "An answer to a removed question can't be pinned")
+
+	hiddenQuestion := postComment(t, router, taskID, "student@example.com", "What's the address?", "")
+	hiddenAnswer := postComment(t, router, taskID, "poster@example.com", "Ask me later.", hiddenQuestion)
+	question, _ := primitive.ObjectIDFromHex(hiddenQuestion)
+	_, err := db.Collection("comments").UpdateOne(context.Background(), bson.M{"_id": question}, bson.M{"$set": bson.M{"hidden": true}})
+	assert.NoError(t, err)
+
+	w, _ = performJSON(router, http.MethodPost, "/comments/"+hiddenAnswer+"/pin/poster@example.com", nil)
+	assert.Equal(t, http.StatusConflict, w.Code, "An answer to a hidden question can't be pinned")
+
+	answer := postComment(t, router, taskID, "poster@example.com", "Bring gloves.", "")
+	answerID, _ := primitive.ObjectIDFromHex(answer)
+	_, err = db.Collection("comments").UpdateOne(context.Background(), bson.M{"_id": answerID}, bson.M{"$set": bson.M{"hidden": true}})
+	assert.NoError(t, err)
+
+	w, _ = performJSON(router, http.MethodPost, "/comments/"+answer+"/pin/poster@example.com", nil)
+	assert.Equal(t, http.StatusConflict, w.Code, "A hidden answer can't be pinned")
+
+	var task models.Task
+	assert.NoError(t, db.Collection("tasks").FindOne(context.Background(), bson.M{"_id": taskID}).Decode(&task))
+	assert.Equal(t, "Help me move a couch.", task.Description, "Nothing should have been pinned")
+}
+
+// Test which comments a viewer sees on a task
+func TestTaskCommentsVisibility(t *testing.T) {
+	db, router, taskID := setupCommentsTest(t)
+
+	kept := postComment(t, router, taskID, "student@example.com", "Is there parking?", "")
+	removed := postComment(t, router, taskID, "student@example.com", "Never mind", "")
+	postComment(t, router, taskID, "blocked@example.com", "How heavy is it?", "")
+
+	// The student blocks the other student after they commented
+	_, err := db.Collection("blocks").InsertOne(context.Background(), models.UserBlock{
+		BlockerEmail: "student@example.com",
+		BlockedEmail: "blocked@example.com",
+		CreatedAt:    time.Now(),
+	})
+	assert.NoError(t, err)
+
+	w, _ := performJSON(router, http.MethodDelete, "/comments/"+removed+"/poster@example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code, "The poster should be able to remove any comment on their task")
+
+	w, response := performJSON(router, http.MethodGet, "/tasks/"+taskID.Hex()+"/comments/student@example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	comments, _ := response["comments"].([]interface{})
+	if assert.Len(t, comments, 1, "Removed comments and comments from blocked users should be left out") {
+		assert.Equal(t, kept, comments[0].(map[string]interface{})["id"])
+	}
+
+	// Once the task closes the Q&A is no longer shown
+	_, err = db.Collection("tasks").UpdateOne(context.Background(), bson.M{"_id": taskID}, bson.M{"$set": bson.M{"status": models.InProgress}})
+	assert.NoError(t, err)
+
+	_, response = performJSON(router, http.MethodGet, "/tasks/"+taskID.Hex()+"/comments/poster@example.com", nil)
+	assert.Equal(t, true, response["closed"])
+	assert.Equal(t, float64(0), response["count"])
+
+	w, _ = performJSON(router, http.MethodPost, "/tasks/"+taskID.Hex()+"/comments/student@example.com", gin.H{"body": "Still available?"})
+	assert.Equal(t, http.StatusConflict, w.Code, "Comments can't be posted on closed tasks")
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/handlers"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Test that confirming completion for a worker whose assignment is already settled pays nothing
+func TestValidateCompletionRequiresActiveAssignment(t *testing.T) {
+	db := setupHandlerDatabase(t, "ufpeerassist_test_task_completion")
+
+	seedUsers(t, db,
+		models.Users{Name: "Poster", Email: "poster@example.com"},
+		models.Users{Name: "Worker", Email: "worker@example.com"},
+	)
+
+	start := time.Now().Add(-3 * time.Hour)
+	task := models.Task{
+		ID:               primitive.NewObjectID(),
+		Title:            "Fix a bike",
+		StartAt:          start,
+		EndAt:            start.Add(2 * time.Hour),
+		DurationMinutes:  120,
+		EstimatedPayRate: 15,
+		PeopleNeeded:     1,
+		CreatorEmail:     "poster@example.com",
+		Status:           models.InProgress,
+		Applicants:       []string{"worker@example.com"},
+		SelectedUsers:    []string{"worker@example.com"},
+		Version:          1,
+	}
+	_, err := db.Collection("tasks").InsertOne(context.Background(), task)
+	assert.NoError(t, err)
+
+	// The worker's part was already settled, here by a dispute ruling
+	_, err = db.Collection("scheduled_tasks").InsertOne(context.Background(), models.ScheduledTask{
+		TaskID:  task.ID,
+		Title:   task.Title,
+		Poster:  task.CreatorEmail,
+		Worker:  "worker@example.com",
+		StartAt: task.StartAt,
+		EndAt:   task.EndAt,
+		Status:  models.Cancelled,
+	})
+	assert.NoError(t, err)
+
+	_, err = db.Collection("otp").InsertOne(context.Background(), models.TaskCompletionOTP{
+		Email:       "poster@example.com",
+		Code:        "123456",
+		Expires_At:  time.Now().Add(30 * time.Minute),
+		Context:     "task_completion",
+		TaskID:      task.ID,
+		WorkerEmail: "worker@example.com",
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.POST("/validate-task-completion", handlers.ValidateTaskCompletionOTP)
+
+	w, _ := performJSON(router, http.MethodPost, "/validate-task-completion", gin.H{
+		"task_id": task.ID.Hex(),
+		"email":   "poster@example.com",
+		"otp":     "123456",
+	})
+	assert.Equal(t, http.StatusConflict, w.Code, "A settled assignment can't be completed: %s", w.Body.String())
+
+	released, err := db.Collection("ledger").CountDocuments(context.Background(), bson.M{"kind": models.LedgerEscrowRelease})
+	assert.NoError(t, err)
+	assert.Zero(t, released, "Nothing should be released from escrow")
+	assert.Equal(t, models.InProgress, findTaskStatus(t, db, task.ID))
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/handlers"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// setupFeedFilterTest seeds a viewer and a few open tasks that differ in pay, wording and place
+func setupFeedFilterTest(t *testing.T) *gin.Engine {
+	db := setupHandlerDatabase(t, "ufpeerassist_test_feed_filter")
+
+	seedUsers(t, db,
+		models.Users{Name: "Poster", Email: "poster@example.com"},
+		models.Users{Name: "Viewer", Email: "viewer@example.com"},
+		models.Users{Name: "Other", Email: "other@example.com"},
+	)
+
+	start := time.Now().Add(72 * time.Hour)
+	campus := &models.GeoPoint{Latitude: 29.6436, Longitude: -82.3549}
+	orlando := &models.GeoPoint{Latitude: 28.5383, Longitude: -81.3792}
+	tasks := []models.Task{
+		{Title: "Calculus tutoring", Description: "Help before the exam", EstimatedPayRate: 25, Coordinates: campus},
+		{Title: "Lawn mowing", Description: "Front yard only", EstimatedPayRate: 12, Coordinates: campus},
+		{Title: "Calculus homework", Description: "Weekly problem sets", EstimatedPayRate: 20, Coordinates: orlando},
+	}
+	for _, task := range tasks {
+		task.ID = primitive.NewObjectID()
+		task.StartAt = start
+		task.EndAt = start.Add(time.Hour)
+		task.TaskDate = start
+		task.PeopleNeeded = 1
+		task.CreatorEmail = "poster@example.com"
+		task.Status = models.Open
+		task.Version = 1
+		_, err := db.Collection("tasks").InsertOne(context.Background(), task)
+		assert.NoError(t, err, "Seeding a task should succeed")
+	}
+
+	router := gin.New()
+	router.GET("/tasks/feed/:viewer_email", handlers.GetAllTasksForUser)
+	router.POST("/users/:email/saved-searches", handlers.CreateSavedSearch)
+	return router
+}
+
+// feedTitles fetches the viewer's feed and returns the task titles in it, sorted
+func feedTitles(t *testing.T, router *gin.Engine, query string) (int, []string) {
+	w, response := performJSON(router, http.MethodGet, "/tasks/feed/viewer@example.com"+query, nil)
+
+	titles := []string{}
+	tasks, _ := response["tasks"].([]interface{})
+	for _, task := range tasks {
+		fields, _ := task.(map[string]interface{})
+		title, _ := fields["title"].(string)
+		titles = append(titles, title)
+	}
+	sort.Strings(titles)
+	return w.Code, titles
+}
+
+// Test that the feed applies pay range, keyword and distance filters from the query string
+func TestFeedAppliesTaskFilter(t *testing.T) {
+	router := setupFeedFilterTest(t)
+
+	code, titles := feedTitles(t, router, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, titles, 3, "An unfiltered feed shows everything")
+
+	_, titles = feedTitles(t, router, "?min_pay=15&max_pay=22")
+	assert.Equal(t, []string{"Calculus homework"}, titles)
+
+	_, titles = feedTitles(t, router, "?keyword=calculus")
+	assert.Equal(t, []string{"Calculus homework", "Calculus tutoring"}, titles)
+
+	_, titles = feedTitles(t, router, "?keyword=calculus&latitude=29.65&longitude=-82.33&radius_km=10")
+	assert.Equal(t, []string{"Calculus tutoring"}, titles, "Only the nearby calculus task should show")
+
+	code, _ = feedTitles(t, router, "?min_pay=lots")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = feedTitles(t, router, "?min_pay=30&max_pay=10")
+	assert.Equal(t, http.StatusBadRequest, code, "A reversed pay range is rejected")
+
+	code, _ = feedTitles(t, router, "?latitude=29.65&longitude=-82.33")
+	assert.Equal(t, http.StatusBadRequest, code, "A position needs a radius")
+
+	code, _ = feedTitles(t, router, "?latitude=29.6436&longitude=-82.3549&radius_km=0.05")
+	assert.Equal(t, http.StatusBadRequest, code, "A radius small enough to pin down a task's position is rejected")
+}
+
+// Test that the feed can apply one of the viewer's saved searches, and only their own
+func TestFeedAppliesSavedSearch(t *testing.T) {
+	router := setupFeedFilterTest(t)
+
+	w, response := performJSON(router, http.MethodPost, "/users/viewer@example.com/saved-searches", gin.H{
+		"name":    "Tutoring near campus",
+		"keyword": "calculus",
+		"min_pay": 22,
+		"alert":   "none",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code, "Saving the search should succeed: %s", w.Body.String())
+	search, _ := response["saved_search"].(map[string]interface{})
+	searchID, _ := search["id"].(string)
+
+	code, titles := feedTitles(t, router, "?saved_search="+searchID)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"Calculus tutoring"}, titles)
+
+	w, _ = performJSON(router, http.MethodGet, "/tasks/feed/other@example.com?saved_search="+searchID, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, "Another user's saved search can't be used")
+
+	code, _ = feedTitles(t, router, "?saved_search=not-an-id")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
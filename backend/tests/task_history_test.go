@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/handlers"
+	"ufpeerassist/backend/api/middleware"
+	"ufpeerassist/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// historyEvents reads the events of a task history response
+func historyEvents(response gin.H) []map[string]interface{} {
+	events := []map[string]interface{}{}
+	list, _ := response["events"].([]interface{})
+	for _, item := range list {
+		event, _ := item.(map[string]interface{})
+		events = append(events, event)
+	}
+	return events
+}
+
+// eventSnapshot reads the before or after snapshot of an event
+func eventSnapshot(event map[string]interface{}, side string) map[string]interface{} {
+	snapshot, _ := event[side].(map[string]interface{})
+	return snapshot
+}
+
+// Test that creating, editing and accepting are logged with their changes and request IDs, and
+// that the history is only shown in full to the poster and moderators
+func TestTaskHistory(t *testing.T) {
+	db := setupHandlerDatabase(t, "ufpeerassist_test_task_history")
+
+	seedUsers(t, db,
+		models.Users{Name: "Poster", Email: "poster@example.com"},
+		models.Users{Name: "Worker", Email: "worker@example.com"},
+		models.Users{Name: "Other", Email: "other@example.com"},
+		models.Users{Name: "Stranger", Email: "stranger@example.com"},
+		models.Users{Name: "Moderator", Email: "moderator@example.com", Role: models.UserRoleModerator},
+	)
+
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.POST("/tasks", handlers.CreateTask)
+	router.PATCH("/tasks/:task_id", handlers.UpdateTask)
+	router.POST("/tasks/:task_id/apply/:email", handlers.ApplyForTask)
+	router.POST("/tasks/:task_id/accept/:email", handlers.AcceptTask)
+	router.GET("/tasks/:task_id/history/:email", handlers.GetTaskHistory)
+
+	w, response := performJSON(router, http.MethodPost, "/tasks", gin.H{
+		"creator_email":      "poster@example.com",
+		"title":              "Rake leaves",
+		"description":        "Front and back yard",
+		"estimated_pay_rate": 15,
+		"place_of_work":      "12 Oak St, Gainesville, FL",
+		"latitude":           29.6436,
+		"longitude":          -82.3549,
+		"work_type":          "Gardening",
+		"people_needed":      1,
+		"start_at":           time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339),
+		"duration_minutes":   120,
+	})
+	if !assert.Equal(t, http.StatusCreated, w.Code, "Creating should succeed: %s", w.Body.String()) {
+		return
+	}
+	taskID, _ := response["task_id"].(string)
+	createdRequest := w.Header().Get(middleware.RequestIDHeader)
+
+	w, _ = performJSON(router, http.MethodPatch, "/tasks/"+taskID, gin.H{"email": "poster@example.com", "version": 1, "title": "Rake all the leaves"})
+	assert.Equal(t, http.StatusOK, w.Code, "Editing should succeed: %s", w.Body.String())
+	editedRequest := w.Header().Get(middleware.RequestIDHeader)
+
+	for _, applicant := range []string{"worker@example.com", "other@example.com"} {
+		w, _ = performJSON(router, http.MethodPost, "/tasks/"+taskID+"/apply/"+applicant, nil)
+		assert.Equal(t, http.StatusOK, w.Code, "Applying should succeed: %s", w.Body.String())
+	}
+
+	w, _ = performJSON(router, http.MethodPost, "/tasks/"+taskID+"/accept/worker@example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code, "Accepting should succeed: %s", w.Body.String())
+	acceptedRequest := w.Header().Get(middleware.RequestIDHeader)
+
+	w, response = performJSON(router, http.MethodGet, "/tasks/"+taskID+"/history/poster@example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	events := historyEvents(response)
+	if !assert.Len(t, events, 5) {
+		return
+	}
+
+	created, edited, accepted := events[0], events[1], events[4]
+	assert.Equal(t, string(models.TaskCreated), created["type"])
+	assert.Equal(t, createdRequest, created["request_id"])
+	assert.Nil(t, created["before"], "A new task has nothing before it")
+	assert.Equal(t, "Rake leaves", eventSnapshot(created, "after")["title"])
+	assert.Equal(t, "12 Oak St, Gainesville, FL", eventSnapshot(created, "after")["place_of_work"])
+
+	assert.Equal(t, string(models.TaskEdited), edited["type"])
+	assert.Equal(t, editedRequest, edited["request_id"])
+	assert.Equal(t, "Rake leaves", eventSnapshot(edited, "before")["title"])
+	assert.Equal(t, "Rake all the leaves", eventSnapshot(edited, "after")["title"])
+	assert.NotContains(t, eventSnapshot(edited, "after"), "description", "Only changed fields are recorded")
+
+	assert.Equal(t, string(models.TaskApplied), events[2]["type"])
+	assert.Equal(t, string(models.TaskAccepted), accepted["type"])
+	assert.Equal(t, acceptedRequest, accepted["request_id"])
+	assert.Equal(t, "poster@example.com", accepted["actor"])
+	assert.Empty(t, eventSnapshot(accepted, "before")["selected_users"])
+	assert.Equal(t, []interface{}{"worker@example.com"}, eventSnapshot(accepted, "after")["selected_users"])
+
+	w, _ = performJSON(router, http.MethodGet, "/tasks/"+taskID+"/history/stranger@example.com", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code, "Someone with no part in the task can't see its history")
+
+	w, _ = performJSON(router, http.MethodGet, "/tasks/"+taskID+"/history/other@example.com", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code, "An applicant who wasn't selected can't see the history")
+
+	w, response = performJSON(router, http.MethodGet, "/tasks/"+taskID+"/history/moderator@example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	if events := historyEvents(response); assert.Len(t, events, 5) {
+		assert.Equal(t, []interface{}{"worker@example.com"}, eventSnapshot(events[4], "after")["selected_users"], "Moderators see the full history")
+	}
+
+	w, response = performJSON(router, http.MethodGet, "/tasks/"+taskID+"/history/worker@example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code, "The selected worker can see the history")
+	events = historyEvents(response)
+	if assert.Len(t, events, 5) {
+		assert.Equal(t, "Rake leaves", eventSnapshot(events[0], "after")["title"], "Workers still see what changed")
+		for _, event := range events {
+			for _, side := range []string{"before", "after"} {
+				for _, field := range []string{"applicants", "selected_users", "place_of_work", "coordinates"} {
+					assert.NotContains(t, eventSnapshot(event, side), field, "%s %s shouldn't show %s to a worker", event["type"], side, field)
+				}
+			}
+		}
+	}
+}
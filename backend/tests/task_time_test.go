@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test parsing the free-form task_time strings found on existing tasks
+func TestParseTimeOfDay(t *testing.T) {
+	cases := []struct {
+		input  string
+		hour   int
+		minute int
+		ok     bool
+	}{
+		{"14:00", 14, 0, true},
+		{"09:30", 9, 30, true},
+		{"9", 9, 0, true},
+		{"2 PM", 14, 0, true},
+		{"2:15pm", 14, 15, true},
+		{"12 am", 0, 0, true},
+		{"12pm", 12, 0, true},
+		{"10:00 - 12:00", 10, 0, true},
+		{"noon", 12, 0, true},
+		{"16:00:00", 16, 0, true},
+		{"25:00", 0, 0, false},
+		{"13 pm", 0, 0, false},
+		{"sometime in the morning", 0, 0, false},
+		{"", 0, 0, false},
+	}
+
+	for _, tc := range cases {
+		hour, minute, ok := utils.ParseTimeOfDay(tc.input)
+		assert.Equal(t, tc.ok, ok, "Unexpected parse result for %q", tc.input)
+		if tc.ok {
+			assert.Equal(t, tc.hour, hour, "Unexpected hour for %q", tc.input)
+			assert.Equal(t, tc.minute, minute, "Unexpected minute for %q", tc.input)
+		}
+	}
+}
+
+// Test combining a date-only task_date with a time in the task's time zone
+func TestCombineTaskDateTime(t *testing.T) {
+	loc, err := utils.LoadTaskLocation("")
+	assert.NoError(t, err, "Default time zone should load")
+	assert.Equal(t, utils.DefaultTaskTimeZone, loc.String())
+
+	date, _ := time.Parse("2006-01-02", "2025-04-15")
+
+	start, ok := utils.CombineTaskDateTime(date, "2 PM", loc)
+	assert.True(t, ok)
+	// 2 PM EDT is 18:00 UTC
+	assert.Equal(t, time.Date(2025, 4, 15, 18, 0, 0, 0, time.UTC), start.UTC())
+
+	// Unreadable times fall back to local midnight on the same date
+	start, ok = utils.CombineTaskDateTime(date, "whenever", loc)
+	assert.False(t, ok)
+	assert.Equal(t, time.Date(2025, 4, 15, 4, 0, 0, 0, time.UTC), start.UTC())
+
+	// The stored task_date stays the local calendar date, even late in the evening
+	late, _ := utils.CombineTaskDateTime(date, "23:30", loc)
+	assert.Equal(t, date, utils.TaskDateOf(late, loc))
+}
+
+// Test parsing start datetimes with and without an offset
+func TestParseTaskStart(t *testing.T) {
+	loc, _ := utils.LoadTaskLocation("America/Los_Angeles")
+
+	withOffset, err := utils.ParseTaskStart("2025-04-20T15:30:00Z", loc)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 4, 20, 15, 30, 0, 0, time.UTC), withOffset.UTC())
+
+	local, err := utils.ParseTaskStart("2025-04-20T15:30", loc)
+	assert.NoError(t, err)
+	// 15:30 PDT is 22:30 UTC
+	assert.Equal(t, time.Date(2025, 4, 20, 22, 30, 0, 0, time.UTC), local.UTC())
+
+	_, err = utils.ParseTaskStart("April 20th", loc)
+	assert.Error(t, err)
+
+	_, err = utils.LoadTaskLocation("Mars/Olympus_Mons")
+	assert.Error(t, err)
+}
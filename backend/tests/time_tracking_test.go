@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"testing"
+	"time"
+	"ufpeerassist/backend/api/utils"
+	"ufpeerassist/backend/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that paused time is left out of the worked total and a running segment counts up to now
+func TestWorkedMinutes(t *testing.T) {
+	start := time.Date(2025, 4, 20, 13, 0, 0, 0, time.UTC)
+	pausedAt := start.Add(50 * time.Minute)
+	resumedAt := start.Add(65 * time.Minute)
+	now := start.Add(95 * time.Minute)
+
+	assignment := models.ScheduledTask{
+		Segments: []models.WorkSegment{
+			{Start: start, End: &pausedAt},
+			{Start: resumedAt},
+		},
+	}
+
+	assert.Equal(t, 80, assignment.WorkedMinutes(now), "Expected 50 + 30 minutes of work")
+	assert.Equal(t, 0, models.ScheduledTask{}.WorkedMinutes(now), "Expected no time before checking in")
+}
+
+// Test that approved time takes precedence over logged time
+func TestPayableMinutes(t *testing.T) {
+	approved := 45
+	assert.Equal(t, 60, models.ScheduledTask{LoggedMinutes: 60}.PayableMinutes(), "Expected logged time before approval")
+	assert.Equal(t, 45, models.ScheduledTask{LoggedMinutes: 60, ApprovedMinutes: &approved}.PayableMinutes(), "Expected approved time")
+}
+
+// Test converting minutes at an hourly rate into an amount due
+func TestAmountDue(t *testing.T) {
+	cases := []struct {
+		minutes int
+		rate    float64
+		amount  float64
+	}{
+		{60, 20, 20},
+		{90, 15, 22.5},
+		{50, 25.5, 21.25},
+		{0, 20, 0},
+		{30, 0, 0},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.amount, utils.AmountDue(tc.minutes, tc.rate), "Unexpected amount for %d minutes at %.2f", tc.minutes, tc.rate)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -136,31 +137,25 @@ func (vt *ValidateTaskCompletionTest) mockValidateTaskCompletionOTP(c *gin.Conte
 
 	// Transaction Function
 	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
-		// Update task status to Completed
-		_, err = vt.db.Collection("tasks").UpdateOne(
+		// Complete only this worker's assignment
+		result, err := vt.db.Collection("scheduled_tasks").UpdateOne(
 			sessCtx,
-			bson.M{"_id": objectID},
-			bson.M{"$set": bson.M{
-				"status":     models.Completed,
-				"updated_at": time.Now(),
-			}},
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		// Update scheduled task status
-		_, err = vt.db.Collection("scheduled_tasks").UpdateOne(
-			sessCtx,
-			bson.M{"task_id": objectID},
+			bson.M{
+				"task_id":      objectID,
+				"worker_email": storedOTP.WorkerEmail,
+				"status":       bson.M{"$nin": []models.TaskStatus{models.Completed, models.Cancelled}},
+			},
 			bson.M{"$set": bson.M{
-				"status":       "Completed",
+				"status":       models.Completed,
 				"completed_at": time.Now(),
 			}},
 		)
 		if err != nil {
 			return nil, err
 		}
+		if result.MatchedCount == 0 {
+			return nil, errTestAssignmentNotActive
+		}
 
 		// Increment completed tasks count for the worker
 		_, err = vt.db.Collection("users").UpdateOne(
@@ -176,31 +171,70 @@ func (vt *ValidateTaskCompletionTest) mockValidateTaskCompletionOTP(c *gin.Conte
 		_, err = vt.db.Collection("otp").DeleteOne(
 			sessCtx,
 			bson.M{
-				"email":   request.Email,
-				"task_id": objectID,
-				"context": "task_completion",
+				"email":        request.Email,
+				"task_id":      objectID,
+				"context":      "task_completion",
+				"worker_email": storedOTP.WorkerEmail,
 			},
 		)
 		if err != nil {
 			return nil, err
 		}
 
-		return nil, nil
+		// The task itself is completed once no assignment is left open
+		active, err := vt.db.Collection("scheduled_tasks").CountDocuments(sessCtx, bson.M{
+			"task_id": objectID,
+			"status":  bson.M{"$nin": []models.TaskStatus{models.Completed, models.Cancelled}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if active > 0 {
+			return false, nil
+		}
+
+		_, err = vt.db.Collection("tasks").UpdateOne(
+			sessCtx,
+			bson.M{"_id": objectID},
+			bson.M{"$set": bson.M{
+				"status":     models.Completed,
+				"updated_at": time.Now(),
+			}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		return true, nil
 	}
 
 	// Execute transaction
-	_, err = session.WithTransaction(vt.ctx, callback)
+	completed, err := session.WithTransaction(vt.ctx, callback)
+	if errors.Is(err, errTestAssignmentNotActive) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This worker's assignment is already completed or was released"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed", "details": err.Error()})
 		return
 	}
+	taskCompleted := completed.(bool)
+
+	message := "Task completed successfully!"
+	if !taskCompleted {
+		message = "Worker's part of the task completed. The task completes once every worker has finished"
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Task completed successfully!",
-		"task_id": request.TaskID,
+		"message":        message,
+		"task_id":        request.TaskID,
+		"worker_email":   storedOTP.WorkerEmail,
+		"task_completed": taskCompleted,
 	})
 }
 
+// errTestAssignmentNotActive mirrors the handler's error for an already completed assignment
+var errTestAssignmentNotActive = errors.New("assignment is not active")
+
 // Seed the test database with initial data
 func (vt *ValidateTaskCompletionTest) seedTestData(t *testing.T) {
 	// Insert test users
@@ -357,7 +391,7 @@ func TestValidateTaskCompletionSuccess(t *testing.T) {
 	var worker models.Users
 	err = vt.db.Collection("users").FindOne(vt.ctx, bson.M{"email": "worker@example.com"}).Decode(&worker)
 	assert.NoError(t, err, "Worker should exist in the database")
-	assert.Equal(t, 5, worker.CompletedTasks, "Worker's completed tasks count should be incremented")
+	assert.Equal(t, 6, worker.CompletedTasks, "Worker's completed tasks count should be incremented")
 
 	// Verify OTP was deleted after successful validation
 	count, err := vt.db.Collection("otp").CountDocuments(vt.ctx, bson.M{
@@ -505,3 +539,112 @@ func TestValidateTaskCompletionMissingFields(t *testing.T) {
 	assert.NoError(t, err, "Failed to parse response body")
 	assert.Equal(t, "Invalid request", response.Error)
 }
+
+// Test that a multi-person task completes only after every worker's assignment is validated
+func TestValidateTaskCompletionMultipleWorkers(t *testing.T) {
+	// Initialize test environment
+	vt := &ValidateTaskCompletionTest{}
+	vt.Initialize(t)
+	defer vt.Cleanup(t)
+
+	now := time.Now()
+	taskID := primitive.NewObjectID()
+	workers := []string{"worker@example.com", "second@example.com"}
+
+	_, err := vt.db.Collection("users").InsertOne(vt.ctx, bson.M{
+		"name":            "Second Worker",
+		"email":           "second@example.com",
+		"completed_tasks": 0,
+	})
+	assert.NoError(t, err, "Failed to seed second worker")
+
+	_, err = vt.db.Collection("tasks").InsertOne(vt.ctx, models.Task{
+		ID:            taskID,
+		Title:         "Two Person Task",
+		Description:   "Needs two workers",
+		WorkType:      models.Cleaning,
+		PeopleNeeded:  2,
+		CreatorEmail:  "taskowner@example.com",
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Status:        models.Open,
+		Applicants:    workers,
+		SelectedUsers: workers,
+	})
+	assert.NoError(t, err, "Failed to seed task")
+
+	codes := map[string]string{"worker@example.com": "111111", "second@example.com": "222222"}
+	for _, worker := range workers {
+		_, err = vt.db.Collection("scheduled_tasks").InsertOne(vt.ctx, models.ScheduledTask{
+			TaskID:      taskID,
+			Title:       "Two Person Task",
+			Poster:      "taskowner@example.com",
+			Worker:      worker,
+			ScheduledAt: now,
+		})
+		assert.NoError(t, err, "Failed to seed scheduled task")
+
+		_, err = vt.db.Collection("otp").InsertOne(vt.ctx, TaskCompletionOTP{
+			Email:       "taskowner@example.com",
+			Code:        codes[worker],
+			Expires_At:  now.Add(30 * time.Minute),
+			Context:     "task_completion",
+			TaskID:      taskID,
+			WorkerEmail: worker,
+		})
+		assert.NoError(t, err, "Failed to seed OTP")
+	}
+
+	validate := func(code string) (int, bool) {
+		jsonData, _ := json.Marshal(map[string]string{
+			"task_id": taskID.Hex(),
+			"email":   "taskowner@example.com",
+			"otp":     code,
+		})
+		req, _ := http.NewRequest("POST", "/validate-task-completion", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		vt.router.ServeHTTP(w, req)
+
+		var response struct {
+			TaskCompleted bool `json:"task_completed"`
+		}
+		_ = json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response.TaskCompleted
+	}
+
+	completedTasks := func(email string) int {
+		var user bson.M
+		err := vt.db.Collection("users").FindOne(vt.ctx, bson.M{"email": email}).Decode(&user)
+		assert.NoError(t, err, "User should exist in the database")
+		count, _ := user["completed_tasks"].(int32)
+		return int(count)
+	}
+
+	// First worker finishes: their assignment completes, the task stays open
+	code, taskCompleted := validate("111111")
+	assert.Equal(t, http.StatusOK, code, "Expected status 200 OK for first worker")
+	assert.False(t, taskCompleted, "Task should not complete while a worker is still assigned")
+
+	var task models.Task
+	err = vt.db.Collection("tasks").FindOne(vt.ctx, bson.M{"_id": taskID}).Decode(&task)
+	assert.NoError(t, err, "Task should exist in the database")
+	assert.Equal(t, models.Open, task.Status, "Task should stay open after the first worker")
+	assert.Equal(t, 0, completedTasks("second@example.com"), "Second worker's count should not change yet")
+
+	var second models.ScheduledTask
+	err = vt.db.Collection("scheduled_tasks").FindOne(vt.ctx, bson.M{"task_id": taskID, "worker_email": "second@example.com"}).Decode(&second)
+	assert.NoError(t, err, "Second worker's assignment should exist")
+	assert.Empty(t, second.Status, "Second worker's assignment should still be active")
+
+	// Second worker finishes: now the whole task is completed
+	code, taskCompleted = validate("222222")
+	assert.Equal(t, http.StatusOK, code, "Expected status 200 OK for second worker")
+	assert.True(t, taskCompleted, "Task should complete after the last worker")
+
+	err = vt.db.Collection("tasks").FindOne(vt.ctx, bson.M{"_id": taskID}).Decode(&task)
+	assert.NoError(t, err, "Task should exist in the database")
+	assert.Equal(t, models.Completed, task.Status, "Task should be completed")
+	assert.Equal(t, 1, completedTasks("second@example.com"), "Second worker's count should be incremented once")
+}
@@ -67,13 +67,13 @@ const PostTask = () => {
     const token = localStorage.getItem("token");
     
     try {
-      const response = await fetch(`http://localhost:8080/users/${userEmail}/post_task`, {
+      const response = await fetch(`http://localhost:8080/tasks`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
           "Authorization": `Bearer ${token}`
         },
-        body: JSON.stringify(formData)
+        body: JSON.stringify({ ...formData, creator_email: userEmail })
       });
 
       const data = await response.json();